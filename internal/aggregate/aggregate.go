@@ -0,0 +1,253 @@
+package aggregate
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/johanneserhardt/cxusage/internal/types"
+)
+
+// Dimensions lists all supported dimensions in display order
+var Dimensions = []types.Dimension{
+	types.DimensionDay,
+	types.DimensionWeek,
+	types.DimensionMonth,
+	types.DimensionHour,
+	types.DimensionModel,
+	types.DimensionProject,
+	types.DimensionSession,
+	types.DimensionSource,
+	types.DimensionUser,
+	types.DimensionReasoningEffort,
+	types.DimensionProvider,
+	types.DimensionAgent,
+}
+
+// ParseDimensions parses a comma-separated list of dimensions such as "week,project"
+func ParseDimensions(spec string) ([]types.Dimension, error) {
+	return parseDimensions(spec, Dimensions)
+}
+
+// parseDimensions parses a comma-separated list of dimensions out of the allowed ones
+func parseDimensions(spec string, allowed []types.Dimension) ([]types.Dimension, error) {
+	var dims []types.Dimension
+	seen := make(map[types.Dimension]bool)
+	for _, part := range strings.Split(spec, ",") {
+		name := strings.ToLower(strings.TrimSpace(part))
+		if name == "" {
+			continue
+		}
+		if name == "effort" {
+			name = string(types.DimensionReasoningEffort)
+		}
+
+		dim := types.Dimension(name)
+		if !containsDimension(allowed, dim) {
+			names := make([]string, len(allowed))
+			for i, d := range allowed {
+				names[i] = string(d)
+			}
+			return nil, fmt.Errorf("unknown group-by dimension: %s (expected %s)", part, strings.Join(names, ", "))
+		}
+		if seen[dim] {
+			return nil, fmt.Errorf("duplicate group-by dimension: %s", name)
+		}
+		seen[dim] = true
+		dims = append(dims, dim)
+	}
+	if len(dims) == 0 {
+		return nil, fmt.Errorf("at least one group-by dimension is required")
+	}
+	return dims, nil
+}
+
+func containsDimension(dims []types.Dimension, dim types.Dimension) bool {
+	for _, d := range dims {
+		if d == dim {
+			return true
+		}
+	}
+	return false
+}
+
+// Value returns the value of a dimension for an entry. Time dimensions use local time,
+// like the daily and monthly reports.
+func Value(entry types.CodexUsageEntry, dim types.Dimension) string {
+	local := entry.Timestamp.Local()
+	switch dim {
+	case types.DimensionDay:
+		return local.Format("2006-01-02")
+	case types.DimensionWeek:
+		year, week := local.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case types.DimensionMonth:
+		return local.Format("2006-01")
+	case types.DimensionHour:
+		return local.Format("2006-01-02 15:00")
+	case types.DimensionModel:
+		return entry.Model
+	case types.DimensionProject:
+		if entry.ProjectPath == "" {
+			return ""
+		}
+		return filepath.Base(entry.ProjectPath)
+	case types.DimensionSession:
+		if entry.SessionID == "" {
+			return entry.EntryID
+		}
+		return entry.SessionID
+	case types.DimensionSource:
+		return entry.Source
+	case types.DimensionUser:
+		return entry.User
+	case types.DimensionReasoningEffort:
+		return entry.ReasoningEffort
+	case types.DimensionProvider:
+		return entry.Provider
+	case types.DimensionAgent:
+		// Entries from before agents were recorded all come from Codex
+		if entry.Agent == "" {
+			return types.AgentCodex
+		}
+		return entry.Agent
+	}
+	return ""
+}
+
+// NewTotals returns empty totals
+func NewTotals() types.UsageTotals {
+	return types.UsageTotals{
+		ModelUsage: make(map[string]types.Usage),
+		ModelCosts: make(map[string]float64),
+		Models:     []string{},
+	}
+}
+
+// Add accumulates an entry into totals
+func Add(totals *types.UsageTotals, entry types.CodexUsageEntry) {
+	totals.RequestCount++
+	totals.InputTokens += entry.Usage.PromptTokens
+	totals.OutputTokens += entry.Usage.CompletionTokens
+	totals.CacheCreationTokens += entry.Usage.CacheCreationTokens
+	totals.CacheReadTokens += entry.Usage.CacheReadTokens
+	totals.TotalTokens += entry.Usage.TotalTokens
+	totals.TotalCost += entry.Cost
+	AddEstimation(&totals.Estimation, entry, totals.TotalCost, totals.TotalTokens)
+
+	if AddModelUsage(totals.ModelUsage, totals.ModelCosts, entry) {
+		totals.Models = append(totals.Models, entry.Model)
+	}
+
+	if totals.FirstSeen.IsZero() || entry.Timestamp.Before(totals.FirstSeen) {
+		totals.FirstSeen = entry.Timestamp
+	}
+	if entry.Timestamp.After(totals.LastSeen) {
+		totals.LastSeen = entry.Timestamp
+	}
+}
+
+// AddEstimation accumulates the estimated usage of an entry and updates the estimated
+// percent of the totals it was added to
+func AddEstimation(est *types.Estimation, entry types.CodexUsageEntry, totalCost float64, totalTokens int) {
+	if entry.Provenance == types.ProvenanceEstimatedTokens {
+		est.EstimatedTokens += entry.Usage.TotalTokens
+	}
+	if entry.Provenance.CostEstimated() {
+		est.EstimatedCost += entry.Cost
+	}
+	SetEstimatedPercent(est, totalCost, totalTokens)
+}
+
+// SetEstimatedPercent sets the estimated percent of the cost, or of the tokens when the
+// usage cost nothing
+func SetEstimatedPercent(est *types.Estimation, totalCost float64, totalTokens int) {
+	switch {
+	case totalCost > 0:
+		est.EstimatedPercent = est.EstimatedCost / totalCost * 100
+	case totalTokens > 0:
+		est.EstimatedPercent = float64(est.EstimatedTokens) / float64(totalTokens) * 100
+	default:
+		est.EstimatedPercent = 0
+	}
+}
+
+// AddModelUsage accumulates an entry into per-model usage and costs and reports whether
+// the model was seen for the first time
+func AddModelUsage(usage map[string]types.Usage, costs map[string]float64, entry types.CodexUsageEntry) bool {
+	modelUsage, exists := usage[entry.Model]
+	modelUsage.PromptTokens += entry.Usage.PromptTokens
+	modelUsage.CompletionTokens += entry.Usage.CompletionTokens
+	modelUsage.CacheCreationTokens += entry.Usage.CacheCreationTokens
+	modelUsage.CacheReadTokens += entry.Usage.CacheReadTokens
+	modelUsage.TotalTokens += entry.Usage.TotalTokens
+	usage[entry.Model] = modelUsage
+	costs[entry.Model] += entry.Cost
+	return !exists
+}
+
+// GroupBy groups entries by the given dimensions and returns the groups sorted by their
+// dimension values
+func GroupBy(entries []types.CodexUsageEntry, dims []types.Dimension) []types.UsageGroup {
+	groups := make(map[string]*types.UsageGroup)
+	for _, entry := range entries {
+		values := make([]string, len(dims))
+		for i, dim := range dims {
+			values[i] = Value(entry, dim)
+		}
+		key := strings.Join(values, "\x00")
+
+		group, ok := groups[key]
+		if !ok {
+			group = &types.UsageGroup{
+				Keys:        make(map[types.Dimension]string, len(dims)),
+				Values:      values,
+				UsageTotals: NewTotals(),
+			}
+			for i, dim := range dims {
+				group.Keys[dim] = values[i]
+			}
+			groups[key] = group
+		}
+		Add(&group.UsageTotals, entry)
+	}
+
+	result := make([]types.UsageGroup, 0, len(groups))
+	for _, group := range groups {
+		result = append(result, *group)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return lessValues(result[i].Values, result[j].Values)
+	})
+	return result
+}
+
+func lessValues(a, b []string) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
+}
+
+// SortGroups orders groups by cost, tokens or requests (descending), or by their
+// dimension values for "key"
+func SortGroups(groups []types.UsageGroup, by string) error {
+	var less func(a, b types.UsageGroup) bool
+	switch by {
+	case "", "key":
+		less = func(a, b types.UsageGroup) bool { return lessValues(a.Values, b.Values) }
+	case "cost":
+		less = func(a, b types.UsageGroup) bool { return a.TotalCost > b.TotalCost }
+	case "tokens":
+		less = func(a, b types.UsageGroup) bool { return a.TotalTokens > b.TotalTokens }
+	case "requests":
+		less = func(a, b types.UsageGroup) bool { return a.RequestCount > b.RequestCount }
+	default:
+		return fmt.Errorf("unsupported sort order: %s (expected key, cost, tokens or requests)", by)
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return less(groups[i], groups[j]) })
+	return nil
+}
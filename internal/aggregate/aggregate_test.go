@@ -0,0 +1,136 @@
+package aggregate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/johanneserhardt/cxusage/internal/types"
+)
+
+func mkEntry(ts time.Time, model, project string, tokens int, cost float64) types.CodexUsageEntry {
+	return types.CodexUsageEntry{
+		Timestamp:   ts,
+		Model:       model,
+		ProjectPath: project,
+		Usage:       types.Usage{PromptTokens: tokens, TotalTokens: tokens},
+		Cost:        cost,
+	}
+}
+
+func TestGroupByWeekAndProject(t *testing.T) {
+	monday := time.Date(2026, 10, 12, 10, 0, 0, 0, time.Local)
+	entries := []types.CodexUsageEntry{
+		mkEntry(monday, "gpt-5", "/src/api", 100, 1),
+		mkEntry(monday.Add(24*time.Hour), "gpt-5-mini", "/src/api", 50, 0.5),
+		mkEntry(monday.Add(48*time.Hour), "gpt-5", "/src/web", 10, 0.1),
+		mkEntry(monday.AddDate(0, 0, 7), "gpt-5", "/src/api", 1, 0.01),
+	}
+
+	groups := GroupBy(entries, []types.Dimension{types.DimensionWeek, types.DimensionProject})
+	if len(groups) != 3 {
+		t.Fatalf("expected 3 groups, got %d", len(groups))
+	}
+
+	first := groups[0]
+	if first.Keys[types.DimensionWeek] != "2026-W42" || first.Keys[types.DimensionProject] != "api" {
+		t.Errorf("unexpected first group keys %v", first.Keys)
+	}
+	if first.RequestCount != 2 || first.TotalTokens != 150 || first.TotalCost != 1.5 {
+		t.Errorf("unexpected totals %+v", first.UsageTotals)
+	}
+	if len(first.Models) != 2 || first.ModelUsage["gpt-5-mini"].TotalTokens != 50 || first.ModelCosts["gpt-5"] != 1 {
+		t.Errorf("unexpected model breakdown %+v", first.UsageTotals)
+	}
+	if !first.FirstSeen.Equal(monday) || !first.LastSeen.Equal(monday.Add(24*time.Hour)) {
+		t.Errorf("unexpected time range %v - %v", first.FirstSeen, first.LastSeen)
+	}
+	if groups[2].Keys[types.DimensionWeek] != "2026-W43" {
+		t.Errorf("expected groups sorted by week, got %v", groups[2].Keys)
+	}
+}
+
+func TestGroupByAgent(t *testing.T) {
+	now := time.Date(2026, 10, 18, 10, 0, 0, 0, time.Local)
+	claude := mkEntry(now, "claude-sonnet-4-5", "/src/api", 100, 1)
+	claude.Agent = types.AgentClaude
+	claude.Usage.CacheReadTokens = 1000
+	entries := []types.CodexUsageEntry{mkEntry(now, "gpt-5", "/src/api", 10, 0.1), claude}
+
+	groups := GroupBy(entries, []types.Dimension{types.DimensionAgent})
+	if len(groups) != 2 || groups[0].Values[0] != "claude" || groups[1].Values[0] != "codex" {
+		t.Fatalf("unexpected groups %+v", groups)
+	}
+	if groups[0].CacheReadTokens != 1000 || groups[0].ModelUsage["claude-sonnet-4-5"].CacheReadTokens != 1000 {
+		t.Errorf("cache tokens not summed: %+v", groups[0].UsageTotals)
+	}
+}
+
+func TestSortGroups(t *testing.T) {
+	now := time.Date(2026, 10, 12, 10, 0, 0, 0, time.Local)
+	entries := []types.CodexUsageEntry{
+		mkEntry(now, "a", "", 100, 0.1),
+		mkEntry(now, "b", "", 10, 5),
+	}
+	groups := GroupBy(entries, []types.Dimension{types.DimensionModel})
+
+	if err := SortGroups(groups, "cost"); err != nil || groups[0].Keys[types.DimensionModel] != "b" {
+		t.Errorf("expected the most expensive model first, got %v (%v)", groups[0].Keys, err)
+	}
+	if err := SortGroups(groups, "tokens"); err != nil || groups[0].Keys[types.DimensionModel] != "a" {
+		t.Errorf("expected the model with most tokens first, got %v (%v)", groups[0].Keys, err)
+	}
+	if err := SortGroups(groups, "size"); err == nil {
+		t.Error("expected an error for an unknown sort order")
+	}
+}
+
+func TestParseDimensions(t *testing.T) {
+	dims, err := ParseDimensions(" Week, project ,effort")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []types.Dimension{types.DimensionWeek, types.DimensionProject, types.DimensionReasoningEffort}
+	if len(dims) != len(want) {
+		t.Fatalf("expected %v, got %v", want, dims)
+	}
+	for i := range want {
+		if dims[i] != want[i] {
+			t.Errorf("expected %v, got %v", want, dims)
+		}
+	}
+
+	for _, spec := range []string{"", "day,day", "weekday"} {
+		if _, err := ParseDimensions(spec); err == nil {
+			t.Errorf("%q: expected an error", spec)
+		}
+	}
+}
+
+func TestEstimation(t *testing.T) {
+	now := time.Date(2026, 10, 18, 10, 0, 0, 0, time.Local)
+	exact := mkEntry(now, "gpt-5", "/src/api", 300, 3)
+	exact.Provenance = types.ProvenanceExact
+	tokens := mkEntry(now, "gpt-5", "/src/api", 100, 1)
+	tokens.Provenance = types.ProvenanceEstimatedTokens
+	cost := mkEntry(now, "in-house-7b", "/src/api", 100, 1)
+	cost.Provenance = types.ProvenanceEstimatedCost
+
+	totals := NewTotals()
+	for _, e := range []types.CodexUsageEntry{exact, tokens, cost} {
+		Add(&totals, e)
+	}
+	want := types.Estimation{EstimatedTokens: 100, EstimatedCost: 2, EstimatedPercent: 40}
+	if totals.Estimation != want {
+		t.Errorf("got %+v, want %+v", totals.Estimation, want)
+	}
+
+	// Free usage is estimated by its tokens
+	local := NewTotals()
+	tokens.Cost = 0
+	exact.Cost = 0
+	Add(&local, tokens)
+	Add(&local, exact)
+	if local.EstimatedPercent != 25 {
+		t.Errorf("free usage: got %v%%, want 25%%", local.EstimatedPercent)
+	}
+}
@@ -0,0 +1,112 @@
+package aggregate
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/johanneserhardt/cxusage/internal/types"
+)
+
+// ToolDimensions lists the dimensions tool calls can be grouped by
+var ToolDimensions = []types.Dimension{
+	types.DimensionTool,
+	types.DimensionCommand,
+	types.DimensionProject,
+	types.DimensionSession,
+	types.DimensionDay,
+	types.DimensionWeek,
+	types.DimensionMonth,
+	types.DimensionHour,
+	types.DimensionSource,
+	types.DimensionUser,
+}
+
+// ParseToolDimensions parses a comma-separated list of tool call dimensions such as "tool,command"
+func ParseToolDimensions(spec string) ([]types.Dimension, error) {
+	return parseDimensions(spec, ToolDimensions)
+}
+
+// ToolValue returns the value of a dimension for a tool call
+func ToolValue(call types.ToolCall, dim types.Dimension) string {
+	switch dim {
+	case types.DimensionTool:
+		return call.Tool
+	case types.DimensionCommand:
+		return call.Command
+	}
+	return Value(types.CodexUsageEntry{
+		Timestamp:   call.Timestamp,
+		SessionID:   call.SessionID,
+		ProjectPath: call.ProjectPath,
+		Source:      call.Source,
+		User:        call.User,
+	}, dim)
+}
+
+// GroupToolCalls groups tool calls by the given dimensions and returns the groups sorted by
+// their dimension values
+func GroupToolCalls(calls []types.ToolCall, dims []types.Dimension) []types.ToolGroup {
+	groups := make(map[string]*types.ToolGroup)
+	for _, call := range calls {
+		values := make([]string, len(dims))
+		for i, dim := range dims {
+			values[i] = ToolValue(call, dim)
+		}
+		key := strings.Join(values, "\x00")
+
+		group, ok := groups[key]
+		if !ok {
+			group = &types.ToolGroup{
+				Keys:   make(map[types.Dimension]string, len(dims)),
+				Values: values,
+			}
+			for i, dim := range dims {
+				group.Keys[dim] = values[i]
+			}
+			groups[key] = group
+		}
+
+		group.Calls++
+		if call.Failed {
+			group.Failures++
+		}
+		group.DurationMs += call.Duration
+		group.OutputTokens += call.OutputTokens
+		if call.OutputTokens > group.MaxOutputTokens {
+			group.MaxOutputTokens = call.OutputTokens
+		}
+	}
+
+	result := make([]types.ToolGroup, 0, len(groups))
+	for _, group := range groups {
+		group.FailureRate = float64(group.Failures) / float64(group.Calls) * 100
+		result = append(result, *group)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return lessValues(result[i].Values, result[j].Values)
+	})
+	return result
+}
+
+// SortToolGroups orders groups by output tokens, calls, failures or time (descending), or by
+// their dimension values for "key"
+func SortToolGroups(groups []types.ToolGroup, by string) error {
+	var less func(a, b types.ToolGroup) bool
+	switch by {
+	case "key":
+		less = func(a, b types.ToolGroup) bool { return lessValues(a.Values, b.Values) }
+	case "", "tokens":
+		less = func(a, b types.ToolGroup) bool { return a.OutputTokens > b.OutputTokens }
+	case "calls":
+		less = func(a, b types.ToolGroup) bool { return a.Calls > b.Calls }
+	case "failures":
+		less = func(a, b types.ToolGroup) bool { return a.Failures > b.Failures }
+	case "time":
+		less = func(a, b types.ToolGroup) bool { return a.DurationMs > b.DurationMs }
+	default:
+		return fmt.Errorf("unsupported sort order: %s (expected tokens, calls, failures, time or key)", by)
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return less(groups[i], groups[j]) })
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"sort"
 	"time"
 
+	"github.com/johanneserhardt/cxusage/internal/aggregate"
 	"github.com/johanneserhardt/cxusage/internal/types"
 )
 
@@ -38,43 +39,11 @@ func AggregateIntoBlocks(entries []types.CodexUsageEntry, sessionDurationHours i
 		// Get or create block
 		block, exists := blockMap[blockKey]
 		if !exists {
-			block = &types.SessionBlock{
-				StartTime:     blockStartTime,
-				EndTime:       blockStartTime.Add(blockDuration),
-				ActualEndTime: nil,
-				IsActive:      false,
-				IsGap:         false,
-				ModelUsage:    make(map[string]types.Usage),
-				ModelCosts:    make(map[string]float64),
-				Models:        []string{},
-			}
+			block = newSessionBlock(blockStartTime, blockStartTime.Add(blockDuration))
 			blockMap[blockKey] = block
 		}
 
-		// Update block data
-		block.RequestCount++
-		block.TotalTokens += entry.Usage.TotalTokens
-		block.TotalCost += entry.Cost
-		block.InputTokens += entry.Usage.PromptTokens
-		block.OutputTokens += entry.Usage.CompletionTokens
-
-		// Update model usage
-		if modelUsage, exists := block.ModelUsage[entry.Model]; exists {
-			modelUsage.PromptTokens += entry.Usage.PromptTokens
-			modelUsage.CompletionTokens += entry.Usage.CompletionTokens
-			modelUsage.TotalTokens += entry.Usage.TotalTokens
-			block.ModelUsage[entry.Model] = modelUsage
-		} else {
-			block.ModelUsage[entry.Model] = entry.Usage
-			block.Models = append(block.Models, entry.Model)
-		}
-
-		block.ModelCosts[entry.Model] += entry.Cost
-
-		// Update actual end time (use local timestamp)
-		if block.ActualEndTime == nil || localTimestamp.After(*block.ActualEndTime) {
-			block.ActualEndTime = &localTimestamp
-		}
+		addEntryToBlock(block, entry, localTimestamp)
 	}
 
 	// Convert map to sorted slice and determine active blocks
@@ -140,10 +109,46 @@ func AggregateIntoBlocks(entries []types.CodexUsageEntry, sessionDurationHours i
 	return blocks
 }
 
-// floorToBlockStart floors a timestamp to the appropriate block start time
-func floorToBlockStart(timestamp time.Time, sessionDurationHours int) time.Time {
-	// Floor to the hour first
-	floored := time.Date(
+// newSessionBlock creates an empty usage block spanning [start, end)
+func newSessionBlock(start, end time.Time) *types.SessionBlock {
+	return &types.SessionBlock{
+		StartTime:     start,
+		EndTime:       end,
+		ActualEndTime: nil,
+		IsActive:      false,
+		IsGap:         false,
+		ModelUsage:    make(map[string]types.Usage),
+		ModelCosts:    make(map[string]float64),
+		Models:        []string{},
+	}
+}
+
+// addEntryToBlock accumulates a usage entry into a block
+func addEntryToBlock(block *types.SessionBlock, entry types.CodexUsageEntry, localTimestamp time.Time) {
+	block.RequestCount++
+	block.TotalTokens += entry.Usage.TotalTokens
+	block.TotalCost += entry.Cost
+	block.InputTokens += entry.Usage.PromptTokens
+	block.OutputTokens += entry.Usage.CompletionTokens
+	block.CacheCreationTokens += entry.Usage.CacheCreationTokens
+	block.CacheReadTokens += entry.Usage.CacheReadTokens
+	aggregate.AddEstimation(&block.Estimation, entry, block.TotalCost, block.TotalTokens)
+
+	// Update model usage
+	if aggregate.AddModelUsage(block.ModelUsage, block.ModelCosts, entry) {
+		block.Models = append(block.Models, entry.Model)
+	}
+	block.Entries = append(block.Entries, entry)
+
+	// Update actual end time (use local timestamp)
+	if block.ActualEndTime == nil || localTimestamp.After(*block.ActualEndTime) {
+		block.ActualEndTime = &localTimestamp
+	}
+}
+
+// floorToHour floors a timestamp to the start of its hour
+func floorToHour(timestamp time.Time) time.Time {
+	return time.Date(
 		timestamp.Year(),
 		timestamp.Month(),
 		timestamp.Day(),
@@ -151,6 +156,12 @@ func floorToBlockStart(timestamp time.Time, sessionDurationHours int) time.Time
 		0, 0, 0,
 		timestamp.Location(),
 	)
+}
+
+// floorToBlockStart floors a timestamp to the appropriate block start time
+func floorToBlockStart(timestamp time.Time, sessionDurationHours int) time.Time {
+	// Floor to the hour first
+	floored := floorToHour(timestamp)
 
 	// Find the appropriate block boundary (every 5 hours starting from midnight)
 	hourOfDay := floored.Hour()
@@ -241,40 +252,7 @@ func FilterRecentBlocks(blocks []types.SessionBlock, days int) []types.SessionBl
 	return filtered
 }
 
-// CalculateProjections calculates projections for an active block
+// CalculateProjections calculates projections for an active block using the average burn rate
 func CalculateProjections(block *types.SessionBlock) *types.BlockProjection {
-	if !block.IsActive {
-		return nil
-	}
-
-	now := time.Now()
-	timeElapsed := now.Sub(block.StartTime).Minutes()
-	timeRemaining := block.EndTime.Sub(now)
-	
-	if timeElapsed <= 0 || block.TotalTokens == 0 {
-		return &types.BlockProjection{
-			ProjectedTokens: block.TotalTokens,
-			ProjectedCost:   block.TotalCost,
-			BurnRate:        0,
-			TimeRemaining:   timeRemaining,
-		}
-	}
-
-	// Calculate burn rate (tokens per minute)
-	burnRate := float64(block.TotalTokens) / timeElapsed
-	
-	// Project total usage for the full 5-hour block
-	totalMinutesInBlock := block.EndTime.Sub(block.StartTime).Minutes()
-	projectedTokens := int(burnRate * totalMinutesInBlock)
-	
-	// Project cost based on current cost rate
-	costRate := block.TotalCost / timeElapsed
-	projectedCost := costRate * totalMinutesInBlock
-
-	return &types.BlockProjection{
-		ProjectedTokens: projectedTokens,
-		ProjectedCost:   projectedCost,
-		BurnRate:        burnRate,
-		TimeRemaining:   timeRemaining,
-	}
-}
\ No newline at end of file
+	return CalculateProjectionsWithConfig(block, nil, types.ProjectionConfig{Strategy: types.ProjectionAverage}, time.Now())
+}
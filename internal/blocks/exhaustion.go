@@ -0,0 +1,108 @@
+package blocks
+
+import (
+	"time"
+
+	"github.com/johanneserhardt/cxusage/internal/types"
+)
+
+// RateLimitResetTolerance absorbs drift between reset times derived from relative countdowns
+const RateLimitResetTolerance = 2 * time.Minute
+
+// SameRateLimitWindow reports whether two reset times belong to the same rate-limit window
+func SameRateLimitWindow(a, b time.Time) bool {
+	d := a.Sub(b)
+	return d <= RateLimitResetTolerance && d >= -RateLimitResetTolerance
+}
+
+// EstimateLimitExhaustion sets the estimated time the block hits its limit on the projection.
+// Both the configured token limit and the primary plan rate-limit window are considered,
+// and the earliest exhaustion time wins.
+func EstimateLimitExhaustion(projection *types.BlockProjection, block *types.SessionBlock, tokenLimit *int, snapshots []types.RateLimitSnapshot, now time.Time) {
+	if projection == nil {
+		return
+	}
+
+	if tokenLimit != nil && *tokenLimit > 0 {
+		if at := tokenLimitReachedAt(block.TotalTokens, *tokenLimit, projection.BurnRate, block.EndTime, now); at != nil {
+			projection.LimitReachedAt = at
+			projection.LimitType = types.LimitTypeTokens
+		}
+	}
+
+	if at := rateLimitReachedAt(snapshots, now); at != nil {
+		if projection.LimitReachedAt == nil || at.Before(*projection.LimitReachedAt) {
+			projection.LimitReachedAt = at
+			projection.LimitType = types.LimitTypeRateLimit
+		}
+	}
+}
+
+// tokenLimitReachedAt returns when the token limit is hit at the given burn rate (tokens per minute)
+func tokenLimitReachedAt(tokens, limit int, burnRate float64, blockEnd, now time.Time) *time.Time {
+	if tokens >= limit {
+		return &now
+	}
+	if burnRate <= 0 {
+		return nil
+	}
+
+	minutes := float64(limit-tokens) / burnRate
+	at := now.Add(time.Duration(minutes * float64(time.Minute)))
+	if !at.Before(blockEnd) {
+		return nil
+	}
+	return &at
+}
+
+// rateLimitReachedAt extrapolates the primary window's used percentage to 100%.
+// The rate comes from the snapshots of the current window; with a single snapshot the
+// average rate since the window opened is used.
+func rateLimitReachedAt(snapshots []types.RateLimitSnapshot, now time.Time) *time.Time {
+	var latest *types.RateLimitSnapshot
+	for i := range snapshots {
+		if snapshots[i].Primary != nil && (latest == nil || snapshots[i].Timestamp.After(latest.Timestamp)) {
+			latest = &snapshots[i]
+		}
+	}
+	if latest == nil || latest.Primary.ResetsAt == nil || !now.Before(*latest.Primary.ResetsAt) {
+		return nil
+	}
+
+	window := latest.Primary
+	if window.UsedPercent >= 100 {
+		return &latest.Timestamp
+	}
+
+	// Find the earliest snapshot of the same window
+	first := latest
+	for i := range snapshots {
+		s := &snapshots[i]
+		if s.Primary == nil || s.Primary.ResetsAt == nil || s.Source != latest.Source || !s.Timestamp.Before(first.Timestamp) {
+			continue
+		}
+		if SameRateLimitWindow(*s.Primary.ResetsAt, *window.ResetsAt) {
+			first = s
+		}
+	}
+
+	var percentPerMinute float64
+	if elapsed := latest.Timestamp.Sub(first.Timestamp); elapsed >= time.Minute {
+		percentPerMinute = (window.UsedPercent - first.Primary.UsedPercent) / elapsed.Minutes()
+	} else if window.WindowMinutes > 0 {
+		windowStart := window.ResetsAt.Add(-time.Duration(window.WindowMinutes) * time.Minute)
+		if elapsed := latest.Timestamp.Sub(windowStart); elapsed > 0 {
+			percentPerMinute = window.UsedPercent / elapsed.Minutes()
+		}
+	}
+	if percentPerMinute <= 0 {
+		return nil
+	}
+
+	minutes := (100 - window.UsedPercent) / percentPerMinute
+	at := latest.Timestamp.Add(time.Duration(minutes * float64(time.Minute)))
+	if !at.Before(*window.ResetsAt) {
+		return nil
+	}
+	return &at
+}
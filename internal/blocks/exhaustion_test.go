@@ -0,0 +1,67 @@
+package blocks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/johanneserhardt/cxusage/internal/types"
+)
+
+func TestEstimateLimitExhaustion_TokenLimit(t *testing.T) {
+	start := time.Date(2025, 9, 2, 10, 0, 0, 0, time.Local)
+	now := start.Add(time.Hour)
+	block := mkActiveBlock(start, []time.Duration{30 * time.Minute}, []int{6000})
+	projection := CalculateProjections(block)
+	projection.BurnRate = 100 // tokens per minute
+
+	limit := 10000
+	EstimateLimitExhaustion(projection, block, &limit, nil, now)
+
+	want := now.Add(40 * time.Minute)
+	if projection.LimitReachedAt == nil || !projection.LimitReachedAt.Equal(want) {
+		t.Fatalf("expected limit reached at %s, got %v", want, projection.LimitReachedAt)
+	}
+	if projection.LimitType != types.LimitTypeTokens {
+		t.Fatalf("expected token limit type, got %s", projection.LimitType)
+	}
+
+	// A limit beyond the end of the block is not reported
+	limit = 1000000
+	projection.LimitReachedAt = nil
+	EstimateLimitExhaustion(projection, block, &limit, nil, now)
+	if projection.LimitReachedAt != nil {
+		t.Fatalf("expected no exhaustion within block, got %v", projection.LimitReachedAt)
+	}
+}
+
+func TestEstimateLimitExhaustion_RateLimit(t *testing.T) {
+	now := time.Date(2025, 9, 2, 12, 0, 0, 0, time.UTC)
+	resetsAt := now.Add(3 * time.Hour)
+	snapshots := []types.RateLimitSnapshot{
+		{Timestamp: now.Add(-60 * time.Minute), Primary: &types.RateLimitWindow{UsedPercent: 40, WindowMinutes: 300, ResetsAt: &resetsAt}},
+		{Timestamp: now, Primary: &types.RateLimitWindow{UsedPercent: 70, WindowMinutes: 300, ResetsAt: &resetsAt}},
+	}
+
+	projection := &types.BlockProjection{}
+	EstimateLimitExhaustion(projection, &types.SessionBlock{}, nil, snapshots, now)
+
+	// 30% per hour leaves one hour until 100%
+	want := now.Add(time.Hour)
+	if projection.LimitReachedAt == nil || !projection.LimitReachedAt.Equal(want) {
+		t.Fatalf("expected rate limit reached at %s, got %v", want, projection.LimitReachedAt)
+	}
+	if projection.LimitType != types.LimitTypeRateLimit {
+		t.Fatalf("expected rate limit type, got %s", projection.LimitType)
+	}
+}
+
+func TestSameRateLimitWindow(t *testing.T) {
+	resetsAt := time.Date(2025, 9, 20, 15, 0, 0, 0, time.UTC)
+
+	if !SameRateLimitWindow(resetsAt.Add(90*time.Second), resetsAt) || !SameRateLimitWindow(resetsAt.Add(-90*time.Second), resetsAt) {
+		t.Fatalf("expected reset times within the tolerance to be the same window")
+	}
+	if SameRateLimitWindow(resetsAt.Add(5*time.Hour), resetsAt) {
+		t.Fatalf("expected the next reset time to be a new window")
+	}
+}
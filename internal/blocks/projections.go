@@ -0,0 +1,265 @@
+package blocks
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/johanneserhardt/cxusage/internal/types"
+)
+
+const (
+	// DefaultProjectionWindow is the default lookback for recent and ewma projections
+	DefaultProjectionWindow = 30 * time.Minute
+
+	// projectionBucket is the bucket size used to measure how bursty usage is
+	projectionBucket = 5 * time.Minute
+
+	// projectionZ is the z-score of the projection range (roughly 80% two-sided)
+	projectionZ = 1.28
+
+	// minHistoricalBlocks is the minimum number of past blocks for a historical projection
+	minHistoricalBlocks = 3
+
+	// HistoricalProjectionDays is how many days of past blocks feed historical projections
+	HistoricalProjectionDays = 30
+)
+
+// ParseProjectionStrategy validates a projection strategy name from the command line
+func ParseProjectionStrategy(value string) (types.ProjectionStrategy, error) {
+	switch types.ProjectionStrategy(strings.ToLower(strings.TrimSpace(value))) {
+	case "", types.ProjectionAverage:
+		return types.ProjectionAverage, nil
+	case types.ProjectionRecent:
+		return types.ProjectionRecent, nil
+	case types.ProjectionEWMA:
+		return types.ProjectionEWMA, nil
+	case types.ProjectionHistorical:
+		return types.ProjectionHistorical, nil
+	default:
+		return "", fmt.Errorf("unsupported projection strategy: %s (expected average, recent, ewma or historical)", value)
+	}
+}
+
+// CalculateProjectionsWithConfig calculates projections for an active block with the configured strategy.
+// History is only used by the historical strategy; when it holds too few past blocks the
+// projection falls back to the average burn rate.
+func CalculateProjectionsWithConfig(block *types.SessionBlock, history []types.SessionBlock, config types.ProjectionConfig, now time.Time) *types.BlockProjection {
+	if !block.IsActive {
+		return nil
+	}
+
+	strategy := config.Strategy
+	if strategy == "" {
+		strategy = types.ProjectionAverage
+	}
+	window := config.Window
+	if window <= 0 {
+		window = DefaultProjectionWindow
+	}
+
+	elapsed := now.Sub(block.StartTime)
+	timeRemaining := block.EndTime.Sub(now)
+	if timeRemaining < 0 {
+		timeRemaining = 0
+	}
+
+	projection := &types.BlockProjection{
+		ProjectedTokens:     block.TotalTokens,
+		ProjectedCost:       block.TotalCost,
+		BurnRate:            0,
+		TimeRemaining:       timeRemaining,
+		Strategy:            strategy,
+		ProjectedTokensLow:  block.TotalTokens,
+		ProjectedTokensHigh: block.TotalTokens,
+		ProjectedCostLow:    block.TotalCost,
+		ProjectedCostHigh:   block.TotalCost,
+	}
+
+	if elapsed <= 0 || block.TotalTokens == 0 {
+		return projection
+	}
+
+	if strategy == types.ProjectionHistorical {
+		if projectHistorical(projection, block, history, elapsed) {
+			return projection
+		}
+		projection.Strategy = types.ProjectionAverage
+	}
+
+	// Estimate the burn rate and the period it was measured over
+	var burnRate float64
+	lookback := elapsed
+	switch projection.Strategy {
+	case types.ProjectionRecent:
+		if window < lookback {
+			lookback = window
+		}
+		burnRate = float64(tokensBetween(block.Entries, now.Add(-lookback), now)) / lookback.Minutes()
+	case types.ProjectionEWMA:
+		if window < lookback {
+			lookback = window
+		}
+		burnRate = ewmaBurnRate(block, window, now)
+	default:
+		burnRate = float64(block.TotalTokens) / elapsed.Minutes()
+	}
+
+	expected := burnRate * timeRemaining.Minutes()
+	spread := projectionSpread(block.Entries, now.Add(-lookback), now, timeRemaining)
+
+	projection.BurnRate = burnRate
+	projection.ProjectedTokens = block.TotalTokens + int(expected)
+	projection.ProjectedTokensLow = block.TotalTokens + int(math.Max(0, expected-spread))
+	projection.ProjectedTokensHigh = block.TotalTokens + int(expected+spread)
+	setProjectedCosts(projection, block)
+
+	return projection
+}
+
+// projectHistorical projects the rest of the block from how many tokens past blocks used
+// after the same elapsed time, preferring blocks that started at the same hour
+func projectHistorical(projection *types.BlockProjection, block *types.SessionBlock, history []types.SessionBlock, elapsed time.Duration) bool {
+	var sameHour, all []float64
+	for _, past := range history {
+		if past.IsGap || past.IsActive || len(past.Entries) == 0 || !past.StartTime.Before(block.StartTime) {
+			continue
+		}
+
+		offset := past.StartTime.Add(elapsed)
+		rest := float64(tokensBetween(past.Entries, offset, past.EndTime))
+		all = append(all, rest)
+		if past.StartTime.Local().Hour() == block.StartTime.Local().Hour() {
+			sameHour = append(sameHour, rest)
+		}
+	}
+
+	samples := sameHour
+	if len(samples) < minHistoricalBlocks {
+		samples = all
+	}
+	if len(samples) < minHistoricalBlocks {
+		return false
+	}
+	sort.Float64s(samples)
+
+	median := percentile(samples, 0.5)
+	projection.ProjectedTokens = block.TotalTokens + int(median)
+	projection.ProjectedTokensLow = block.TotalTokens + int(percentile(samples, 0.25))
+	projection.ProjectedTokensHigh = block.TotalTokens + int(percentile(samples, 0.75))
+	if projection.TimeRemaining > 0 {
+		projection.BurnRate = median / projection.TimeRemaining.Minutes()
+	} else {
+		projection.BurnRate = float64(block.TotalTokens) / elapsed.Minutes()
+	}
+	setProjectedCosts(projection, block)
+
+	return true
+}
+
+// ewmaBurnRate returns an exponentially weighted per-minute burn rate, so recent minutes
+// dominate while a single early burst fades out
+func ewmaBurnRate(block *types.SessionBlock, window time.Duration, now time.Time) float64 {
+	minutes := int(math.Ceil(now.Sub(block.StartTime).Minutes()))
+	if minutes <= 0 {
+		return 0
+	}
+
+	perMinute := make([]float64, minutes)
+	for _, entry := range block.Entries {
+		idx := int(entry.Timestamp.Sub(block.StartTime).Minutes())
+		if idx >= 0 && idx < minutes {
+			perMinute[idx] += float64(entry.Usage.TotalTokens)
+		}
+	}
+
+	// Smoothing factor for a span of the window length
+	span := window.Minutes()
+	alpha := 2 / (span + 1)
+
+	// Seed with the average over the first span to avoid overweighting the first minute
+	seedLen := int(math.Min(span, float64(minutes)))
+	if seedLen < 1 {
+		seedLen = 1
+	}
+	var seed float64
+	for _, v := range perMinute[:seedLen] {
+		seed += v
+	}
+	rate := seed / float64(seedLen)
+
+	for _, v := range perMinute[seedLen:] {
+		rate = alpha*v + (1-alpha)*rate
+	}
+	return rate
+}
+
+// projectionSpread estimates how far remaining usage may deviate from the expected value by
+// treating future buckets as draws from the buckets observed in [from, to)
+func projectionSpread(entries []types.CodexUsageEntry, from, to time.Time, remaining time.Duration) float64 {
+	buckets := int(math.Ceil(to.Sub(from).Seconds() / projectionBucket.Seconds()))
+	if buckets < 2 || remaining <= 0 {
+		return 0
+	}
+
+	sums := make([]float64, buckets)
+	for _, entry := range entries {
+		if entry.Timestamp.Before(from) || !entry.Timestamp.Before(to) {
+			continue
+		}
+		idx := int(entry.Timestamp.Sub(from) / projectionBucket)
+		if idx >= 0 && idx < buckets {
+			sums[idx] += float64(entry.Usage.TotalTokens)
+		}
+	}
+
+	var mean float64
+	for _, v := range sums {
+		mean += v
+	}
+	mean /= float64(buckets)
+
+	var variance float64
+	for _, v := range sums {
+		variance += (v - mean) * (v - mean)
+	}
+	stddev := math.Sqrt(variance / float64(buckets-1))
+
+	remainingBuckets := remaining.Seconds() / projectionBucket.Seconds()
+	return projectionZ * stddev * math.Sqrt(remainingBuckets)
+}
+
+// setProjectedCosts derives projected costs from the block's average cost per token
+func setProjectedCosts(projection *types.BlockProjection, block *types.SessionBlock) {
+	costPerToken := block.TotalCost / float64(block.TotalTokens)
+	projection.ProjectedCost = float64(projection.ProjectedTokens) * costPerToken
+	projection.ProjectedCostLow = float64(projection.ProjectedTokensLow) * costPerToken
+	projection.ProjectedCostHigh = float64(projection.ProjectedTokensHigh) * costPerToken
+}
+
+// tokensBetween sums tokens of entries with timestamps in [from, to)
+func tokensBetween(entries []types.CodexUsageEntry, from, to time.Time) int {
+	total := 0
+	for _, entry := range entries {
+		if !entry.Timestamp.Before(from) && entry.Timestamp.Before(to) {
+			total += entry.Usage.TotalTokens
+		}
+	}
+	return total
+}
+
+// percentile returns the linearly interpolated percentile of sorted values
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	pos := p * float64(len(sorted)-1)
+	lower := int(math.Floor(pos))
+	upper := int(math.Ceil(pos))
+	if lower == upper {
+		return sorted[lower]
+	}
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(pos-float64(lower))
+}
@@ -0,0 +1,78 @@
+package blocks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/johanneserhardt/cxusage/internal/types"
+)
+
+// helper to build an active block from entries with the given token counts
+func mkActiveBlock(start time.Time, at []time.Duration, tokens []int) *types.SessionBlock {
+	block := newSessionBlock(start, start.Add(5*time.Hour))
+	block.IsActive = true
+	for i, offset := range at {
+		entry := mkEntry(start.Add(offset))
+		entry.Usage = types.Usage{PromptTokens: tokens[i], TotalTokens: tokens[i]}
+		entry.Cost = float64(tokens[i]) / 1000
+		addEntryToBlock(block, entry, entry.Timestamp)
+	}
+	return block
+}
+
+func TestCalculateProjectionsWithConfig_RecentIgnoresEarlyBurst(t *testing.T) {
+	start := time.Date(2025, 9, 2, 10, 0, 0, 0, time.Local)
+	now := start.Add(2 * time.Hour)
+	block := mkActiveBlock(start,
+		[]time.Duration{time.Minute, 100 * time.Minute, 110 * time.Minute},
+		[]int{100000, 300, 300})
+
+	average := CalculateProjectionsWithConfig(block, nil, types.ProjectionConfig{Strategy: types.ProjectionAverage}, now)
+	recent := CalculateProjectionsWithConfig(block, nil, types.ProjectionConfig{Strategy: types.ProjectionRecent, Window: 30 * time.Minute}, now)
+
+	if recent.BurnRate >= average.BurnRate {
+		t.Fatalf("expected recent burn rate below average, got %.1f >= %.1f", recent.BurnRate, average.BurnRate)
+	}
+	if want := 600.0 / 30; recent.BurnRate != want {
+		t.Fatalf("expected recent burn rate %.1f, got %.1f", want, recent.BurnRate)
+	}
+	if recent.ProjectedTokensLow > recent.ProjectedTokens || recent.ProjectedTokens > recent.ProjectedTokensHigh {
+		t.Fatalf("expected low <= projected <= high, got %d <= %d <= %d",
+			recent.ProjectedTokensLow, recent.ProjectedTokens, recent.ProjectedTokensHigh)
+	}
+}
+
+func TestCalculateProjectionsWithConfig_HistoricalFallsBackToAverage(t *testing.T) {
+	start := time.Date(2025, 9, 2, 10, 0, 0, 0, time.Local)
+	block := mkActiveBlock(start, []time.Duration{10 * time.Minute}, []int{1000})
+
+	projection := CalculateProjectionsWithConfig(block, nil, types.ProjectionConfig{Strategy: types.ProjectionHistorical}, start.Add(time.Hour))
+	if projection.Strategy != types.ProjectionAverage {
+		t.Fatalf("expected fallback to average, got %s", projection.Strategy)
+	}
+}
+
+func TestCalculateProjectionsWithConfig_HistoricalUsesSameHour(t *testing.T) {
+	start := time.Date(2025, 9, 5, 10, 0, 0, 0, time.Local)
+	block := mkActiveBlock(start, []time.Duration{10 * time.Minute}, []int{1000})
+
+	// Past blocks at 10:00 used 2,000-4,000 tokens after the first hour
+	var history []types.SessionBlock
+	for day, rest := range []int{2000, 3000, 4000} {
+		pastStart := start.AddDate(0, 0, -(day + 1))
+		past := mkActiveBlock(pastStart, []time.Duration{10 * time.Minute, 2 * time.Hour}, []int{1000, rest})
+		past.IsActive = false
+		history = append(history, *past)
+	}
+
+	projection := CalculateProjectionsWithConfig(block, history, types.ProjectionConfig{Strategy: types.ProjectionHistorical}, start.Add(time.Hour))
+	if projection.Strategy != types.ProjectionHistorical {
+		t.Fatalf("expected historical strategy, got %s", projection.Strategy)
+	}
+	if projection.ProjectedTokens != 4000 {
+		t.Fatalf("expected median projection of 4,000 tokens, got %d", projection.ProjectedTokens)
+	}
+	if projection.ProjectedTokensLow != 3500 || projection.ProjectedTokensHigh != 4500 {
+		t.Fatalf("expected range 3,500-4,500, got %d-%d", projection.ProjectedTokensLow, projection.ProjectedTokensHigh)
+	}
+}
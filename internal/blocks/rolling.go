@@ -0,0 +1,79 @@
+package blocks
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/johanneserhardt/cxusage/internal/types"
+)
+
+// ParseBlockMode validates a block mode name from the command line
+func ParseBlockMode(value string) (types.BlockMode, error) {
+	switch types.BlockMode(strings.ToLower(strings.TrimSpace(value))) {
+	case "", types.BlockModeFixed:
+		return types.BlockModeFixed, nil
+	case types.BlockModeRolling:
+		return types.BlockModeRolling, nil
+	default:
+		return "", fmt.Errorf("unsupported block mode: %s (expected fixed or rolling)", value)
+	}
+}
+
+// AggregateIntoBlocksWithMode converts usage entries into billing blocks using the given block mode
+func AggregateIntoBlocksWithMode(entries []types.CodexUsageEntry, sessionDurationHours int, mode types.BlockMode) []types.SessionBlock {
+	if mode == types.BlockModeRolling {
+		return AggregateIntoRollingBlocks(entries, sessionDurationHours)
+	}
+	return AggregateIntoBlocks(entries, sessionDurationHours)
+}
+
+// AggregateIntoRollingBlocks converts usage entries into rolling billing blocks.
+//
+// A block starts at the first entry after the previous block expired (floored to
+// the hour) and lasts for the session duration, mirroring how usage limits open a
+// new window on the first request. An inactivity gap longer than the session
+// duration always falls outside the current block, so it also starts a new one.
+func AggregateIntoRollingBlocks(entries []types.CodexUsageEntry, sessionDurationHours int) []types.SessionBlock {
+	if len(entries) == 0 {
+		return []types.SessionBlock{}
+	}
+
+	// Sort entries by timestamp
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Timestamp.Before(entries[j].Timestamp)
+	})
+
+	blockDuration := time.Duration(sessionDurationHours) * time.Hour
+	var blocks []types.SessionBlock
+	var current *types.SessionBlock
+
+	for _, entry := range entries {
+		localTimestamp := entry.Timestamp.Local()
+
+		// Close the current block once the entry falls outside its window
+		if current != nil && !localTimestamp.Before(current.EndTime) {
+			blocks = append(blocks, *current)
+			current = nil
+		}
+
+		if current == nil {
+			blockStartTime := floorToHour(localTimestamp)
+			current = newSessionBlock(blockStartTime, blockStartTime.Add(blockDuration))
+		}
+
+		addEntryToBlock(current, entry, localTimestamp)
+	}
+	blocks = append(blocks, *current)
+
+	// Only the latest block can still be active (its window has not expired yet)
+	now := time.Now()
+	last := &blocks[len(blocks)-1]
+	if now.After(last.StartTime) && now.Before(last.EndTime) {
+		last.IsActive = true
+	}
+
+	// Fill gaps between blocks to show inactive periods
+	return fillGaps(blocks, sessionDurationHours)
+}
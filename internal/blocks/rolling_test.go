@@ -0,0 +1,81 @@
+package blocks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/johanneserhardt/cxusage/internal/types"
+)
+
+// helper to make a CodexUsageEntry at the given time
+func mkEntry(t time.Time) types.CodexUsageEntry {
+	return types.CodexUsageEntry{
+		Timestamp: t,
+		SessionID: "session",
+		Model:     "gpt-4o",
+		Usage:     types.Usage{PromptTokens: 10, CompletionTokens: 20, TotalTokens: 30},
+		Cost:      0.01,
+	}
+}
+
+func TestAggregateIntoRollingBlocks_StartsAtFirstActivity(t *testing.T) {
+	base := time.Date(2025, 9, 2, 7, 40, 0, 0, time.Local)
+	entries := []types.CodexUsageEntry{
+		mkEntry(base),
+		mkEntry(base.Add(2 * time.Hour)),
+		// 11:20 is still inside the 07:00-12:00 window
+		mkEntry(base.Add(3*time.Hour + 40*time.Minute)),
+		// 12:10 opens a new window at 12:00
+		mkEntry(base.Add(4*time.Hour + 30*time.Minute)),
+	}
+
+	result := AggregateIntoRollingBlocks(entries, 5)
+	if len(result) != 2 {
+		t.Fatalf("expected 2 blocks, got %d", len(result))
+	}
+
+	wantFirst := time.Date(2025, 9, 2, 7, 0, 0, 0, time.Local)
+	if !result[0].StartTime.Equal(wantFirst) {
+		t.Fatalf("expected first block at %s, got %s", wantFirst, result[0].StartTime)
+	}
+	if result[0].RequestCount != 3 {
+		t.Fatalf("expected 3 requests in first block, got %d", result[0].RequestCount)
+	}
+
+	wantSecond := time.Date(2025, 9, 2, 12, 0, 0, 0, time.Local)
+	if !result[1].StartTime.Equal(wantSecond) {
+		t.Fatalf("expected second block at %s, got %s", wantSecond, result[1].StartTime)
+	}
+}
+
+func TestAggregateIntoRollingBlocks_InactivityGap(t *testing.T) {
+	base := time.Date(2025, 9, 2, 8, 15, 0, 0, time.Local)
+	entries := []types.CodexUsageEntry{
+		mkEntry(base),
+		mkEntry(base.Add(9 * time.Hour)),
+	}
+
+	result := AggregateIntoRollingBlocks(entries, 5)
+	if len(result) != 3 {
+		t.Fatalf("expected 2 blocks and 1 gap, got %d blocks", len(result))
+	}
+	if !result[1].IsGap {
+		t.Fatalf("expected middle block to be a gap")
+	}
+	wantSecond := time.Date(2025, 9, 2, 17, 0, 0, 0, time.Local)
+	if !result[2].StartTime.Equal(wantSecond) {
+		t.Fatalf("expected block after gap at %s, got %s", wantSecond, result[2].StartTime)
+	}
+}
+
+func TestParseBlockMode(t *testing.T) {
+	if mode, err := ParseBlockMode(""); err != nil || mode != types.BlockModeFixed {
+		t.Fatalf("expected empty mode to default to fixed, got %q (%v)", mode, err)
+	}
+	if mode, err := ParseBlockMode("Rolling"); err != nil || mode != types.BlockModeRolling {
+		t.Fatalf("expected rolling mode, got %q (%v)", mode, err)
+	}
+	if _, err := ParseBlockMode("hourly"); err == nil {
+		t.Fatalf("expected error for unknown block mode")
+	}
+}
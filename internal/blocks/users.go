@@ -0,0 +1,33 @@
+package blocks
+
+import (
+	"sort"
+
+	"github.com/johanneserhardt/cxusage/internal/types"
+)
+
+// AggregateIntoBlocksByUser builds billing blocks separately for each user, since every
+// person has their own usage windows, and tags each block with its user
+func AggregateIntoBlocksByUser(entries []types.CodexUsageEntry, sessionDurationHours int, mode types.BlockMode) []types.SessionBlock {
+	byUser := make(map[string][]types.CodexUsageEntry)
+	for _, entry := range entries {
+		byUser[entry.User] = append(byUser[entry.User], entry)
+	}
+
+	var result []types.SessionBlock
+	for user, userEntries := range byUser {
+		for _, block := range AggregateIntoBlocksWithMode(userEntries, sessionDurationHours, mode) {
+			block.User = user
+			result = append(result, block)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].StartTime.Equal(result[j].StartTime) {
+			return result[i].StartTime.Before(result[j].StartTime)
+		}
+		return result[i].User < result[j].User
+	})
+
+	return result
+}
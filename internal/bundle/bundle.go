@@ -0,0 +1,219 @@
+package bundle
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/johanneserhardt/cxusage/internal/types"
+)
+
+// gzipSuffix marks bundle files that are gzip-compressed
+const gzipSuffix = ".gz"
+
+// New creates a bundle of the given entries for a user.
+// Prompt-derived fields are dropped so bundles only carry usage numbers; identifying
+// fields are redacted by the parser.
+func New(user string, entries []types.CodexUsageEntry, startDate, endDate time.Time) *types.UsageBundle {
+	cleaned := make([]types.CodexUsageEntry, 0, len(entries))
+	for _, entry := range entries {
+		entry.Command = ""
+		if entry.User == "" {
+			entry.User = user
+		}
+		cleaned = append(cleaned, entry)
+	}
+
+	sort.Slice(cleaned, func(i, j int) bool {
+		return cleaned[i].Timestamp.Before(cleaned[j].Timestamp)
+	})
+
+	return &types.UsageBundle{
+		Format:    types.BundleFormat,
+		Version:   types.BundleVersion,
+		User:      user,
+		CreatedAt: time.Now().UTC(),
+		StartDate: startDate.UTC(),
+		EndDate:   endDate.UTC(),
+		Entries:   cleaned,
+	}
+}
+
+// Write writes a bundle as JSON, gzip-compressed when the path ends in .gz
+func Write(path string, b *types.UsageBundle) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create bundle file: %w", err)
+	}
+
+	if err := encode(file, strings.HasSuffix(path, gzipSuffix), b); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	// Buffered data only reaches the disk on close, so its error is the write's error
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	return nil
+}
+
+// encode writes a bundle as indented JSON, flushing the gzip stream and its trailer
+func encode(w io.Writer, compress bool, b *types.UsageBundle) error {
+	var gz *gzip.Writer
+	if compress {
+		gz = gzip.NewWriter(w)
+		w = gz
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(b); err != nil {
+		return err
+	}
+	if gz != nil {
+		return gz.Close()
+	}
+	return nil
+}
+
+// Read reads and validates a bundle file
+func Read(path string) (*types.UsageBundle, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if strings.HasSuffix(path, gzipSuffix) {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress bundle %s: %w", filepath.Base(path), err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	var b types.UsageBundle
+	if err := json.NewDecoder(r).Decode(&b); err != nil {
+		return nil, fmt.Errorf("failed to decode bundle %s: %w", filepath.Base(path), err)
+	}
+
+	if b.Format != types.BundleFormat {
+		return nil, fmt.Errorf("%s is not a cxusage bundle", filepath.Base(path))
+	}
+	if b.Version < 1 || b.Version > types.BundleVersion {
+		return nil, fmt.Errorf("bundle %s has unsupported version %d (this build reads up to %d)",
+			filepath.Base(path), b.Version, types.BundleVersion)
+	}
+
+	// Older exports may not tag every entry
+	for i := range b.Entries {
+		if b.Entries[i].User == "" {
+			b.Entries[i].User = b.User
+		}
+	}
+
+	return &b, nil
+}
+
+// ResolveFiles expands directories into the bundle files they contain
+func ResolveFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("bundle not found: %w", err)
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		dirEntries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bundle directory: %w", err)
+		}
+		for _, entry := range dirEntries {
+			name := entry.Name()
+			if !entry.IsDir() && (strings.HasSuffix(name, ".json") || strings.HasSuffix(name, ".json"+gzipSuffix)) {
+				files = append(files, filepath.Join(path, name))
+			}
+		}
+	}
+	return files, nil
+}
+
+// LoadEntries returns the entries of all bundles within the date range (inclusive)
+func LoadEntries(paths []string, startDate, endDate time.Time) ([]types.CodexUsageEntry, error) {
+	files, err := ResolveFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []types.CodexUsageEntry
+	for _, file := range files {
+		b, err := Read(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range b.Entries {
+			if entry.Timestamp.Before(startDate) || entry.Timestamp.After(endDate) {
+				continue
+			}
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// Import validates a bundle and copies it into the bundle store.
+// The file name is derived from the user and the content, so importing the same file twice is a no-op.
+func Import(path, storeDir string) (string, *types.UsageBundle, error) {
+	b, err := Read(path)
+	if err != nil {
+		return "", nil, err
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil, err
+	}
+	sum := sha256.Sum256(raw)
+
+	ext := ".json"
+	if strings.HasSuffix(path, gzipSuffix) {
+		ext += gzipSuffix
+	}
+	name := fmt.Sprintf("%s-%s%s", sanitizeName(b.User), hex.EncodeToString(sum[:])[:12], ext)
+	dest := filepath.Join(storeDir, name)
+
+	if err := os.WriteFile(dest, raw, 0644); err != nil {
+		return "", nil, fmt.Errorf("failed to store bundle: %w", err)
+	}
+
+	return dest, b, nil
+}
+
+// sanitizeName makes a user identity safe to use in a file name
+func sanitizeName(name string) string {
+	if name == "" {
+		return "unknown"
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
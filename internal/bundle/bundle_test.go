@@ -0,0 +1,100 @@
+package bundle
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/johanneserhardt/cxusage/internal/types"
+)
+
+func TestWriteReadRoundTrip(t *testing.T) {
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 7)
+	entries := []types.CodexUsageEntry{
+		{Timestamp: start.Add(2 * time.Hour), SessionID: "s1", RequestID: "r2", Model: "gpt-4o", Command: "secret prompt"},
+		{Timestamp: start.Add(time.Hour), SessionID: "s1", RequestID: "r1", Model: "gpt-4o"},
+	}
+
+	for _, name := range []string{"alice.json", "alice.json.gz"} {
+		path := filepath.Join(t.TempDir(), name)
+		if err := Write(path, New("alice", entries, start, end)); err != nil {
+			t.Fatalf("%s: write failed: %v", name, err)
+		}
+
+		b, err := Read(path)
+		if err != nil {
+			t.Fatalf("%s: read failed: %v", name, err)
+		}
+		if b.User != "alice" || len(b.Entries) != 2 {
+			t.Fatalf("%s: unexpected bundle %+v", name, b)
+		}
+		if b.Entries[0].RequestID != "r1" {
+			t.Errorf("%s: expected entries sorted by time", name)
+		}
+		for _, entry := range b.Entries {
+			if entry.Command != "" {
+				t.Errorf("%s: command text must not be exported", name)
+			}
+			if entry.User != "alice" {
+				t.Errorf("%s: expected entries tagged with user, got %q", name, entry.User)
+			}
+		}
+	}
+}
+
+// fullWriter fails every write, like a full disk
+type fullWriter struct{}
+
+func (fullWriter) Write([]byte) (int, error) { return 0, errors.New("no space left on device") }
+
+func TestEncodeReportsGzipFlushErrors(t *testing.T) {
+	b := New("alice", []types.CodexUsageEntry{{Model: "gpt-4o"}}, time.Now(), time.Now())
+	// The compressed bundle is small enough to stay buffered until the gzip stream is closed
+	if err := encode(fullWriter{}, true, b); err == nil {
+		t.Error("expected the failed flush of the gzip stream to be reported")
+	}
+	if err := encode(fullWriter{}, false, b); err == nil {
+		t.Error("expected the failed write to be reported")
+	}
+}
+
+func TestReadRejectsUnknownVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "future.json")
+	content := `{"format": "cxusage-bundle", "version": 99, "user": "bob", "entries": []}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Read(path); err == nil {
+		t.Error("expected an error for a newer bundle version")
+	}
+}
+
+func TestImportIsIdempotent(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "export.json")
+	if err := Write(path, New("bob/laptop", nil, time.Now(), time.Now())); err != nil {
+		t.Fatal(err)
+	}
+
+	store := t.TempDir()
+	first, _, err := Import(path, store)
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	second, _, err := Import(path, store)
+	if err != nil {
+		t.Fatalf("second import failed: %v", err)
+	}
+	if first != second {
+		t.Errorf("expected the same stored file, got %s and %s", first, second)
+	}
+
+	files, _ := ResolveFiles([]string{store})
+	if len(files) != 1 {
+		t.Errorf("expected one stored bundle, got %d", len(files))
+	}
+}
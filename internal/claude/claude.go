@@ -0,0 +1,201 @@
+package claude
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/johanneserhardt/cxusage/internal/jsonl"
+	"github.com/johanneserhardt/cxusage/internal/pricing"
+	"github.com/johanneserhardt/cxusage/internal/types"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	DefaultClaudeDir = ".claude"
+	ProjectsDir      = "projects"
+)
+
+// ConfigDirEnv is the environment variable Claude Code uses to relocate its directory
+const ConfigDirEnv = "CLAUDE_CONFIG_DIR"
+
+// Provider is the model provider of every Claude Code entry
+const Provider = "anthropic"
+
+// syntheticModel marks messages Claude Code writes itself, e.g. for interrupted requests
+const syntheticModel = "<synthetic>"
+
+// GetClaudeDir returns the Claude Code directory: claude_path, else CLAUDE_CONFIG_DIR,
+// else ~/.claude
+func GetClaudeDir(cfg *types.Config) (string, error) {
+	dir := cfg.ClaudePath
+	if dir == "" {
+		dir = os.Getenv(ConfigDirEnv)
+	}
+	if dir == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(homeDir, DefaultClaudeDir), nil
+	}
+	if dir == "~" || strings.HasPrefix(dir, "~/") {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(homeDir, dir[1:])
+	}
+	return filepath.Clean(dir), nil
+}
+
+// GetLogFiles returns the session logs under the projects directory, sorted by path
+func GetLogFiles(cfg *types.Config) ([]string, error) {
+	dir, err := GetClaudeDir(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	_ = filepath.WalkDir(filepath.Join(dir, ProjectsDir), func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil // skip unreadable entries
+		}
+		if !d.IsDir() && strings.HasSuffix(d.Name(), ".jsonl") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, nil
+}
+
+// ParseUsageFiles reads the usage of every Claude Code session between the dates. Claude
+// Code logs exact usage, including prompt cache writes and reads, with every response.
+func ParseUsageFiles(cfg *types.Config, prices *pricing.Table, startDate, endDate time.Time, logger *logrus.Logger) ([]types.CodexUsageEntry, error) {
+	files, err := GetLogFiles(cfg)
+	if err != nil {
+		return nil, err
+	}
+	logger.WithField("files_count", len(files)).Info("Found Claude Code session files")
+
+	var allEntries []types.CodexUsageEntry
+	// A response is logged once per content block, and resumed sessions copy the history
+	// of the session they continue; the first copy wins
+	seen := make(map[string]struct{})
+	for _, file := range files {
+		// Files untouched since the start date cannot contain entries in range
+		if info, err := os.Stat(file); err == nil && info.ModTime().Before(startDate) {
+			continue
+		}
+
+		entries, err := parseSessionFile(file, prices, startDate, endDate, seen)
+		if err != nil {
+			logger.WithError(err).WithField("file", filepath.Base(file)).Warn("Failed to parse Claude Code session file")
+			continue
+		}
+		for i := range entries {
+			entries[i].User = cfg.User
+		}
+		allEntries = append(allEntries, entries...)
+	}
+
+	logger.WithField("total_entries", len(allEntries)).Info("Parsed Claude Code usage entries")
+	return allEntries, nil
+}
+
+// record is one line of a Claude Code session log; only assistant lines carry usage
+type record struct {
+	Type      string  `json:"type"`
+	Timestamp string  `json:"timestamp"`
+	SessionID string  `json:"sessionId"`
+	RequestID string  `json:"requestId"`
+	Cwd       string  `json:"cwd"`
+	CostUSD   float64 `json:"costUSD"`
+	Message   struct {
+		ID    string `json:"id"`
+		Model string `json:"model"`
+		Usage *struct {
+			InputTokens              int `json:"input_tokens"`
+			OutputTokens             int `json:"output_tokens"`
+			CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+			CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+		} `json:"usage"`
+	} `json:"message"`
+}
+
+// parseSessionFile reads the usage entries of one session log, skipping responses in seen
+func parseSessionFile(filename string, prices *pricing.Table, startDate, endDate time.Time, seen map[string]struct{}) ([]types.CodexUsageEntry, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []types.CodexUsageEntry
+	err = jsonl.Scan(file, func(lineNum int, line string) {
+		// Cheap check before decoding lines that cannot carry usage
+		if !strings.Contains(line, `"usage"`) {
+			return
+		}
+		var r record
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			return
+		}
+		if r.Type != "assistant" || r.Message.Usage == nil || r.Message.Model == syntheticModel {
+			return
+		}
+		timestamp, err := time.Parse(time.RFC3339, r.Timestamp)
+		if err != nil || timestamp.Before(startDate) || timestamp.After(endDate) {
+			return
+		}
+
+		if r.Message.ID != "" || r.RequestID != "" {
+			key := r.Message.ID + ":" + r.RequestID
+			if _, ok := seen[key]; ok {
+				return
+			}
+			seen[key] = struct{}{}
+		}
+
+		u := r.Message.Usage
+		usage := types.Usage{
+			PromptTokens:        u.InputTokens,
+			CompletionTokens:    u.OutputTokens,
+			CacheCreationTokens: u.CacheCreationInputTokens,
+			CacheReadTokens:     u.CacheReadInputTokens,
+			TotalTokens:         u.InputTokens + u.OutputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens,
+		}
+		cost, provenance := r.CostUSD, types.ProvenanceExact
+		if cost == 0 {
+			var priced bool
+			if cost, priced = prices.Cost(Provider, r.Message.Model, usage); !priced {
+				provenance = types.ProvenanceEstimatedCost
+			}
+		}
+
+		requestID := r.RequestID
+		if requestID == "" {
+			requestID = r.Message.ID
+		}
+		entries = append(entries, types.CodexUsageEntry{
+			Timestamp:   timestamp,
+			SessionID:   r.SessionID,
+			RequestID:   requestID,
+			Model:       r.Message.Model,
+			Usage:       usage,
+			Cost:        cost,
+			ProjectPath: r.Cwd,
+			Source:      types.AgentClaude,
+			File:        filename,
+			Line:        lineNum,
+			Provider:    Provider,
+			Agent:       types.AgentClaude,
+			Provenance:  provenance,
+		})
+	})
+	return entries, err
+}
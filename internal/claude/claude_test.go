@@ -0,0 +1,99 @@
+package claude
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/johanneserhardt/cxusage/internal/jsonl"
+	"github.com/johanneserhardt/cxusage/internal/pricing"
+	"github.com/johanneserhardt/cxusage/internal/types"
+	"github.com/sirupsen/logrus"
+)
+
+// A response logged twice (once per content block), a synthetic message, a user line and a
+// response with a logged cost
+const claudeTestSession = `{"type":"user","sessionId":"s1","cwd":"/home/u/app","timestamp":"2026-10-18T09:00:00.000Z","message":{"role":"user","content":"fix the bug"}}
+{"type":"assistant","sessionId":"s1","cwd":"/home/u/app","requestId":"req_1","timestamp":"2026-10-18T09:00:05.123Z","message":{"id":"msg_1","model":"claude-sonnet-4-5-20250929","usage":{"input_tokens":10,"output_tokens":200,"cache_creation_input_tokens":1000,"cache_read_input_tokens":20000}}}
+{"type":"assistant","sessionId":"s1","cwd":"/home/u/app","requestId":"req_1","timestamp":"2026-10-18T09:00:06.000Z","message":{"id":"msg_1","model":"claude-sonnet-4-5-20250929","usage":{"input_tokens":10,"output_tokens":200,"cache_creation_input_tokens":1000,"cache_read_input_tokens":20000}}}
+{"type":"assistant","sessionId":"s1","timestamp":"2026-10-18T09:01:00.000Z","message":{"id":"msg_2","model":"<synthetic>","usage":{"input_tokens":0,"output_tokens":0}}}
+{"type":"assistant","sessionId":"s1","cwd":"/home/u/app","requestId":"req_3","timestamp":"2026-10-18T09:02:00.000Z","costUSD":0.5,"message":{"id":"msg_3","model":"claude-opus-4-1-20250805","usage":{"input_tokens":100,"output_tokens":50}}}
+`
+
+func TestParseUsageFiles(t *testing.T) {
+	dir := t.TempDir()
+	project := filepath.Join(dir, ProjectsDir, "-home-u-app")
+	if err := os.MkdirAll(project, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// The resumed session repeats the first one's history
+	for _, name := range []string{"s1.jsonl", "s2.jsonl"} {
+		if err := os.WriteFile(filepath.Join(project, name), []byte(claudeTestSession), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := &types.Config{ClaudePath: dir, User: "me"}
+	start := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	entries, err := ParseUsageFiles(cfg, pricing.Default(), start, start.AddDate(0, 0, 1), logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d: %+v", len(entries), entries)
+	}
+
+	first := entries[0]
+	want := types.Usage{PromptTokens: 10, CompletionTokens: 200, CacheCreationTokens: 1000, CacheReadTokens: 20000, TotalTokens: 21210}
+	if first.Usage != want {
+		t.Errorf("usage %+v, want %+v", first.Usage, want)
+	}
+	if first.Agent != types.AgentClaude || first.Provider != Provider || first.RequestID != "req_1" ||
+		first.ProjectPath != "/home/u/app" || first.User != "me" || first.Line != 2 {
+		t.Errorf("unexpected entry: %+v", first)
+	}
+	// 10 * 3 + 200 * 15 + 1000 * 3.75 + 20000 * 0.3 per 1M tokens
+	if cost := 0.01278; first.Cost < cost-1e-9 || first.Cost > cost+1e-9 {
+		t.Errorf("cost %v, want %v", first.Cost, cost)
+	}
+	if entries[1].Cost != 0.5 {
+		t.Errorf("logged cost %v, want 0.5", entries[1].Cost)
+	}
+}
+
+func TestParseUsageFilesSkipsOverlongLine(t *testing.T) {
+	dir := t.TempDir()
+	project := filepath.Join(dir, ProjectsDir, "-home-u-app")
+	if err := os.MkdirAll(project, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// A huge tool result must not hide the usage logged around it
+	lines := strings.SplitAfter(claudeTestSession, "\n")
+	huge := `{"type":"user","sessionId":"s1","timestamp":"2026-10-18T09:00:07.000Z","message":{"role":"user","content":[{"type":"tool_result","content":"` +
+		strings.Repeat("x", jsonl.MaxLineSize) + `"}]}}` + "\n"
+	content := strings.Join(lines[:3], "") + huge + strings.Join(lines[3:], "")
+	if err := os.WriteFile(filepath.Join(project, "s1.jsonl"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	entries, err := ParseUsageFiles(&types.Config{ClaudePath: dir}, pricing.Default(), start, start.AddDate(0, 0, 1), logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Line != 2 || entries[1].Line != 6 {
+		t.Fatalf("expected entries at lines 2 and 6, got %+v", entries)
+	}
+}
+
+func TestGetClaudeDir(t *testing.T) {
+	t.Setenv(ConfigDirEnv, "/tmp/claude-config")
+	if dir, _ := GetClaudeDir(&types.Config{}); dir != "/tmp/claude-config" {
+		t.Errorf("got %s, want CLAUDE_CONFIG_DIR", dir)
+	}
+	if dir, _ := GetClaudeDir(&types.Config{ClaudePath: "/srv/claude/"}); dir != "/srv/claude" {
+		t.Errorf("got %s, want claude_path", dir)
+	}
+}
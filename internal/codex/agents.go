@@ -0,0 +1,90 @@
+package codex
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/johanneserhardt/cxusage/internal/claude"
+	"github.com/johanneserhardt/cxusage/internal/types"
+)
+
+// KnownAgents are the coding agents whose logs can be read
+var KnownAgents = []string{types.AgentCodex, types.AgentClaude}
+
+// AvailableAgents returns the known agents followed by the names of the configured
+// mappings
+func AvailableAgents(cfg *types.Config) []string {
+	agents := append([]string{}, KnownAgents...)
+	for _, m := range cfg.Mappings {
+		agents = append(agents, m.Name)
+	}
+	return agents
+}
+
+// agentSources returns the source labels of the entries of agents other than Codex, which
+// are named after their agent or mapping
+func agentSources(cfg *types.Config) []string {
+	var sources []string
+	for _, agent := range AvailableAgents(cfg) {
+		if agent != types.AgentCodex {
+			sources = append(sources, agent)
+		}
+	}
+	return sources
+}
+
+// CheckAgents reports agents whose logs cannot be read
+func CheckAgents(cfg *types.Config) error {
+	available := AvailableAgents(cfg)
+	for _, agent := range cfg.Agents {
+		if !containsString(available, agent) {
+			return fmt.Errorf("unknown agent: %s (expected one of %s)", agent, strings.Join(available, ", "))
+		}
+	}
+	return nil
+}
+
+// AgentEnabled reports whether the logs of an agent are read; without --agent the Codex
+// CLI logs and every mapping are
+func AgentEnabled(cfg *types.Config, agent string) bool {
+	if len(cfg.Agents) == 0 {
+		return agent != types.AgentClaude
+	}
+	return containsString(cfg.Agents, agent)
+}
+
+// EntryAgent returns the agent that logged an entry; entries from before agents were
+// recorded all come from Codex
+func EntryAgent(e types.CodexUsageEntry) string {
+	if e.Agent == "" {
+		return types.AgentCodex
+	}
+	return e.Agent
+}
+
+// LogDirExists checks if the directory of any enabled agent exists
+func LogDirExists(cfg *types.Config) (bool, error) {
+	if AgentEnabled(cfg, types.AgentCodex) {
+		exists, err := CodexDirExists(cfg)
+		if err != nil || exists {
+			return exists, err
+		}
+	}
+	// Mapped logs can be anywhere; their globs are checked when they are read
+	for _, m := range cfg.Mappings {
+		if AgentEnabled(cfg, m.Name) {
+			return true, nil
+		}
+	}
+	if AgentEnabled(cfg, types.AgentClaude) {
+		dir, err := claude.GetClaudeDir(cfg)
+		if err != nil {
+			return false, err
+		}
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,340 @@
+package codex
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/johanneserhardt/cxusage/internal/config"
+	"github.com/johanneserhardt/cxusage/internal/types"
+	"github.com/sirupsen/logrus"
+)
+
+// MinCalibrationSamples is the number of turns a model needs for its own parameters
+const MinCalibrationSamples = 10
+
+// calibrationFolds is the number of parts the sessions are split into for cross-validation:
+// the turns of each part are estimated with parameters fitted to the other parts
+const calibrationFolds = 5
+
+// calibrationSample is one turn of a session with both message text and exact usage: the
+// token counts of its messages and the usage Codex logged for its model requests
+type calibrationSample struct {
+	file                  string // session file of the turn
+	model                 string
+	userMessages          int
+	inputText, outputText int
+	input, output         int
+}
+
+// estimate returns the total tokens the estimator makes of a turn's messages
+func (c calibrationSample) estimate(params types.EstimatorParams) float64 {
+	return math.Round(float64(c.inputText)*params.InputRatio+float64(c.userMessages)*params.RequestOverhead) +
+		math.Round(float64(c.outputText)*params.OutputRatio)
+}
+
+// LoadCalibration reads the stored calibration, nil if there is none
+func LoadCalibration(path string) (*types.Calibration, error) {
+	raw, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read calibration: %w", err)
+	}
+	var cal types.Calibration
+	if err := json.Unmarshal(raw, &cal); err != nil {
+		return nil, fmt.Errorf("invalid calibration %s: %w", path, err)
+	}
+	return &cal, nil
+}
+
+// SaveCalibration stores a calibration for later estimates
+func SaveCalibration(path string, cal *types.Calibration) error {
+	raw, err := json.MarshalIndent(cal, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode calibration: %w", err)
+	}
+	if err := os.WriteFile(path, raw, 0644); err != nil {
+		return fmt.Errorf("failed to write calibration: %w", err)
+	}
+	return nil
+}
+
+// LoadTokenEstimator returns an estimator with the stored calibration; without a readable
+// one the built-in parameters are used
+func LoadTokenEstimator(logger *logrus.Logger) *TokenEstimator {
+	path, err := config.GetCalibrationPath()
+	if err != nil {
+		logger.WithError(err).Debug("No estimator calibration")
+		return NewTokenEstimator()
+	}
+	cal, err := LoadCalibration(path)
+	if err != nil {
+		logger.WithError(err).Warn("Ignoring the estimator calibration")
+		return NewTokenEstimator()
+	}
+	return NewCalibratedEstimator(cal)
+}
+
+// CalibrateEstimator fits estimator parameters to the Codex sessions between the dates and
+// reports the accuracy of the fitted estimates, cross-validated so that no turn is
+// estimated with parameters fitted to its own session
+func CalibrateEstimator(cfg *types.Config, startDate, endDate, now time.Time, logger *logrus.Logger) (*types.Calibration, *types.CalibrationReport, error) {
+	samples, err := collectCalibrationSamples(cfg, startDate, endDate, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	cal := fitCalibration(samples, now)
+	report := calibrationReport(samples, cal, crossValidatedEstimates(samples, now), now)
+	report.CrossValidated = true
+	return cal, report, nil
+}
+
+// EstimatorReport reports the accuracy of the estimates of a calibration, nil for the
+// built-in parameters, on the Codex sessions between the dates
+func EstimatorReport(cfg *types.Config, cal *types.Calibration, startDate, endDate, now time.Time, logger *logrus.Logger) (*types.CalibrationReport, error) {
+	samples, err := collectCalibrationSamples(cfg, startDate, endDate, logger)
+	if err != nil {
+		return nil, err
+	}
+	return calibrationReport(samples, cal, calibratedEstimates(samples, cal), now), nil
+}
+
+// calibrationReport evaluates the calibrated estimates of the samples
+func calibrationReport(samples []calibrationSample, cal *types.Calibration, estimates []float64, now time.Time) *types.CalibrationReport {
+	report := &types.CalibrationReport{GeneratedAt: now}
+	if cal != nil {
+		report.CalibratedAt = &cal.CalibratedAt
+	}
+	report.Models, report.Total = evaluateCalibration(samples, cal, estimates)
+	return report
+}
+
+// calibratedEstimates estimates the samples with the parameters of a calibration
+func calibratedEstimates(samples []calibrationSample, cal *types.Calibration) []float64 {
+	estimator := NewCalibratedEstimator(cal)
+	estimates := make([]float64, len(samples))
+	for i, c := range samples {
+		estimates[i] = c.estimate(estimator.Params(c.model))
+	}
+	return estimates
+}
+
+// crossValidatedEstimates estimates each sample with parameters fitted to the sessions of
+// the other folds, so the errors show how the calibration does on sessions it has not seen
+func crossValidatedEstimates(samples []calibrationSample, now time.Time) []float64 {
+	folds := sessionFolds(samples)
+	estimates := make([]float64, len(samples))
+	for fold := 0; fold < calibrationFolds; fold++ {
+		var train []calibrationSample
+		for i, c := range samples {
+			if folds[i] != fold {
+				train = append(train, c)
+			}
+		}
+		estimator := NewCalibratedEstimator(fitCalibration(train, now))
+		for i, c := range samples {
+			if folds[i] == fold {
+				estimates[i] = c.estimate(estimator.Params(c.model))
+			}
+		}
+	}
+	return estimates
+}
+
+// sessionFolds assigns the sessions of the samples to the folds in turn; all turns of a
+// session share a fold, as they are too alike to test each other
+func sessionFolds(samples []calibrationSample) []int {
+	folds := make([]int, len(samples))
+	sessions := make(map[string]int)
+	for i, c := range samples {
+		fold, ok := sessions[c.file]
+		if !ok {
+			fold = len(sessions) % calibrationFolds
+			sessions[c.file] = fold
+		}
+		folds[i] = fold
+	}
+	return folds
+}
+
+// collectCalibrationSamples reads the turns of Codex sessions between the dates that have
+// both message text and logged token counts
+func collectCalibrationSamples(cfg *types.Config, startDate, endDate time.Time, logger *logrus.Logger) ([]calibrationSample, error) {
+	files, err := GetSourceLogFiles(cfg)
+	if err != nil {
+		return nil, err
+	}
+	sourceDefaults, err := LoadSourceDefaults(cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	var samples []calibrationSample
+	for _, file := range files {
+		if info, err := os.Stat(file.Path); err == nil && info.ModTime().Before(startDate) {
+			continue
+		}
+		fileSamples, err := sampleSessionFile(file.Path, sourceDefaults[file.Source], startDate, endDate)
+		if err != nil {
+			logger.WithError(err).WithField("file", filepath.Base(file.Path)).Warn("Failed to parse log file")
+			continue
+		}
+		samples = append(samples, fileSamples...)
+	}
+	logger.WithField("samples", len(samples)).Info("Collected estimator calibration samples")
+	return samples, nil
+}
+
+// sampleSessionFile returns the calibration samples of one session file
+func sampleSessionFile(filename string, defaults SessionDefaults, startDate, endDate time.Time) ([]calibrationSample, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	first, reader, err := readFirstLine(file)
+	if err != nil || first == nil {
+		return nil, err
+	}
+	format := DetectLogFormat(filename, first)
+	if format == nil {
+		format = fallbackFormat
+	}
+
+	state := newSessionState(filename, defaults, NewTokenEstimator(), startDate, endDate)
+	state.sampling = true
+	if err := format.Parse(reader, state); err != nil {
+		return nil, err
+	}
+	state.finish()
+	return state.samples, nil
+}
+
+// fitCalibration fits estimator parameters for each model with enough samples, and
+// default parameters from all of them
+func fitCalibration(samples []calibrationSample, now time.Time) *types.Calibration {
+	cal := &types.Calibration{
+		CalibratedAt: now,
+		Models:       make(map[string]types.ModelCalibration),
+	}
+	for model, modelSamples := range samplesByModel(samples) {
+		if len(modelSamples) >= MinCalibrationSamples {
+			cal.Models[model] = types.ModelCalibration{EstimatorParams: fitParams(modelSamples), Samples: len(modelSamples)}
+		}
+	}
+	if len(samples) >= MinCalibrationSamples {
+		cal.Default = &types.ModelCalibration{EstimatorParams: fitParams(samples), Samples: len(samples)}
+	}
+	return cal
+}
+
+// fitParams fits the parameters to turns by least squares: the input tokens of a turn are
+// its user message text times the input ratio plus the request overhead per user message,
+// its output tokens the assistant message text times the output ratio
+func fitParams(samples []calibrationSample) types.EstimatorParams {
+	var sxx, sxk, skk, sxy, sky, outText, out float64
+	for _, c := range samples {
+		x, k, y := float64(c.inputText), float64(c.userMessages), float64(c.input)
+		sxx += x * x
+		sxk += x * k
+		skk += k * k
+		sxy += x * y
+		sky += k * y
+		outText += float64(c.outputText)
+		out += float64(c.output)
+	}
+
+	params := DefaultEstimatorParams()
+	if det := sxx*skk - sxk*sxk; det > 0 {
+		params.InputRatio = (sxy*skk - sky*sxk) / det
+		params.RequestOverhead = (sky*sxx - sxy*sxk) / det
+	}
+	// Neither term can be negative; fit the other alone
+	switch {
+	case params.InputRatio < 0 && skk > 0:
+		params.InputRatio, params.RequestOverhead = 0, sky/skk
+	case params.RequestOverhead < 0 && sxx > 0:
+		params.InputRatio, params.RequestOverhead = sxy/sxx, 0
+	}
+	if outText > 0 && out > 0 {
+		params.OutputRatio = out / outText
+	}
+	return params
+}
+
+// evaluateCalibration compares the estimates of the samples with the built-in parameters and
+// the calibrated estimates against their exact usage
+func evaluateCalibration(samples []calibrationSample, cal *types.Calibration, estimates []float64) ([]types.CalibrationStats, types.CalibrationStats) {
+	calibrated := NewCalibratedEstimator(cal)
+
+	byModel := make(map[string][]int)
+	all := make([]int, len(samples))
+	for i, c := range samples {
+		byModel[c.model] = append(byModel[c.model], i)
+		all[i] = i
+	}
+
+	var models []types.CalibrationStats
+	for model, indices := range byModel {
+		stats := calibrationStats(samples, estimates, indices)
+		stats.Model = model
+		stats.Params = calibrated.Params(model)
+		_, stats.Calibrated = calibrated.Models[model]
+		models = append(models, stats)
+	}
+	sort.Slice(models, func(i, j int) bool {
+		if models[i].Samples != models[j].Samples {
+			return models[i].Samples > models[j].Samples
+		}
+		return models[i].Model < models[j].Model
+	})
+
+	total := calibrationStats(samples, estimates, all)
+	total.Params = calibrated.Default
+	total.Calibrated = cal != nil && cal.Default != nil
+	return models, total
+}
+
+// calibrationStats computes the errors of the estimates of the samples at the indices, with
+// the built-in parameters and the calibrated estimates
+func calibrationStats(samples []calibrationSample, estimates []float64, indices []int) types.CalibrationStats {
+	stats := types.CalibrationStats{Samples: len(indices)}
+	if len(indices) == 0 {
+		return stats
+	}
+
+	defaults := DefaultEstimatorParams()
+	var exact, defaultSum, calibratedSum, defaultErr, calibratedErr float64
+	for _, i := range indices {
+		c := samples[i]
+		actual := float64(c.input + c.output)
+		d, e := c.estimate(defaults), estimates[i]
+		exact += actual
+		defaultSum += d
+		calibratedSum += e
+		defaultErr += math.Abs(d-actual) / actual
+		calibratedErr += math.Abs(e-actual) / actual
+	}
+	n := float64(len(indices))
+	stats.DefaultError = defaultErr / n * 100
+	stats.CalibratedError = calibratedErr / n * 100
+	stats.DefaultBias = (defaultSum - exact) / exact * 100
+	stats.CalibratedBias = (calibratedSum - exact) / exact * 100
+	return stats
+}
+
+// samplesByModel groups samples by model
+func samplesByModel(samples []calibrationSample) map[string][]calibrationSample {
+	byModel := make(map[string][]calibrationSample)
+	for _, c := range samples {
+		byModel[c.model] = append(byModel[c.model], c)
+	}
+	return byModel
+}
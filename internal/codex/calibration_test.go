@@ -0,0 +1,121 @@
+package codex
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/johanneserhardt/cxusage/internal/types"
+)
+
+func TestSampleSessionFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rollout.jsonl")
+	if err := os.WriteFile(path, []byte(formatsTestWrapped), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	samples, err := sampleSessionFile(path, ResolveDefaults(nil), start, start.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 1 {
+		t.Fatalf("expected 1 sample, got %d: %+v", len(samples), samples)
+	}
+
+	// The repeated token count is not counted twice; the developer message is not part of the turn
+	want := calibrationSample{
+		file:         path,
+		model:        "gpt-5",
+		userMessages: 1,
+		inputText:    CountTokens(EncodingO200kBase, "fix the bug") + MessageOverheadTokens,
+		outputText:   CountTokens(EncodingO200kBase, "fixed it") + MessageOverheadTokens,
+		input:        11000,
+		output:       400,
+	}
+	if samples[0] != want {
+		t.Errorf("got %+v, want %+v", samples[0], want)
+	}
+}
+
+func TestFitCalibration(t *testing.T) {
+	// Turns of a model whose input is twice the text plus 1,000 tokens per request and
+	// whose output is three times the text
+	var samples []calibrationSample
+	for i := 1; i <= MinCalibrationSamples; i++ {
+		k := 1 + i%2
+		samples = append(samples, calibrationSample{
+			model: "gpt-5", userMessages: k, inputText: 50 * i, outputText: 20 * i,
+			input: 2*50*i + 1000*k, output: 3 * 20 * i,
+		})
+	}
+	samples = append(samples, calibrationSample{model: "gpt-4o", userMessages: 1, inputText: 10, outputText: 10, input: 5000, output: 10})
+
+	cal := fitCalibration(samples, time.Now())
+	params, ok := cal.Models["gpt-5"]
+	if !ok || len(cal.Models) != 1 || cal.Default == nil {
+		t.Fatalf("unexpected calibration %+v", cal)
+	}
+	if math.Abs(params.InputRatio-2) > 1e-6 || math.Abs(params.RequestOverhead-1000) > 1e-6 || math.Abs(params.OutputRatio-3) > 1e-6 {
+		t.Errorf("fitted %+v, want input ratio 2, overhead 1000, output ratio 3", params.EstimatorParams)
+	}
+
+	models, total := evaluateCalibration(samples, cal, calibratedEstimates(samples, cal))
+	if len(models) != 2 || models[0].Model != "gpt-5" || !models[0].Calibrated || models[1].Calibrated {
+		t.Fatalf("unexpected stats %+v", models)
+	}
+	if models[0].CalibratedError > 1e-6 || models[0].CalibratedBias > 1e-6 || models[0].DefaultError == 0 {
+		t.Errorf("unexpected errors %+v", models[0])
+	}
+	if total.Samples != len(samples) || !total.Calibrated {
+		t.Errorf("unexpected total %+v", total)
+	}
+
+	// The fitted parameters replace the built-in ones for the model only
+	e := NewCalibratedEstimator(cal)
+	if e.Params("gpt-5") != params.EstimatorParams || e.Params("o3") != cal.Default.EstimatorParams {
+		t.Errorf("unexpected estimator params %+v", e)
+	}
+	if NewCalibratedEstimator(nil).Params("gpt-5") != (types.EstimatorParams{InputRatio: 1, RequestOverhead: DefaultRequestOverheadTokens, OutputRatio: 1}) {
+		t.Errorf("uncalibrated estimator does not use the built-in parameters")
+	}
+}
+
+func TestCrossValidatedEstimates(t *testing.T) {
+	// Sessions whose overhead differs: the turns of a session are estimated with the
+	// overhead fitted to the other sessions, so their error is not fitted away
+	var samples []calibrationSample
+	for s := 0; s < calibrationFolds; s++ {
+		for i := 1; i <= 4; i++ {
+			samples = append(samples, calibrationSample{
+				file: fmt.Sprintf("session-%d.jsonl", s), model: "gpt-5", userMessages: 1,
+				inputText: 50 * i, outputText: 20 * i,
+				input: 2*50*i + 1000 + 200*s, output: 3 * 20 * i,
+			})
+		}
+	}
+
+	folds := sessionFolds(samples)
+	for i, c := range samples {
+		if want := int(c.file[len("session-")] - '0'); folds[i] != want {
+			t.Fatalf("sample %d of %s in fold %d, want %d", i, c.file, folds[i], want)
+		}
+	}
+
+	now := time.Now()
+	cal := fitCalibration(samples, now)
+	_, inSample := evaluateCalibration(samples, cal, calibratedEstimates(samples, cal))
+	_, held := evaluateCalibration(samples, cal, crossValidatedEstimates(samples, now))
+	if held.CalibratedError <= inSample.CalibratedError {
+		t.Errorf("cross-validated error %.2f%% not above in-sample error %.2f%%", held.CalibratedError, inSample.CalibratedError)
+	}
+
+	// The first session is estimated with the parameters of the other four only
+	train := NewCalibratedEstimator(fitCalibration(samples[4:], now))
+	estimates := crossValidatedEstimates(samples, now)
+	if got, want := estimates[0], samples[0].estimate(train.Params("gpt-5")); math.Abs(got-want) > 1e-6 {
+		t.Errorf("held-out estimate %.2f, want %.2f", got, want)
+	}
+}
@@ -0,0 +1,123 @@
+package codex
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/johanneserhardt/cxusage/internal/pricing"
+	"github.com/johanneserhardt/cxusage/internal/types"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultCodexModel is the model Codex CLI uses when config.toml does not set one
+	DefaultCodexModel = "gpt-5-codex"
+	// DefaultCodexProvider is the provider Codex CLI uses when config.toml does not set one
+	DefaultCodexProvider = "openai"
+)
+
+// builtinProviderNames are the display names of providers Codex CLI ships with
+var builtinProviderNames = map[string]string{
+	"openai": "OpenAI",
+	"oss":    "Open Source (Ollama)",
+}
+
+// SessionDefaults are the model and provider a Codex directory is configured to use. They
+// apply to entries whose logs do not name a model or provider themselves.
+type SessionDefaults struct {
+	Model        string `json:"model"`
+	Provider     string `json:"provider"`
+	ProviderName string `json:"provider_name,omitempty"`
+	Profile      string `json:"profile,omitempty"`
+}
+
+// LoadCodexConfig parses a Codex CLI config.toml
+func LoadCodexConfig(path string) (*types.CodexConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var config types.CodexConfig
+	if err := toml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", filepath.Base(path), err)
+	}
+	return &config, nil
+}
+
+// ResolveDefaults applies the active profile of a Codex config and fills in Codex's own
+// defaults; a nil config yields the built-in defaults
+func ResolveDefaults(config *types.CodexConfig) SessionDefaults {
+	defaults := SessionDefaults{Model: DefaultCodexModel, Provider: DefaultCodexProvider}
+	if config != nil {
+		if config.Model != "" {
+			defaults.Model = config.Model
+		}
+		if config.ModelProvider != "" {
+			defaults.Provider = config.ModelProvider
+		}
+		if profile, ok := config.Profiles[config.Profile]; ok && config.Profile != "" {
+			defaults.Profile = config.Profile
+			if profile.Model != "" {
+				defaults.Model = profile.Model
+			}
+			if profile.ModelProvider != "" {
+				defaults.Provider = profile.ModelProvider
+			}
+		}
+	}
+
+	defaults.ProviderName = builtinProviderNames[defaults.Provider]
+	if config != nil {
+		if provider, ok := config.ModelProviders[defaults.Provider]; ok && provider.Name != "" {
+			defaults.ProviderName = provider.Name
+		}
+	}
+	return defaults
+}
+
+// LoadSourceDefaults returns the session defaults of every selected Codex directory by
+// source label. Directories without a readable config.toml get Codex's built-in defaults.
+func LoadSourceDefaults(cfg *types.Config, logger *logrus.Logger) (map[string]SessionDefaults, error) {
+	all, err := GetAllCodexPaths(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	defaults := make(map[string]SessionDefaults, len(all))
+	for _, paths := range all {
+		config, err := LoadCodexConfig(paths.ConfigFile)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			logger.WithError(err).WithField("source", paths.Label).Warn("Failed to read Codex config, using built-in defaults")
+		}
+		defaults[paths.Label] = ResolveDefaults(config)
+	}
+	return defaults, nil
+}
+
+// LoadPriceTable returns the prices configured in the pricing section of the config file.
+// Providers of the selected Codex directories whose base_url is on the local machine are
+// treated as local and cost nothing.
+func LoadPriceTable(cfg *types.Config) *pricing.Table {
+	prices := pricing.New(cfg.Pricing)
+
+	// Without readable Codex directories (e.g. reading a database) only the config applies
+	all, _ := GetAllCodexPaths(cfg)
+	for _, paths := range all {
+		// Unreadable configs are reported by LoadSourceDefaults
+		config, err := LoadCodexConfig(paths.ConfigFile)
+		if err != nil {
+			continue
+		}
+		for id, provider := range config.ModelProviders {
+			if pricing.IsLocalURL(provider.BaseURL) {
+				prices.AddLocalProvider(id)
+			}
+		}
+	}
+	return prices
+}
@@ -0,0 +1,117 @@
+package codex
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/johanneserhardt/cxusage/internal/pricing"
+	"github.com/sirupsen/logrus"
+)
+
+const configTestToml = `model = "gpt-5"
+model_provider = "openai"
+profile = "work"
+
+[model_providers.azure]
+name = "Azure OpenAI"
+base_url = "https://example.openai.azure.com/openai"
+env_key = "AZURE_OPENAI_API_KEY"
+
+[profiles.work]
+model = "gpt-5-codex"
+model_provider = "azure"
+
+[profiles.local]
+model = "qwen3-coder"
+model_provider = "oss"
+`
+
+func TestLoadCodexConfigProfiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(configTestToml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := LoadCodexConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.ModelProviders["azure"].EnvKey != "AZURE_OPENAI_API_KEY" {
+		t.Errorf("provider not parsed: %+v", config.ModelProviders)
+	}
+
+	defaults := ResolveDefaults(config)
+	want := SessionDefaults{Model: "gpt-5-codex", Provider: "azure", ProviderName: "Azure OpenAI", Profile: "work"}
+	if defaults != want {
+		t.Errorf("active profile: got %+v, want %+v", defaults, want)
+	}
+
+	config.Profile = "local"
+	defaults = ResolveDefaults(config)
+	want = SessionDefaults{Model: "qwen3-coder", Provider: "oss", ProviderName: "Open Source (Ollama)", Profile: "local"}
+	if defaults != want {
+		t.Errorf("local profile: got %+v, want %+v", defaults, want)
+	}
+
+	// An undefined profile leaves the top-level settings in place
+	config.Profile = "missing"
+	if defaults = ResolveDefaults(config); defaults.Model != "gpt-5" || defaults.Provider != "openai" || defaults.Profile != "" {
+		t.Errorf("missing profile: got %+v", defaults)
+	}
+
+	if defaults = ResolveDefaults(nil); defaults.Model != DefaultCodexModel || defaults.Provider != DefaultCodexProvider {
+		t.Errorf("built-in defaults: got %+v", defaults)
+	}
+}
+
+func TestLoadCodexConfigInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte("model = \n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadCodexConfig(path); err == nil {
+		t.Error("expected an error for invalid TOML")
+	}
+}
+
+const configTestRollout = `{"timestamp":"2026-10-18T09:00:00Z","type":"session_meta","payload":{"id":"sess-cfg","timestamp":"2026-10-18T09:00:00Z","cwd":"/home/u/app","model_provider":"azure"}}
+{"type":"message","role":"user","content":[{"type":"input_text","text":"first question"}],"timestamp":"2026-10-18T09:00:01Z"}
+{"timestamp":"2026-10-18T09:00:02Z","type":"turn_context","payload":{"model":"o3","effort":"high"}}
+{"type":"message","role":"assistant","content":[{"type":"output_text","text":"an answer"}],"timestamp":"2026-10-18T09:00:05Z"}
+{"type":"message","role":"assistant","model":"gpt-4.1","content":[{"type":"output_text","text":"named model"}],"timestamp":"2026-10-18T09:00:06Z"}
+`
+
+func TestParseCodexSessionFileModelFallback(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rollout.jsonl")
+	if err := os.WriteFile(path, []byte(configTestRollout), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	defaults := SessionDefaults{Model: "gpt-5-codex", Provider: "openai"}
+	entries, err := parseCodexSessionFile(path, defaults, pricing.Default(), NewTokenEstimator(), start, start.AddDate(0, 0, 1), logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+
+	// Configured default, then the turn's model, then the model named by the message
+	for i, want := range []string{"gpt-5-codex", "o3", "gpt-4.1"} {
+		if entries[i].Model != want {
+			t.Errorf("entry %d: model %s, want %s", i, entries[i].Model, want)
+		}
+		if entries[i].Provider != "azure" {
+			t.Errorf("entry %d: provider %s, want the session's azure", i, entries[i].Provider)
+		}
+	}
+
+	// Estimated costs follow the model instead of a gpt-4o baseline
+	estimator := NewTokenEstimator()
+	if got, want := entries[0].Cost, estimator.EstimateCostFromTokens("gpt-5-codex", entries[0].Usage.PromptTokens, 0); got != want {
+		t.Errorf("cost %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,391 @@
+package codex
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/johanneserhardt/cxusage/internal/jsonl"
+	"github.com/johanneserhardt/cxusage/internal/pricing"
+	"github.com/johanneserhardt/cxusage/internal/types"
+	"github.com/sirupsen/logrus"
+)
+
+// futureTolerance allows for small clock differences before a timestamp counts as in the future
+const futureTolerance = 5 * time.Minute
+
+// knownRecordTypes are the top-level line types written by Codex CLI, including the
+// unwrapped items of older rollouts
+var knownRecordTypes = map[string]bool{
+	"session_meta": true, "response_item": true, "event_msg": true, "turn_context": true, "compacted": true,
+	"message": true, "reasoning": true, "function_call": true, "function_call_output": true,
+	"local_shell_call": true, "custom_tool_call": true, "custom_tool_call_output": true, "web_search_call": true,
+}
+
+// knownPayloadTypes are the payload types of wrapped records
+var knownPayloadTypes = map[string]map[string]bool{
+	"response_item": {
+		"message": true, "reasoning": true, "function_call": true, "function_call_output": true,
+		"local_shell_call": true, "custom_tool_call": true, "custom_tool_call_output": true,
+		"web_search_call": true, "ghost_snapshot": true,
+	},
+	"event_msg": {
+		"token_count": true, "user_message": true, "agent_message": true, "agent_message_delta": true,
+		"agent_reasoning": true, "agent_reasoning_delta": true, "agent_reasoning_raw_content": true,
+		"agent_reasoning_section_break": true, "task_started": true, "task_complete": true,
+		"turn_aborted": true, "exec_command_begin": true, "exec_command_output_delta": true,
+		"exec_command_end": true, "exec_approval_request": true, "apply_patch_approval_request": true,
+		"patch_apply_begin": true, "patch_apply_end": true, "mcp_tool_call_begin": true,
+		"mcp_tool_call_end": true, "web_search_begin": true, "web_search_end": true,
+		"view_image_tool_call": true, "turn_diff": true, "plan_update": true, "error": true,
+		"stream_error": true, "background_event": true, "session_configured": true,
+		"entered_review_mode": true, "exited_review_mode": true, "shutdown_complete": true,
+	},
+}
+
+// doctorChecks lists every check with its severity, in report order
+var doctorChecks = []types.DoctorSummary{
+	{Check: types.CheckUnparseableLine, Severity: types.SeverityError},
+	{Check: types.CheckLineTooLong, Severity: types.SeverityError},
+	{Check: types.CheckReadError, Severity: types.SeverityError},
+	{Check: types.CheckDuplicateKey, Severity: types.SeverityError},
+	{Check: types.CheckUnknownFormat, Severity: types.SeverityWarning},
+	{Check: types.CheckUnknownType, Severity: types.SeverityWarning},
+	{Check: types.CheckUnpricedModel, Severity: types.SeverityWarning},
+	{Check: types.CheckMissingMetadata, Severity: types.SeverityWarning},
+	{Check: types.CheckFutureTimestamp, Severity: types.SeverityWarning},
+	{Check: types.CheckOutOfOrder, Severity: types.SeverityWarning},
+}
+
+// doctorEntry remembers where an entry key was first seen
+type doctorEntry struct {
+	file  string
+	line  int
+	model string
+	usage types.Usage
+}
+
+// doctorOccurrence counts a problem that is reported once, at its first occurrence
+type doctorOccurrence struct {
+	file  string
+	line  int
+	count int
+}
+
+// diagnosis collects the findings of a doctor run
+type diagnosis struct {
+	report   *types.DoctorReport
+	now      time.Time
+	prices   *pricing.Table
+	sessions map[string]bool
+	seen     map[string]doctorEntry
+	unknown  map[string]*doctorOccurrence
+	unpriced map[string]*doctorOccurrence
+	redactor *Redactor // nil without --redact
+}
+
+// Diagnose scans every selected log file for lines the parser cannot read, record types it
+// does not know, clock anomalies, unpriced models and colliding entry keys
+func Diagnose(cfg *types.Config, now time.Time, logger *logrus.Logger) (*types.DoctorReport, error) {
+	files, err := GetSourceLogFiles(cfg)
+	if err != nil {
+		return nil, err
+	}
+	sourceDefaults, err := LoadSourceDefaults(cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &diagnosis{
+		report: &types.DoctorReport{
+			GeneratedAt: now,
+			Files:       len(files),
+			Formats:     make(map[string]int),
+			RecordTypes: make(map[string]int),
+			Summary:     []types.DoctorSummary{},
+			Issues:      []types.DoctorIssue{},
+		},
+		now:      now,
+		prices:   LoadPriceTable(cfg),
+		sessions: make(map[string]bool),
+		seen:     make(map[string]doctorEntry),
+		unknown:  make(map[string]*doctorOccurrence),
+		unpriced: make(map[string]*doctorOccurrence),
+	}
+	// File names and entry keys hold the home directory and session ids
+	if RedactionEnabled(cfg) {
+		if d.redactor, err = NewRedactor(cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, file := range files {
+		format, ok, err := detectFileFormat(file.Path)
+		if err != nil {
+			d.add(types.CheckReadError, file.Path, 0, err.Error())
+			continue
+		}
+		if format == nil && ok {
+			d.add(types.CheckUnknownFormat, file.Path, 1, fmt.Sprintf("no known log format (%s) matches; read as %s",
+				strings.Join(LogFormatNames(), ", "), fallbackFormat.Name()))
+			format = fallbackFormat
+		}
+		if format != nil {
+			d.report.Formats[format.Name()]++
+		}
+
+		// Lines are only checked in JSONL files
+		if filepath.Ext(file.Path) == ".jsonl" {
+			if err := d.scanFile(file.Path); err != nil {
+				d.add(types.CheckReadError, file.Path, 0, err.Error())
+				continue
+			}
+		}
+		d.checkEntries(file, sourceDefaults[file.Source], logger)
+	}
+	d.finish()
+	if d.redactor != nil {
+		if err := d.redactor.Save(); err != nil {
+			logger.WithError(err).Warn("Failed to save redaction state")
+		}
+	}
+
+	logger.WithFields(logrus.Fields{"files": len(files), "issues": len(d.report.Issues)}).Info("Diagnosed Codex log files")
+	return d.report, nil
+}
+
+// scanFile checks every line of a log file on its own
+func (d *diagnosis) scanFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// A reader rather than a scanner, so lines too long for the parsers are still seen
+	reader := bufio.NewReader(file)
+	var previous time.Time
+	previousLine := 0
+	for lineNum := 1; ; lineNum++ {
+		text, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if text == "" && err == io.EOF {
+			break
+		}
+		d.report.Lines++
+
+		line := strings.TrimSpace(text)
+		if lineNum == 1 {
+			d.checkMetadata(path, line)
+		}
+		if len(text) > jsonl.MaxLineSize {
+			d.add(types.CheckLineTooLong, path, lineNum, fmt.Sprintf("line of %s exceeds the %d MB parser buffer and is skipped in reports",
+				formatBytes(len(text)), jsonl.MaxLineSize/(1024*1024)))
+			continue
+		}
+		if line == "" {
+			continue
+		}
+
+		var raw map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &raw); err != nil {
+			d.add(types.CheckUnparseableLine, path, lineNum, fmt.Sprintf("invalid JSON: %v", err))
+			continue
+		}
+		d.checkRecordType(path, lineNum, raw)
+
+		timeStr, ok := raw["timestamp"].(string)
+		if !ok {
+			continue
+		}
+		timestamp, err := time.Parse(time.RFC3339, timeStr)
+		if err != nil {
+			d.add(types.CheckUnparseableLine, path, lineNum, fmt.Sprintf("invalid timestamp %q", timeStr))
+			continue
+		}
+		if timestamp.After(d.now.Add(futureTolerance)) {
+			d.add(types.CheckFutureTimestamp, path, lineNum, fmt.Sprintf("timestamp %s is in the future", timeStr))
+		}
+		if timestamp.Before(previous) {
+			d.add(types.CheckOutOfOrder, path, lineNum, fmt.Sprintf("timestamp %s is earlier than line %d (%s)",
+				timeStr, previousLine, previous.Format(time.RFC3339)))
+		} else {
+			previous, previousLine = timestamp, lineNum
+		}
+	}
+	return nil
+}
+
+// checkMetadata reports session files whose first line carries no session id
+func (d *diagnosis) checkMetadata(path, line string) {
+	metadata, err := parseSessionMetadata(line)
+	if err != nil || metadata.ID == "" {
+		d.add(types.CheckMissingMetadata, path, 1, "first line has no session id; entries are not attributed to a session")
+		return
+	}
+	if !d.sessions[metadata.ID] {
+		d.sessions[metadata.ID] = true
+		d.report.Sessions++
+	}
+}
+
+// checkRecordType counts the type of a line and notes types the parsers do not know
+func (d *diagnosis) checkRecordType(path string, lineNum int, raw map[string]interface{}) {
+	recordType, _ := raw["type"].(string)
+	if recordType == "" {
+		// Older rollouts start with bare session metadata and record state lines
+		if _, ok := raw["record_type"]; ok {
+			recordType, _ = raw["record_type"].(string)
+			d.report.RecordTypes["record_type/"+recordType]++
+			return
+		}
+		if _, ok := raw["id"]; ok && lineNum == 1 {
+			d.report.RecordTypes["session_meta"]++
+			return
+		}
+		d.noteUnknown(path, lineNum, "(none)")
+		return
+	}
+	if !knownRecordTypes[recordType] {
+		d.noteUnknown(path, lineNum, recordType)
+		return
+	}
+
+	known, wrapped := knownPayloadTypes[recordType]
+	if !wrapped {
+		d.report.RecordTypes[recordType]++
+		return
+	}
+	payloadType, _ := getNestedString(raw, "payload", "type")
+	name := recordType + "/" + payloadType
+	if !known[payloadType] {
+		d.noteUnknown(path, lineNum, name)
+		return
+	}
+	d.report.RecordTypes[name]++
+}
+
+// noteUnknown counts an unknown record type, reported once at its first occurrence
+func (d *diagnosis) noteUnknown(path string, lineNum int, recordType string) {
+	d.report.RecordTypes[recordType]++
+	if occurrence, ok := d.unknown[recordType]; ok {
+		occurrence.count++
+		return
+	}
+	d.unknown[recordType] = &doctorOccurrence{file: path, line: lineNum, count: 1}
+}
+
+// checkEntries parses a file like the reports do and checks the resulting entries for
+// unpriced models and entry keys shared by different entries
+func (d *diagnosis) checkEntries(file types.CodexLogFile, defaults SessionDefaults, logger *logrus.Logger) {
+	entries, err := parseCodexSessionFile(file.Path, defaults, d.prices, NewTokenEstimator(), time.Time{}, d.now.AddDate(100, 0, 0), logger)
+	if err != nil {
+		d.add(types.CheckReadError, file.Path, 0, err.Error())
+		return
+	}
+
+	for _, e := range entries {
+		if _, ok := d.prices.Lookup(e.Provider, e.Model); !ok {
+			key := e.Provider + "|" + e.Model
+			if occurrence, ok := d.unpriced[key]; ok {
+				occurrence.count++
+			} else {
+				d.unpriced[key] = &doctorOccurrence{file: e.File, line: e.Line, count: 1}
+			}
+		}
+
+		key := EntryKey(e)
+		first, ok := d.seen[key]
+		if !ok {
+			d.seen[key] = doctorEntry{file: e.File, line: e.Line, model: e.Model, usage: e.Usage}
+			d.report.Entries++
+			continue
+		}
+		if first.model == e.Model && first.usage == e.Usage {
+			d.report.Duplicates++
+			continue
+		}
+		if d.redactor != nil {
+			key = d.redactor.EntryKey(key)
+		}
+		d.add(types.CheckDuplicateKey, e.File, e.Line, fmt.Sprintf("entry key %q is also used by a different entry at %s:%d; only the first is counted",
+			key, d.fileName(first.file), first.line))
+	}
+}
+
+// finish adds the issues reported once per type or model and summarizes all issues
+func (d *diagnosis) finish() {
+	for _, recordType := range sortedKeys(d.unknown) {
+		occurrence := d.unknown[recordType]
+		d.add(types.CheckUnknownType, occurrence.file, occurrence.line, fmt.Sprintf("unknown record type %s (%d lines)", recordType, occurrence.count))
+	}
+	for _, key := range sortedKeys(d.unpriced) {
+		occurrence := d.unpriced[key]
+		provider, model, _ := strings.Cut(key, "|")
+		d.add(types.CheckUnpricedModel, occurrence.file, occurrence.line, fmt.Sprintf("model %s at provider %s has no price; %d entries are priced as %s",
+			model, provider, occurrence.count, fallbackPricingModel))
+	}
+
+	for _, check := range doctorChecks {
+		for _, issue := range d.report.Issues {
+			if issue.Check == check.Check {
+				check.Count++
+			}
+		}
+		if check.Count > 0 {
+			d.report.Summary = append(d.report.Summary, check)
+		}
+	}
+}
+
+// add records an issue with the severity of its check
+func (d *diagnosis) add(check types.DoctorCheck, file string, line int, message string) {
+	severity := types.SeverityWarning
+	for _, c := range doctorChecks {
+		if c.Check == check {
+			severity = c.Severity
+		}
+	}
+	// Read errors name the file too
+	if name := d.fileName(file); name != file {
+		message = strings.ReplaceAll(message, file, name)
+		file = name
+	}
+	d.report.Issues = append(d.report.Issues, types.DoctorIssue{
+		Check:    check,
+		Severity: severity,
+		File:     file,
+		Line:     line,
+		Message:  message,
+	})
+}
+
+// fileName returns the name of a log file as shown in the report
+func (d *diagnosis) fileName(path string) string {
+	if d.redactor == nil {
+		return path
+	}
+	return d.redactor.File(path)
+}
+
+func sortedKeys(m map[string]*doctorOccurrence) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// formatBytes formats a size in bytes as megabytes
+func formatBytes(n int) string {
+	return fmt.Sprintf("%.1f MB", float64(n)/(1024*1024))
+}
@@ -0,0 +1,105 @@
+package codex
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/johanneserhardt/cxusage/internal/jsonl"
+	"github.com/johanneserhardt/cxusage/internal/types"
+	"github.com/sirupsen/logrus"
+)
+
+const doctorTestRollout = `{"timestamp":"2026-10-18T09:00:00Z","type":"session_meta","payload":{"id":"sess-doc","timestamp":"2026-10-18T09:00:00Z"}}
+{"type":"message","role":"user","id":"m1","content":[{"type":"input_text","text":"question"}],"timestamp":"2026-10-18T09:00:05Z"}
+{"type":"message","role":"assistant","id":"m1","model":"mystery-model","content":[{"type":"output_text","text":"answer"}],"timestamp":"2026-10-18T09:00:05Z"}
+{"type":"message", oops
+{"timestamp":"2026-10-18T08:59:00Z","type":"event_msg","payload":{"type":"brand_new_event"}}
+{"timestamp":"2026-10-18T09:01:00Z","type":"event_msg","payload":{"type":"brand_new_event"}}
+{"timestamp":"2027-01-01T00:00:00Z","type":"event_msg","payload":{"type":"token_count"}}
+`
+
+func TestDiagnose(t *testing.T) {
+	dir := t.TempDir()
+	sessions := filepath.Join(dir, "sessions")
+	if err := os.MkdirAll(sessions, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(sessions, "rollout-a.jsonl"), []byte(doctorTestRollout), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	huge := `{"type":"message","role":"user","content":[{"type":"input_text","text":"` + strings.Repeat("x", jsonl.MaxLineSize) + `"}]}` + "\n"
+	if err := os.WriteFile(filepath.Join(sessions, "rollout-b.jsonl"), []byte(huge), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	report, err := Diagnose(&types.Config{CodexPath: dir}, now, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[types.DoctorCheck][]types.DoctorIssue)
+	for _, issue := range report.Issues {
+		got[issue.Check] = append(got[issue.Check], issue)
+	}
+	want := map[types.DoctorCheck]int{
+		types.CheckUnparseableLine: 4,
+		types.CheckDuplicateKey:    3,
+		types.CheckUnknownType:     5, // reported once per type
+		types.CheckUnpricedModel:   3,
+		types.CheckFutureTimestamp: 7,
+		types.CheckOutOfOrder:      5,
+		types.CheckLineTooLong:     1,
+		types.CheckMissingMetadata: 1,
+	}
+	for check, line := range want {
+		if len(got[check]) != 1 || got[check][0].Line != line {
+			t.Errorf("%s: got %+v, want one issue at line %d", check, got[check], line)
+		}
+	}
+	if len(got[types.CheckReadError]) != 0 {
+		t.Errorf("overlong line also reported as read error: %+v", got[types.CheckReadError])
+	}
+	if !strings.Contains(got[types.CheckUnknownType][0].Message, "event_msg/brand_new_event (2 lines)") {
+		t.Errorf("unknown type message: %s", got[types.CheckUnknownType][0].Message)
+	}
+	if report.Errors() != 3 || report.Sessions != 1 || report.RecordTypes["event_msg/token_count"] != 1 {
+		t.Errorf("unexpected report: errors %d, sessions %d, types %v", report.Errors(), report.Sessions, report.RecordTypes)
+	}
+}
+
+func TestDiagnoseRedactsFileNames(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	sessions := filepath.Join(dir, "sessions")
+	if err := os.MkdirAll(sessions, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(sessions, "rollout-sess-doc.jsonl"), []byte(doctorTestRollout), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	report, err := Diagnose(&types.Config{CodexPath: dir, Redact: types.RedactModeAlias}, now, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Issues) == 0 {
+		t.Fatal("expected issues")
+	}
+	raw, _ := json.Marshal(report)
+	for _, secret := range []string{dir, "sess-doc", "rollout-sess"} {
+		if strings.Contains(string(raw), secret) {
+			t.Errorf("redacted report still contains %q: %s", secret, raw)
+		}
+	}
+	for _, issue := range report.Issues {
+		if issue.File != "file-1.jsonl" {
+			t.Errorf("%s: file %q, want file-1.jsonl", issue.Check, issue.File)
+		}
+	}
+}
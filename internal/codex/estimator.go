@@ -2,9 +2,10 @@ package codex
 
 import (
     "encoding/json"
+    "math"
     "strings"
-    "unicode/utf8"
 
+    "github.com/johanneserhardt/cxusage/internal/pricing"
     "github.com/johanneserhardt/cxusage/internal/types"
 )
 
@@ -24,132 +25,132 @@ type Content struct {
 	Text string `json:"text,omitempty"`
 }
 
-// TokenEstimator provides token counting functionality for Codex CLI content
+// Overhead the tokenizer cannot see in the logs, added to the exact token count of the
+// logged text:
+//
+//   - every message is framed by role and separator tokens of the chat format
+//   - every request (user message) also sends the Codex CLI system prompt and tool
+//     schemas (shell, apply_patch, update_plan, ...), which are not logged
+//
+// `cx estimator calibrate` replaces the request overhead and scales the counts with
+// parameters fitted from sessions with exact usage.
+const (
+	MessageOverheadTokens        = 4
+	DefaultRequestOverheadTokens = 3000
+)
+
+// DefaultEstimatorParams are the parameters of models without a calibration
+func DefaultEstimatorParams() types.EstimatorParams {
+	return types.EstimatorParams{InputRatio: 1, RequestOverhead: DefaultRequestOverheadTokens, OutputRatio: 1}
+}
+
+// TokenEstimator estimates the usage of messages logged without it, by counting their text
+// with the BPE encoding of the model and adding the overhead of the request
 type TokenEstimator struct {
-    // Estimation constants based on OpenAI's guidelines
-    CharactersPerToken float64
+	Default types.EstimatorParams
+	Models  map[string]types.EstimatorParams // calibrated parameters by model
 }
 
 // NewTokenEstimator creates a new token estimator
 func NewTokenEstimator() *TokenEstimator {
 	return &TokenEstimator{
-		CharactersPerToken: 1.5, // Adjusted based on real Codex CLI usage (8250/1290 ≈ 6.4x, so 4.0/6.4 ≈ 0.6, using 1.5 for safety)
+		Default: DefaultEstimatorParams(),
 	}
 }
 
-// EstimateTokens estimates token count from text content
-func (e *TokenEstimator) EstimateTokens(text string) int {
-	if text == "" {
-		return 0
+// NewCalibratedEstimator creates a token estimator with the parameters of a calibration
+func NewCalibratedEstimator(cal *types.Calibration) *TokenEstimator {
+	e := NewTokenEstimator()
+	if cal == nil {
+		return e
+	}
+	if cal.Default != nil {
+		e.Default = cal.Default.EstimatorParams
 	}
-	
-	// Count UTF-8 characters (more accurate than byte length)
-	charCount := utf8.RuneCountInString(text)
-	
-	// Apply character-to-token ratio
-	tokenEstimate := float64(charCount) / e.CharactersPerToken
-	
-	// Apply overhead factor based on real Codex CLI usage patterns
-	// Accounts for system prompts, reasoning tokens, function calls, etc.
-	overheadFactor := 2.8 // Based on 8250/1290 ≈ 6.4x observed, but conservative
-	tokenEstimate *= overheadFactor
-	
-	// Round to nearest integer, minimum 1 for non-empty text
-	if tokenEstimate < 1 && charCount > 0 {
-		return 1
+	e.Models = make(map[string]types.EstimatorParams, len(cal.Models))
+	for model, m := range cal.Models {
+		e.Models[model] = m.EstimatorParams
 	}
-	
-	return int(tokenEstimate + 0.5) // Round to nearest
+	return e
 }
 
-// EstimateTokensFromMessage estimates tokens from a Codex message
-func (e *TokenEstimator) EstimateTokensFromMessage(msg CodexMessage) (inputTokens, outputTokens int) {
-	// Extract all text content from the message
-	var allText strings.Builder
-	
-    for _, content := range msg.Content {
-        if strings.TrimSpace(content.Text) != "" {
-            allText.WriteString(content.Text)
-            allText.WriteString(" ")
-        }
-    }
-	
-	textContent := strings.TrimSpace(allText.String())
-	totalTokens := e.EstimateTokens(textContent)
-	
+// Params returns the parameters the estimates of a model are made with
+func (e *TokenEstimator) Params(model string) types.EstimatorParams {
+	if params, ok := e.Models[model]; ok {
+		return params
+	}
+	return e.Default
+}
+
+// EstimateTokens counts the tokens of text in the default encoding, without overhead
+func (e *TokenEstimator) EstimateTokens(text string) int {
+	return CountTokens(defaultEncoding, text)
+}
+
+// EstimateTokensFromMessage estimates the usage of a message of a model: user messages are
+// the input of a request and assistant messages its output
+func (e *TokenEstimator) EstimateTokensFromMessage(msg CodexMessage, model string) (inputTokens, outputTokens int) {
+	params := e.Params(model)
+	tokens := float64(messageTokens(msg, model))
+
 	// Determine if this is input (user) or output (assistant)
 	switch msg.Role {
 	case "user":
-		inputTokens = totalTokens
-		outputTokens = 0
-	case "assistant":
-		inputTokens = 0
-		outputTokens = totalTokens
+		inputTokens = int(math.Round(tokens*params.InputRatio + params.RequestOverhead))
 	default:
 		// For messages without clear role, assume it's output
-		inputTokens = 0
-		outputTokens = totalTokens
+		outputTokens = int(math.Round(tokens * params.OutputRatio))
 	}
-	
 	return inputTokens, outputTokens
 }
 
-// EstimateCostFromTokens estimates cost based on model and token counts
-func (e *TokenEstimator) EstimateCostFromTokens(model string, inputTokens, outputTokens int) float64 {
-	// Default to gpt-4o if model not specified or not recognized
-	if model == "" {
-		model = "gpt-4o"
+// messageTokens counts the text of a message in the encoding of a model, with its framing
+func messageTokens(msg CodexMessage, model string) int {
+	// Extract all text content from the message
+	var allText strings.Builder
+	for _, content := range msg.Content {
+		if strings.TrimSpace(content.Text) != "" {
+			allText.WriteString(content.Text)
+			allText.WriteString(" ")
+		}
 	}
-	
-	// Create usage object for cost calculation
+	return CountTokens(EncodingForModel(model), strings.TrimSpace(allText.String())) + MessageOverheadTokens
+}
+
+// fallbackPricingModel prices estimates for models without a known price
+const fallbackPricingModel = "gpt-4o"
+
+// EstimateCostFromTokens estimates cost based on model and token counts at list prices
+func (e *TokenEstimator) EstimateCostFromTokens(model string, inputTokens, outputTokens int) float64 {
 	usage := types.Usage{
 		PromptTokens:     inputTokens,
 		CompletionTokens: outputTokens,
 		TotalTokens:      inputTokens + outputTokens,
 	}
-	
-	// Use existing cost calculation with estimated tokens
-	cost, err := calculateCostSafely(model, usage)
-	if err != nil {
-		// Fallback: estimate with gpt-4o pricing if model not found
-		cost, _ = calculateCostSafely("gpt-4o", usage)
-	}
-	
+	return EstimateCost(pricing.Default(), "", model, usage)
+}
+
+// EstimateCost prices usage by provider and model. Models without a known price are
+// estimated at the gpt-4o baseline, unless their provider runs locally.
+func EstimateCost(prices *pricing.Table, provider, model string, usage types.Usage) float64 {
+	cost, _ := PriceUsage(prices, provider, model, usage)
 	return cost
 }
 
-// calculateCostSafely wraps cost calculation with error handling
-func calculateCostSafely(model string, usage types.Usage) (float64, error) {
-    // Minimal per-model pricing table (fallbacks to gpt-4o baseline)
-    type rate struct{ inPerM, outPerM float64 }
-    pricing := map[string]rate{
-        // Known baseline (OpenAI public pricing, subject to change)
-        "gpt-4o":        {inPerM: 5.0, outPerM: 15.0},
-        "gpt-4o-mini":   {inPerM: 0.15, outPerM: 0.6},
-        "gpt-4":         {inPerM: 10.0, outPerM: 30.0},
-        "gpt-3.5-turbo": {inPerM: 0.5, outPerM: 1.5},
-    }
-
-    // Pick pricing by exact or prefix match, else fallback
-    r, ok := pricing[model]
-    if !ok {
-        // Try loose prefix match for variants like gpt-4o-mini-...
-        for k, v := range pricing {
-            if strings.HasPrefix(model, k) {
-                r = v
-                ok = true
-                break
-            }
-        }
-    }
-
-    if !ok {
-        r = pricing["gpt-4o"]
-    }
-
-    inputCost := float64(usage.PromptTokens) * r.inPerM / 1000000.0
-    outputCost := float64(usage.CompletionTokens) * r.outPerM / 1000000.0
-    return inputCost + outputCost, nil
+// PriceUsage prices usage like EstimateCost and reports whether the price of the model was
+// known; unnamed models are priced as Codex's default model, which is an estimate too
+func PriceUsage(prices *pricing.Table, provider, model string, usage types.Usage) (float64, bool) {
+	// Default to Codex's own default model if none is known
+	if model == "" {
+		cost, _ := PriceUsage(prices, provider, DefaultCodexModel, usage)
+		return cost, false
+	}
+
+	if cost, ok := prices.Cost(provider, model, usage); ok {
+		return cost, true
+	}
+	cost, _ := prices.Cost(provider, fallbackPricingModel, usage)
+	return cost, false
 }
 
 // ParseCodexMessage parses a JSONL line into a Codex message
@@ -161,16 +162,9 @@ func ParseCodexMessage(line string) (*CodexMessage, error) {
 	return &msg, nil
 }
 
-// ExtractModelFromMessage attempts to extract model information from message
+// ExtractModelFromMessage returns the model named by a message, or "" when it names none
 func ExtractModelFromMessage(msg CodexMessage) string {
-	// Check if model is directly specified
-	if msg.Model != "" {
-		return msg.Model
-	}
-	
-	// Try to extract from message content or other fields
-	// For Codex CLI, we might need to infer the model
-	
-	// Default to gpt-4o for estimation
-	return "gpt-4o"
+	// Only the model named by the message itself; callers fall back to the turn's
+	// model or the default from Codex's config.toml
+	return msg.Model
 }
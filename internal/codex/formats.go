@@ -0,0 +1,280 @@
+package codex
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/johanneserhardt/cxusage/internal/jsonl"
+)
+
+// LogFormat reads one version of the Codex CLI session log format
+type LogFormat interface {
+	// Name identifies the format in logs and diagnostics
+	Name() string
+	// Detect reports whether a file uses this format, given its path and first non-empty line
+	Detect(path string, first []byte) bool
+	// Parse reads a whole session file into the session state
+	Parse(r io.Reader, s *SessionState) error
+}
+
+// logFormats are the registered formats, detected in order
+var logFormats []LogFormat
+
+// fallbackFormat reads files no format recognizes; it accepts both wrapped and flat records
+var fallbackFormat LogFormat = wrappedFormat{}
+
+// maxDetectLines is how many leading empty lines detection skips to find the first record
+const maxDetectLines = 100
+
+// RegisterLogFormat adds a log format. Formats are tried in the order they are registered,
+// so specific formats go before general ones.
+func RegisterLogFormat(format LogFormat) {
+	logFormats = append(logFormats, format)
+}
+
+// LogFormatNames returns the names of the registered formats
+func LogFormatNames() []string {
+	names := make([]string, len(logFormats))
+	for i, format := range logFormats {
+		names[i] = format.Name()
+	}
+	return names
+}
+
+// DetectLogFormat returns the format of a file from its path and first non-empty line,
+// or nil if no registered format recognizes it
+func DetectLogFormat(path string, first []byte) LogFormat {
+	for _, format := range logFormats {
+		if format.Detect(path, first) {
+			return format
+		}
+	}
+	return nil
+}
+
+// detectFileFormat returns the format of a file, nil if no format recognizes it. Empty
+// files have no format and are not recognized.
+func detectFileFormat(path string) (LogFormat, bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, false, err
+	}
+	defer file.Close()
+
+	first, _, err := readFirstLine(file)
+	if err != nil || first == nil {
+		return nil, false, err
+	}
+	return DetectLogFormat(path, first), true, nil
+}
+
+// readFirstLine reads up to the first non-empty line of a file. It returns that line and
+// a reader that yields the whole file again.
+func readFirstLine(r io.Reader) ([]byte, io.Reader, error) {
+	reader := bufio.NewReader(r)
+	var consumed bytes.Buffer
+	for i := 0; i < maxDetectLines; i++ {
+		line, err := reader.ReadBytes('\n')
+		consumed.Write(line)
+		if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 {
+			return trimmed, io.MultiReader(&consumed, reader), nil
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return nil, io.MultiReader(&consumed, reader), nil
+}
+
+// recordTimestamp parses the timestamp of a record, zero if missing or invalid
+func recordTimestamp(value string) time.Time {
+	timestamp, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}
+	}
+	return timestamp
+}
+
+// responseItemTypes are the item types written at the top level of flat rollouts
+var responseItemTypes = map[string]bool{
+	"message": true, "reasoning": true, "function_call": true, "function_call_output": true,
+	"local_shell_call": true, "custom_tool_call": true, "custom_tool_call_output": true, "web_search_call": true,
+}
+
+// wrappedFormat reads rollout-v3 files, the current Codex CLI format: every line is a
+// record with a timestamp, a type (session_meta, turn_context, response_item, event_msg,
+// ...) and a payload. Token counts logged in event_msg records replace estimates. Flat
+// message lines, as in rollout-v2, are read too.
+type wrappedFormat struct{}
+
+func (wrappedFormat) Name() string { return "rollout-v3" }
+
+func (wrappedFormat) Detect(path string, first []byte) bool {
+	var record struct {
+		Type    string          `json:"type"`
+		Payload json.RawMessage `json:"payload"`
+	}
+	if err := json.Unmarshal(first, &record); err != nil || record.Payload == nil {
+		return false
+	}
+	switch record.Type {
+	case "session_meta", "turn_context", "response_item", "event_msg", "compacted":
+		return true
+	}
+	return false
+}
+
+func (wrappedFormat) Parse(r io.Reader, s *SessionState) error {
+	return jsonl.Scan(r, func(lineNum int, line string) {
+		var record struct {
+			Timestamp string          `json:"timestamp"`
+			Type      string          `json:"type"`
+			Payload   json.RawMessage `json:"payload"`
+		}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			return
+		}
+		timestamp := recordTimestamp(record.Timestamp)
+
+		switch record.Type {
+		case "session_meta":
+			if metadata, err := parseSessionMetadata(line); err == nil {
+				s.SetSession(metadata)
+			}
+		case "turn_context":
+			if turn, ok := parseTurnContext(line); ok {
+				s.SetTurn(turn.Model, turn.Effort)
+			}
+		case "response_item":
+			// Developer and system messages are injected context, not turns
+			if role := messageRole(string(record.Payload)); role == "user" || role == "assistant" {
+				s.AddMessage(lineNum, string(record.Payload), timestamp)
+			}
+		case "event_msg":
+			if last, total, ok := parseTokenCount(record.Payload); ok {
+				s.AddTokenCount(lineNum, timestamp, last, total)
+			}
+		case "message":
+			s.AddMessage(lineNum, line, timestamp)
+		}
+	})
+}
+
+// flatFormat reads rollout-v2 files of earlier Codex CLI releases: the first line holds
+// bare session metadata (id, timestamp, instructions, git) and the response items follow
+// unwrapped, interleaved with {"record_type":"state"} lines. Usage is only logged when a
+// message carries it.
+type flatFormat struct{}
+
+func (flatFormat) Name() string { return "rollout-v2" }
+
+func (flatFormat) Detect(path string, first []byte) bool {
+	var record map[string]interface{}
+	if err := json.Unmarshal(first, &record); err != nil {
+		return false
+	}
+	recordType, _ := record["type"].(string)
+	if recordType == "" {
+		_, hasID := record["id"]
+		_, isState := record["record_type"]
+		return hasID || isState
+	}
+	return responseItemTypes[recordType]
+}
+
+func (flatFormat) Parse(r io.Reader, s *SessionState) error {
+	first := true
+	sessionID := ""
+	return jsonl.Scan(r, func(lineNum int, line string) {
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			return
+		}
+		recordType, _ := record["type"].(string)
+
+		// Only the first record can be session metadata
+		isFirst := first
+		first = false
+		if isFirst && recordType == "" {
+			if metadata, err := parseSessionMetadata(line); err == nil && metadata.ID != "" {
+				s.SetSession(metadata)
+				sessionID = metadata.ID
+			}
+			return
+		}
+		if recordType == "message" {
+			// Messages without ids or timestamps would share one de-duplication key
+			if _, ok := record["id"].(string); !ok {
+				record["id"] = fmt.Sprintf("%s-line-%d", sessionID, lineNum)
+				data, err := json.Marshal(record)
+				if err != nil {
+					return
+				}
+				line = string(data)
+			}
+			timeStr, _ := record["timestamp"].(string)
+			s.AddMessage(lineNum, line, recordTimestamp(timeStr))
+		}
+	})
+}
+
+// sessionJSONFormat reads rollout-v1 files of the original TypeScript Codex CLI: one JSON
+// document per session ({"session": {...}, "items": [...]}) saved as rollout-*.json. Items
+// carry no timestamps, so entries are dated at the session start and their line is the
+// item's position.
+type sessionJSONFormat struct{}
+
+func (sessionJSONFormat) Name() string { return "rollout-v1" }
+
+func (sessionJSONFormat) Detect(path string, first []byte) bool {
+	return strings.HasSuffix(path, ".json")
+}
+
+func (sessionJSONFormat) Parse(r io.Reader, s *SessionState) error {
+	var document struct {
+		Session struct {
+			ID        string `json:"id"`
+			Timestamp string `json:"timestamp"`
+		} `json:"session"`
+		Items []map[string]interface{} `json:"items"`
+	}
+	if err := json.NewDecoder(r).Decode(&document); err != nil {
+		return fmt.Errorf("invalid session file: %w", err)
+	}
+
+	s.SetSession(&SessionMetadata{
+		ID:        document.Session.ID,
+		Timestamp: recordTimestamp(document.Session.Timestamp),
+		RawTime:   document.Session.Timestamp,
+	})
+	for i, item := range document.Items {
+		if item["type"] != "message" {
+			continue
+		}
+		// Items without ids would share one de-duplication key
+		if _, ok := item["id"].(string); !ok {
+			item["id"] = fmt.Sprintf("%s-item-%d", document.Session.ID, i+1)
+		}
+		data, err := json.Marshal(item)
+		if err != nil {
+			continue
+		}
+		s.AddMessage(i+1, string(data), time.Time{})
+	}
+	return nil
+}
+
+func init() {
+	RegisterLogFormat(wrappedFormat{})
+	RegisterLogFormat(flatFormat{})
+	RegisterLogFormat(sessionJSONFormat{})
+}
@@ -0,0 +1,230 @@
+package codex
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/johanneserhardt/cxusage/internal/jsonl"
+	"github.com/johanneserhardt/cxusage/internal/pricing"
+	"github.com/johanneserhardt/cxusage/internal/types"
+	"github.com/sirupsen/logrus"
+)
+
+// rollout-v3: wrapped records with token counts
+const formatsTestWrapped = `{"timestamp":"2026-10-18T09:00:00Z","type":"session_meta","payload":{"id":"sess-v3","timestamp":"2026-10-18T09:00:00Z","cwd":"/home/u/api","model_provider":"openai"}}
+{"timestamp":"2026-10-18T09:00:01Z","type":"turn_context","payload":{"model":"gpt-5","effort":"medium"}}
+{"timestamp":"2026-10-18T09:00:01Z","type":"response_item","payload":{"type":"message","role":"developer","content":[{"type":"input_text","text":"injected context"}]}}
+{"timestamp":"2026-10-18T09:00:02Z","type":"response_item","payload":{"type":"message","role":"user","content":[{"type":"input_text","text":"fix the bug"}]}}
+{"timestamp":"2026-10-18T09:00:05Z","type":"response_item","payload":{"type":"function_call","name":"shell","arguments":"{\"command\":[\"rg\",\"bug\"]}","call_id":"c1"}}
+{"timestamp":"2026-10-18T09:00:06Z","type":"event_msg","payload":{"type":"token_count","info":{"last_token_usage":{"input_tokens":5000,"output_tokens":100},"total_token_usage":{"input_tokens":5000,"output_tokens":100}}}}
+{"timestamp":"2026-10-18T09:00:20Z","type":"response_item","payload":{"type":"message","role":"assistant","content":[{"type":"output_text","text":"fixed it"}]}}
+{"timestamp":"2026-10-18T09:00:21Z","type":"event_msg","payload":{"type":"token_count","info":{"last_token_usage":{"input_tokens":6000,"output_tokens":300},"total_token_usage":{"input_tokens":11000,"output_tokens":400}}}}
+{"timestamp":"2026-10-18T09:00:22Z","type":"event_msg","payload":{"type":"token_count","info":{"last_token_usage":{"input_tokens":6000,"output_tokens":300},"total_token_usage":{"input_tokens":11000,"output_tokens":400}},"rate_limits":{"primary":{"used_percent":5}}}}
+`
+
+// rollout-v2: bare metadata and flat items
+const formatsTestFlat = `{"id":"sess-v2","timestamp":"2026-10-18T10:00:00Z","instructions":"be brief","git":{"repository_url":"git@example.com:u/app.git"}}
+{"record_type":"state"}
+{"type":"message","role":"user","content":[{"type":"input_text","text":"hello"}]}
+{"type":"message","role":"assistant","content":[{"type":"output_text","text":"hi there"}],"usage":{"input_tokens":40,"output_tokens":8}}
+`
+
+// rollout-v1: one JSON document per session
+const formatsTestSessionJSON = `{
+  "session": {"id": "sess-v1", "timestamp": "2026-10-18T11:00:00Z", "instructions": ""},
+  "items": [
+    {"type": "message", "role": "user", "content": [{"type": "input_text", "text": "explain this"}]},
+    {"type": "function_call", "name": "shell", "arguments": "{}"},
+    {"id": "msg_1", "type": "message", "role": "assistant", "content": [{"type": "output_text", "text": "it parses logs"}]}
+  ]
+}
+`
+
+func parseFormatsTestFile(t *testing.T, name, content string) []types.CodexUsageEntry {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	entries, err := parseCodexSessionFile(path, ResolveDefaults(nil), pricing.Default(), NewTokenEstimator(), start, start.AddDate(0, 0, 1), logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return entries
+}
+
+func TestDetectLogFormat(t *testing.T) {
+	tests := []struct {
+		path, content, want string
+	}{
+		{"rollout.jsonl", formatsTestWrapped, "rollout-v3"},
+		{"rollout.jsonl", formatsTestFlat, "rollout-v2"},
+		{"rollout.jsonl", `{"type":"message","role":"user","content":[]}`, "rollout-v2"},
+		{"rollout-2025-04-16-abc.json", formatsTestSessionJSON, "rollout-v1"},
+		{"rollout.jsonl", `{"kind":"something new"}`, ""},
+	}
+	for _, tt := range tests {
+		first, _, err := readFirstLine(strings.NewReader("\n" + tt.content))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := ""
+		if format := DetectLogFormat(tt.path, first); format != nil {
+			got = format.Name()
+		}
+		if got != tt.want {
+			t.Errorf("DetectLogFormat(%s, %.30q) = %q, want %q", tt.path, tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestParseWrappedFormat(t *testing.T) {
+	entries := parseFormatsTestFile(t, "rollout.jsonl", formatsTestWrapped)
+
+	// The tool-only request becomes its own entry and the final response carries the
+	// logged usage; the user message's estimate is dropped, the repeated count ignored
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d: %+v", len(entries), entries)
+	}
+	want := []types.Usage{
+		{PromptTokens: 5000, CompletionTokens: 100, TotalTokens: 5100},
+		{PromptTokens: 6000, CompletionTokens: 300, TotalTokens: 6300},
+	}
+	for i, entry := range entries {
+		if entry.Usage != want[i] || entry.Estimated {
+			t.Errorf("entry %d: usage %+v (estimated %v), want %+v", i, entry.Usage, entry.Estimated, want[i])
+		}
+		if entry.Model != "gpt-5" || entry.ReasoningEffort != "medium" || entry.SessionID != "sess-v3" || entry.ProjectPath != "/home/u/api" {
+			t.Errorf("entry %d: missing session context: %+v", i, entry)
+		}
+	}
+	if entries[0].Line != 6 || entries[1].Line != 7 {
+		t.Errorf("lines %d, %d; want 6, 7", entries[0].Line, entries[1].Line)
+	}
+	if entries[1].Duration != 18000 {
+		t.Errorf("turn duration %d, want 18000", entries[1].Duration)
+	}
+}
+
+func TestParseSkipsOverlongLine(t *testing.T) {
+	lines := strings.SplitAfter(formatsTestWrapped, "\n")
+	huge := `{"timestamp":"2026-10-18T09:00:05Z","type":"response_item","payload":{"type":"function_call_output","call_id":"c1","output":"` +
+		strings.Repeat("x", jsonl.MaxLineSize) + `"}}` + "\n"
+	content := strings.Join(lines[:5], "") + huge + strings.Join(lines[5:], "")
+
+	// The rest of the file is still read, with line numbers counting the skipped line
+	entries := parseFormatsTestFile(t, "rollout.jsonl", content)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].Usage.TotalTokens != 5100 || entries[1].Usage.TotalTokens != 6300 {
+		t.Errorf("unexpected usage: %+v, %+v", entries[0].Usage, entries[1].Usage)
+	}
+	if entries[0].Line != 7 || entries[1].Line != 8 {
+		t.Errorf("lines %d, %d; want 7, 8", entries[0].Line, entries[1].Line)
+	}
+}
+
+func TestParseFlatFormat(t *testing.T) {
+	entries := parseFormatsTestFile(t, "rollout.jsonl", formatsTestFlat)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	for _, entry := range entries {
+		if entry.SessionID != "sess-v2" || entry.RepoURL != "git@example.com:u/app.git" || !entry.Timestamp.Equal(time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)) {
+			t.Errorf("missing session metadata: %+v", entry)
+		}
+	}
+	if !entries[0].Estimated || entries[1].Estimated || entries[1].Usage.PromptTokens != 40 {
+		t.Errorf("unexpected usage: %+v", entries)
+	}
+	if entries[0].Provenance != types.ProvenanceEstimatedTokens || entries[1].Provenance != types.ProvenanceExact {
+		t.Errorf("provenance %s, %s; want estimated_tokens, exact", entries[0].Provenance, entries[1].Provenance)
+	}
+}
+
+func TestParseSessionJSONFormat(t *testing.T) {
+	entries := parseFormatsTestFile(t, "rollout-2025-04-16-abc.json", formatsTestSessionJSON)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].RequestID != "sess-v1-item-1" || entries[1].RequestID != "msg_1" || entries[1].Line != 3 {
+		t.Errorf("unexpected ids: %s, %s (line %d)", entries[0].RequestID, entries[1].RequestID, entries[1].Line)
+	}
+	if EntryKey(entries[0]) == EntryKey(entries[1]) {
+		t.Error("items share a de-duplication key")
+	}
+}
+
+func TestParseUnknownFormatWarns(t *testing.T) {
+	content := `{"kind":"session","name":"x"}
+{"type":"message","role":"assistant","content":[{"type":"output_text","text":"still counted"}],"timestamp":"2026-10-18T12:00:00Z"}
+`
+	path := filepath.Join(t.TempDir(), "rollout.jsonl")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var logs bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&logs)
+	start := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	entries, err := parseCodexSessionFile(path, ResolveDefaults(nil), pricing.Default(), NewTokenEstimator(), start, start.AddDate(0, 0, 1), logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected the message to be read, got %d entries", len(entries))
+	}
+	if !strings.Contains(logs.String(), "Unrecognized log format") {
+		t.Errorf("expected a warning, got %q", logs.String())
+	}
+}
+
+func TestParseUnpricedModel(t *testing.T) {
+	session := strings.Replace(formatsTestWrapped, `"model":"gpt-5"`, `"model":"in-house-7b"`, 1)
+	for _, entry := range parseFormatsTestFile(t, "rollout.jsonl", session) {
+		if entry.Provenance != types.ProvenanceEstimatedCost || entry.Cost == 0 {
+			t.Errorf("unpriced model: provenance %s, cost %v; want a baseline estimate", entry.Provenance, entry.Cost)
+		}
+	}
+}
+
+func TestParseUsageFilesKeepsFlatFormatMessages(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(CodexHomeEnv, "")
+
+	// Messages of rollout-v2 sessions have neither ids nor timestamps of their own
+	content := formatsTestFlat + `{"type":"message","role":"user","content":[{"type":"input_text","text":"and now?"}],"usage":{"input_tokens":60,"output_tokens":0}}
+{"type":"message","role":"assistant","content":[{"type":"output_text","text":"done"}],"usage":{"input_tokens":70,"output_tokens":5}}
+`
+	dir := filepath.Join(t.TempDir(), "sessions")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "rollout.jsonl"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &types.Config{CodexDirs: []types.CodexSource{{Label: "old", Path: filepath.Dir(dir)}}}
+	start := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	entries, err := ParseUsageFiles(cfg, start, start.AddDate(0, 0, 1), logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("expected 4 entries, got %d: %+v", len(entries), entries)
+	}
+	keys := make(map[string]bool)
+	for _, e := range entries {
+		keys[EntryKey(e)] = true
+	}
+	if len(keys) != 4 {
+		t.Errorf("entries share de-duplication keys: %v", keys)
+	}
+}
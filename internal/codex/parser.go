@@ -1,121 +1,318 @@
 package codex
 
 import (
-    "bufio"
     "encoding/json"
     "errors"
+    "fmt"
     "os"
     "path/filepath"
     "strings"
     "time"
 
+    "github.com/johanneserhardt/cxusage/internal/bundle"
+    "github.com/johanneserhardt/cxusage/internal/claude"
+    "github.com/johanneserhardt/cxusage/internal/filter"
+    "github.com/johanneserhardt/cxusage/internal/mapping"
+    "github.com/johanneserhardt/cxusage/internal/pricing"
+    "github.com/johanneserhardt/cxusage/internal/store"
     "github.com/johanneserhardt/cxusage/internal/types"
     "github.com/sirupsen/logrus"
 )
 
 // ParseUsageFiles parses all Codex usage log files and returns usage entries with token estimation
 func ParseUsageFiles(cfg *types.Config, startDate, endDate time.Time, logger *logrus.Logger) ([]types.CodexUsageEntry, error) {
-    files, err := GetUsageLogFiles(cfg)
+    // Reports can read a previously exported database instead of rescanning logs
+    if cfg.Database != "" {
+        return parseDatabase(cfg, startDate, endDate, logger)
+    }
+
+    var allEntries []types.CodexUsageEntry
+    // De-dup across files using a composite key (this also drops copies of the same
+    // session found in several Codex directories; the first source wins)
+    seen := make(map[string]struct{})
+    prices := LoadPriceTable(cfg)
+    estimator := LoadTokenEstimator(logger)
+
+    if AgentEnabled(cfg, types.AgentCodex) {
+        files, err := GetSourceLogFiles(cfg)
+        if err != nil {
+            return nil, err
+        }
+
+        // Model and provider configured in each directory's config.toml, for entries
+        // whose logs do not name them
+        sourceDefaults, err := LoadSourceDefaults(cfg, logger)
+        if err != nil {
+            return nil, err
+        }
+
+        logger.WithField("files_count", len(files)).Info("Found Codex usage log files")
+
+        for _, file := range files {
+            // Files untouched since the start date cannot contain entries in range
+            if info, err := os.Stat(file.Path); err == nil && info.ModTime().Before(startDate) {
+                continue
+            }
+
+            entries, err := parseCodexSessionFile(file.Path, sourceDefaults[file.Source], prices, estimator, startDate, endDate, logger)
+            if err != nil {
+                logger.WithError(err).WithField("file", filepath.Base(file.Path)).Warn("Failed to parse log file")
+                continue
+            }
+            for _, e := range entries {
+                e.Source = file.Source
+                e.User = cfg.User
+                e.Agent = types.AgentCodex
+                key := EntryKey(e)
+                if _, ok := seen[key]; ok {
+                    continue
+                }
+                seen[key] = struct{}{}
+                allEntries = append(allEntries, e)
+            }
+        }
+    }
+
+    var other []types.CodexUsageEntry
+    if AgentEnabled(cfg, types.AgentClaude) {
+        entries, err := claude.ParseUsageFiles(cfg, prices, startDate, endDate, logger)
+        if err != nil {
+            return nil, err
+        }
+        other = append(other, entries...)
+    }
+    // Logs of other tools read through user-defined mappings
+    for _, m := range cfg.Mappings {
+        if !AgentEnabled(cfg, m.Name) {
+            continue
+        }
+        entries, err := mapping.ParseUsageFiles(cfg, m, prices, startDate, endDate, logger)
+        if err != nil {
+            return nil, err
+        }
+        other = append(other, entries...)
+    }
+    for _, e := range other {
+        // Other agents' entries have their agent or mapping name as source
+        if len(cfg.Sources) > 0 && !containsString(cfg.Sources, e.Source) {
+            continue
+        }
+        key := EntryKey(e)
+        if _, ok := seen[key]; ok {
+            continue
+        }
+        seen[key] = struct{}{}
+        allEntries = append(allEntries, e)
+    }
+
+    allEntries, err := finishEntries(cfg, allEntries, seen, startDate, endDate, logger)
     if err != nil {
         return nil, err
     }
 
-	logger.WithField("files_count", len(files)).Info("Found Codex usage log files")
+	logger.WithField("total_entries", len(allEntries)).Info("Parsed Codex usage entries with token estimation")
+	return allEntries, nil
+}
+
+// parseDatabase reads usage entries from an exported SQLite database
+func parseDatabase(cfg *types.Config, startDate, endDate time.Time, logger *logrus.Logger) ([]types.CodexUsageEntry, error) {
+    entries, err := store.LoadEntries(cfg.Database, startDate, endDate)
+    if err != nil {
+        return nil, err
+    }
 
     var allEntries []types.CodexUsageEntry
-    // De-dup across files using a composite key
     seen := make(map[string]struct{})
+    for _, e := range entries {
+        if len(cfg.Sources) > 0 && !containsString(cfg.Sources, e.Source) {
+            continue
+        }
+        if len(cfg.Agents) > 0 && !containsString(cfg.Agents, EntryAgent(e)) {
+            continue
+        }
+        seen[EntryKey(e)] = struct{}{}
+        allEntries = append(allEntries, e)
+    }
+
+    allEntries, err = finishEntries(cfg, allEntries, seen, startDate, endDate, logger)
+    if err != nil {
+        return nil, err
+    }
+
+    logger.WithField("total_entries", len(allEntries)).Info("Read usage entries from database")
+    return allEntries, nil
+}
 
-    for _, file := range files {
-        entries, err := parseCodexSessionFile(file, startDate, endDate, logger)
+// finishEntries merges usage bundles, applies the --where filter and redacts parsed entries
+func finishEntries(cfg *types.Config, allEntries []types.CodexUsageEntry, seen map[string]struct{}, startDate, endDate time.Time, logger *logrus.Logger) ([]types.CodexUsageEntry, error) {
+    // Merge teammates' usage bundles with the same de-dup identity, so a bundle that
+    // overlaps local logs or another bundle is only counted once
+    if len(cfg.Bundles) > 0 {
+        bundleEntries, err := bundle.LoadEntries(cfg.Bundles, startDate, endDate)
         if err != nil {
-            logger.WithError(err).WithField("file", filepath.Base(file)).Warn("Failed to parse log file")
-            continue
+            return nil, fmt.Errorf("failed to load usage bundles: %w", err)
         }
-        for _, e := range entries {
-            key := e.SessionID + "|" + e.RequestID + "|" + e.Timestamp.Format(time.RFC3339Nano)
+        for _, e := range bundleEntries {
+            if len(cfg.Agents) > 0 && !containsString(cfg.Agents, EntryAgent(e)) {
+                continue
+            }
+            key := EntryKey(e)
             if _, ok := seen[key]; ok {
                 continue
             }
             seen[key] = struct{}{}
             allEntries = append(allEntries, e)
         }
+        logger.WithField("bundle_entries", len(bundleEntries)).Info("Merged usage bundles")
     }
 
-	logger.WithField("total_entries", len(allEntries)).Info("Parsed Codex usage entries with token estimation")
-	return allEntries, nil
+    // Entries of bundles and databases written before provenance was recorded
+    for i := range allEntries {
+        if allEntries[i].Provenance == "" {
+            allEntries[i].Provenance = EntryProvenance(allEntries[i])
+        }
+    }
+
+    // Filter before any aggregation, and before redaction so expressions see real names
+    if cfg.Where != "" {
+        pred, err := filter.Parse(cfg.Where)
+        if err != nil {
+            return nil, fmt.Errorf("invalid --where expression: %w", err)
+        }
+        before := len(allEntries)
+        allEntries = filter.Apply(allEntries, pred)
+        logger.WithFields(logrus.Fields{"before": before, "after": len(allEntries)}).Info("Applied --where filter")
+    }
+
+    // Redact identifying fields after de-duplication, which needs the original ids
+    if RedactionEnabled(cfg) {
+        redactor, err := NewRedactor(cfg)
+        if err != nil {
+            return nil, err
+        }
+        allEntries = redactor.Entries(allEntries)
+        if err := redactor.Save(); err != nil {
+            logger.WithError(err).Warn("Failed to save redaction state")
+        }
+    }
+
+    return allEntries, nil
 }
 
-// parseCodexSessionFile parses a complete Codex session file
-func parseCodexSessionFile(filename string, startDate, endDate time.Time, logger *logrus.Logger) ([]types.CodexUsageEntry, error) {
+func containsString(values []string, value string) bool {
+    for _, v := range values {
+        if v == value {
+            return true
+        }
+    }
+    return false
+}
+
+// EntryKey returns the identity of a usage entry used for de-duplication
+func EntryKey(e types.CodexUsageEntry) string {
+    if e.EntryID != "" {
+        return e.EntryID
+    }
+    return e.SessionID + "|" + e.RequestID + "|" + e.Timestamp.Format(time.RFC3339Nano)
+}
+
+// EntryProvenance returns the provenance of an entry recorded without one: entries with
+// estimated tokens are estimated, all others were exact
+func EntryProvenance(e types.CodexUsageEntry) types.Provenance {
+	if e.Estimated {
+		return types.ProvenanceEstimatedTokens
+	}
+	return types.ProvenanceExact
+}
+
+// parseCodexSessionFile parses a complete Codex session file in whichever log format it uses
+func parseCodexSessionFile(filename string, defaults SessionDefaults, prices *pricing.Table, estimator *TokenEstimator, startDate, endDate time.Time, logger *logrus.Logger) ([]types.CodexUsageEntry, error) {
 	file, err := os.Open(filename)
 	if err != nil {
 		return nil, err
 	}
 	defer file.Close()
 
-	var entries []types.CodexUsageEntry
-	scanner := bufio.NewScanner(file)
-	
-	// Increase buffer size for very large Codex CLI messages
-	const maxCapacity = 10 * 1024 * 1024 // 10MB buffer for huge messages
-	buf := make([]byte, maxCapacity)
-	scanner.Buffer(buf, maxCapacity)
-	
-	estimator := NewTokenEstimator()
-	var sessionTimestamp time.Time
-	var sessionID string
-	lineNum := 0
-
-	for scanner.Scan() {
-		lineNum++
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
-			continue
-		}
+	first, reader, err := readFirstLine(file)
+	if err != nil {
+		return nil, err
+	}
+	if first == nil {
+		return nil, nil
+	}
+	format := DetectLogFormat(filename, first)
+	if format == nil {
+		format = fallbackFormat
+		logger.WithFields(logrus.Fields{
+			"file":   filepath.Base(filename),
+			"format": format.Name(),
+		}).Warn("Unrecognized log format, reading it with the closest known format")
+	}
 
-		// Parse each JSONL line
-		if lineNum == 1 {
-			// First line contains session metadata with timestamp
-			sessionData, err := parseSessionMetadata(line)
-			if err == nil {
-				sessionTimestamp = sessionData.Timestamp
-				sessionID = sessionData.ID
+	state := newSessionState(filename, defaults, estimator, startDate, endDate)
+	if err := format.Parse(reader, state); err != nil {
+		return nil, err
+	}
+	entries := state.finish()
+
+	// Price the entries without a logged cost now that the provider is known
+	for i := range entries {
+		provenance := types.ProvenanceExact
+		if entries[i].Cost == 0 {
+			var priced bool
+			if entries[i].Cost, priced = PriceUsage(prices, entries[i].Provider, entries[i].Model, entries[i].Usage); !priced {
+				provenance = types.ProvenanceEstimatedCost
 			}
 		}
-
-		// Try to parse as a message
-		entry, err := parseMessageEntry(line, sessionTimestamp, sessionID, estimator)
-		if err != nil {
-			// Skip non-message entries (metadata, state, etc.)
-			continue
+		if entries[i].Estimated {
+			provenance = types.ProvenanceEstimatedTokens
 		}
-
-        // Filter by date range (inclusive) so boundary events are not dropped
-        if (entry.Timestamp.Equal(startDate) || entry.Timestamp.After(startDate)) &&
-            (entry.Timestamp.Equal(endDate) || entry.Timestamp.Before(endDate)) {
-            entries = append(entries, entry)
-        }
-	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, err
+		entries[i].Provenance = provenance
 	}
 
 	logger.WithFields(logrus.Fields{
 		"file":    filepath.Base(filename),
+		"format":  format.Name(),
 		"entries": len(entries),
-		"lines":   lineNum,
 	}).Debug("Parsed Codex session file")
 
 	return entries, nil
 }
 
+// turnContext holds the settings a turn_context line applies to the turns that follow
+type turnContext struct {
+	Model  string
+	Effort string
+}
+
+// parseTurnContext returns the model and reasoning effort of a turn_context line
+func parseTurnContext(line string) (turnContext, bool) {
+	var turn turnContext
+	if !strings.Contains(line, `"turn_context"`) {
+		return turn, false
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &raw); err != nil || raw["type"] != "turn_context" {
+		return turn, false
+	}
+	turn.Model, _ = getNestedString(raw, "payload", "model")
+	if effort, ok := getNestedString(raw, "payload", "effort"); ok {
+		turn.Effort = effort
+	} else {
+		turn.Effort, _ = getNestedString(raw, "payload", "reasoning_effort")
+	}
+	return turn, true
+}
+
 // SessionMetadata represents the first line of a Codex session file
 type SessionMetadata struct {
 	ID        string    `json:"id"`
 	Timestamp time.Time `json:"-"`
 	RawTime   string    `json:"timestamp"`
+	Cwd       string    `json:"cwd"`
+	RepoURL   string    `json:"-"`
+	ModelProvider string `json:"-"` // Provider recorded by newer Codex versions
 }
 
 // parseSessionMetadata parses session metadata from first line
@@ -128,9 +325,29 @@ func parseSessionMetadata(line string) (*SessionMetadata, error) {
 		return nil, err
 	}
 	
-	// Extract ID
+	// Extract ID (newer rollouts wrap metadata in a session_meta payload)
 	if id, ok := rawData["id"].(string); ok {
 		metadata.ID = id
+	} else if id, ok := getNestedString(rawData, "payload", "id"); ok {
+		metadata.ID = id
+	}
+	
+	// Extract working directory and git remote
+	if cwd, ok := rawData["cwd"].(string); ok {
+		metadata.Cwd = cwd
+	} else if cwd, ok := getNestedString(rawData, "payload", "cwd"); ok {
+		metadata.Cwd = cwd
+	}
+	if url, ok := getNestedString(rawData, "git", "repository_url"); ok {
+		metadata.RepoURL = url
+	} else if url, ok := getNestedString(rawData, "payload", "git", "repository_url"); ok {
+		metadata.RepoURL = url
+	}
+	
+	if provider, ok := rawData["model_provider"].(string); ok {
+		metadata.ModelProvider = provider
+	} else if provider, ok := getNestedString(rawData, "payload", "model_provider"); ok {
+		metadata.ModelProvider = provider
 	}
 	
 	// Extract and parse timestamp
@@ -143,8 +360,10 @@ func parseSessionMetadata(line string) (*SessionMetadata, error) {
     return &metadata, nil
 }
 
-// parseMessageEntry parses a message entry and prefers logged usage/cost, with estimation as fallback
-func parseMessageEntry(line string, sessionTimestamp time.Time, sessionID string, estimator *TokenEstimator) (types.CodexUsageEntry, error) {
+// parseMessageEntry parses a message entry and prefers logged usage, with estimation as fallback.
+// defaultModel is used when the line does not name a model. Cost is only set when logged;
+// callers price the remaining entries once the provider is known.
+func parseMessageEntry(line string, sessionTimestamp time.Time, sessionID, defaultModel string, estimator *TokenEstimator) (types.CodexUsageEntry, error) {
     var entry types.CodexUsageEntry
 
     // Parse the message
@@ -213,14 +432,15 @@ func parseMessageEntry(line string, sessionTimestamp time.Time, sessionID string
         }
     }
 
-    // If usage not present, estimate from content
-    if inputTokens == 0 && outputTokens == 0 {
-        inputTokens, outputTokens = estimator.EstimateTokensFromMessage(*msg)
+    // Fall back to the model of the turn or the configured default
+    if model == "" {
+        model = defaultModel
     }
 
-    // If cost not present, estimate from tokens and model
-    if cost == 0 {
-        cost = estimator.EstimateCostFromTokens(model, inputTokens, outputTokens)
+    // If usage not present, estimate from content
+    if inputTokens == 0 && outputTokens == 0 {
+        inputTokens, outputTokens = estimator.EstimateTokensFromMessage(*msg, model)
+        entry.Estimated = true
     }
 
     // Populate the usage entry
@@ -1,6 +1,7 @@
 package codex
 
 import (
+    "fmt"
     "os"
     "path/filepath"
     "strings"
@@ -10,61 +11,206 @@ import (
 
 const (
 	DefaultCodexDir      = ".codex"
-	ConfigFileName       = "config.yaml"
+	ConfigFileName       = "config.toml"
 	InstructionsFileName = "instructions.md"
 	LogsDir              = "logs"
 	ProjectsDir          = "projects"
 )
 
-// GetCodexPaths returns the Codex CLI directory paths
-func GetCodexPaths(cfg *types.Config) (*types.CodexPaths, error) {
-	var codexDir string
-	
-	// Use custom codex path if provided, otherwise use default
+// CodexHomeEnv is the environment variable Codex CLI uses to relocate its home directory
+const CodexHomeEnv = "CODEX_HOME"
+
+// GetCodexSources returns all configured Codex directories with unique labels.
+// Directories come from codex_path, codex_dirs (including --codex-dir flags) and CODEX_HOME,
+// falling back to ~/.codex when none is configured.
+func GetCodexSources(cfg *types.Config) ([]types.CodexSource, error) {
+	var candidates []types.CodexSource
 	if cfg.CodexPath != "" {
-		codexDir = cfg.CodexPath
-	} else {
+		candidates = append(candidates, types.CodexSource{Path: cfg.CodexPath})
+	}
+	candidates = append(candidates, cfg.CodexDirs...)
+	if home := os.Getenv(CodexHomeEnv); home != "" {
+		candidates = append(candidates, types.CodexSource{Path: home})
+	}
+
+	if len(candidates) == 0 {
 		homeDir, err := os.UserHomeDir()
 		if err != nil {
 			return nil, err
 		}
-		codexDir = filepath.Join(homeDir, DefaultCodexDir)
+		candidates = append(candidates, types.CodexSource{Path: filepath.Join(homeDir, DefaultCodexDir)})
+	}
+
+	var sources []types.CodexSource
+	seenPaths := make(map[string]bool)
+	seenLabels := make(map[string]bool)
+	for _, candidate := range candidates {
+		path, err := expandHome(candidate.Path)
+		if err != nil {
+			return nil, err
+		}
+		if path == "" || seenPaths[path] {
+			continue
+		}
+		seenPaths[path] = true
+
+		label := candidate.Label
+		if label == "" {
+			label = strings.TrimPrefix(filepath.Base(path), ".")
+		}
+		unique := label
+		for i := 2; seenLabels[unique]; i++ {
+			unique = fmt.Sprintf("%s-%d", label, i)
+		}
+		seenLabels[unique] = true
+
+		sources = append(sources, types.CodexSource{Label: unique, Path: path})
+	}
+
+	return sources, nil
+}
+
+// ParseCodexDirFlag parses a --codex-dir value of the form "[label=]path"
+func ParseCodexDirFlag(value string) types.CodexSource {
+	if idx := strings.Index(value, "="); idx > 0 && !strings.ContainsAny(value[:idx], `/\`) {
+		return types.CodexSource{Label: value[:idx], Path: value[idx+1:]}
+	}
+	return types.CodexSource{Path: value}
+}
+
+// GetSelectedCodexSources returns the Codex directories matching the --source filter
+func GetSelectedCodexSources(cfg *types.Config) ([]types.CodexSource, error) {
+	sources, err := GetCodexSources(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.Sources) == 0 {
+		return sources, nil
+	}
+
+	var selected []types.CodexSource
+	var labels []string
+	for _, source := range sources {
+		labels = append(labels, source.Label)
+		for _, want := range cfg.Sources {
+			if source.Label == want {
+				selected = append(selected, source)
+				break
+			}
+		}
+	}
+	if len(selected) == 0 {
+		// Selecting only the logs of other agents, e.g. --source claude
+		agents := agentSources(cfg)
+		for _, want := range cfg.Sources {
+			if containsString(agents, want) {
+				return nil, nil
+			}
+		}
+		return nil, fmt.Errorf("no Codex directory matches source %s (available: %s)",
+			strings.Join(cfg.Sources, ", "), strings.Join(append(labels, agents...), ", "))
+	}
+
+	return selected, nil
+}
+
+// GetAllCodexPaths returns the Codex CLI directory paths of every selected source
+func GetAllCodexPaths(cfg *types.Config) ([]*types.CodexPaths, error) {
+	sources, err := GetSelectedCodexSources(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var all []*types.CodexPaths
+	for _, source := range sources {
+		all = append(all, codexPathsFor(source))
 	}
+	return all, nil
+}
 
+// codexPathsFor returns the directory layout of one Codex source
+func codexPathsFor(source types.CodexSource) *types.CodexPaths {
+	codexDir := source.Path
 	return &types.CodexPaths{
+		Label:            source.Label,
 		ConfigDir:        codexDir,
 		ConfigFile:       filepath.Join(codexDir, ConfigFileName),
 		InstructionsFile: filepath.Join(codexDir, InstructionsFileName),
 		LogsDir:          filepath.Join(codexDir, LogsDir),
 		ProjectsDir:      filepath.Join(codexDir, ProjectsDir),
-	}, nil
+	}
+}
+
+// expandHome expands a leading ~ and cleans the path
+func expandHome(path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(homeDir, path[1:])
+	}
+	return filepath.Clean(path), nil
 }
 
-// CodexDirExists checks if the Codex CLI directory exists
+// CodexDirExists checks if any selected Codex CLI directory exists
 func CodexDirExists(cfg *types.Config) (bool, error) {
-	paths, err := GetCodexPaths(cfg)
+	all, err := GetAllCodexPaths(cfg)
 	if err != nil {
 		return false, err
 	}
 
-	info, err := os.Stat(paths.ConfigDir)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return false, nil
+	for _, paths := range all {
+		info, err := os.Stat(paths.ConfigDir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return false, err
+		}
+		if info.IsDir() {
+			return true, nil
 		}
-		return false, err
 	}
 
-	return info.IsDir(), nil
+	return false, nil
 }
 
-// GetUsageLogFiles returns all usage log files from Codex CLI
+// GetUsageLogFiles returns all usage log files from the selected Codex CLI directories
 func GetUsageLogFiles(cfg *types.Config) ([]string, error) {
-    paths, err := GetCodexPaths(cfg)
-    if err != nil {
-        return nil, err
-    }
+	logFiles, err := GetSourceLogFiles(cfg)
+	if err != nil {
+		return nil, err
+	}
 
+	files := make([]string, 0, len(logFiles))
+	for _, logFile := range logFiles {
+		files = append(files, logFile.Path)
+	}
+	return files, nil
+}
+
+// GetSourceLogFiles returns all usage log files together with their source label
+func GetSourceLogFiles(cfg *types.Config) ([]types.CodexLogFile, error) {
+	all, err := GetAllCodexPaths(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []types.CodexLogFile
+	for _, paths := range all {
+		for _, file := range collectUsageLogFiles(paths) {
+			files = append(files, types.CodexLogFile{Path: file, Source: paths.Label})
+		}
+	}
+	return files, nil
+}
+
+// collectUsageLogFiles returns all usage log files of one Codex CLI directory
+func collectUsageLogFiles(paths *types.CodexPaths) []string {
     var files []string
 
     // Helper: recursively collect .jsonl files under a root directory
@@ -97,7 +243,11 @@ func GetUsageLogFiles(cfg *types.Config) ([]string, error) {
     sessionsDir := filepath.Join(paths.ConfigDir, "sessions")
     if stat, err := os.Stat(sessionsDir); err == nil && stat.IsDir() {
         collectJSONL(sessionsDir)
+
+        // The original TypeScript Codex CLI saved each session as one JSON document
+        legacy, _ := filepath.Glob(filepath.Join(sessionsDir, "rollout-*.json"))
+        files = append(files, legacy...)
     }
 
-    return files, nil
+    return files
 }
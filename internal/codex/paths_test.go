@@ -0,0 +1,149 @@
+package codex
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/johanneserhardt/cxusage/internal/types"
+	"github.com/sirupsen/logrus"
+)
+
+func TestGetCodexSourcesMergesAndLabels(t *testing.T) {
+	t.Setenv(CodexHomeEnv, "/tmp/devcontainer/.codex")
+
+	cfg := &types.Config{
+		CodexPath: "/home/me/.codex",
+		CodexDirs: []types.CodexSource{
+			{Label: "work", Path: "/home/me/work-codex"},
+			{Path: "/home/me/.codex/"}, // duplicate of codex_path
+			ParseCodexDirFlag("/other/.codex"),
+		},
+	}
+
+	sources, err := GetCodexSources(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []types.CodexSource{
+		{Label: "codex", Path: "/home/me/.codex"},
+		{Label: "work", Path: "/home/me/work-codex"},
+		{Label: "codex-2", Path: "/other/.codex"},
+		{Label: "codex-3", Path: "/tmp/devcontainer/.codex"},
+	}
+	if len(sources) != len(want) {
+		t.Fatalf("expected %d sources, got %d: %+v", len(want), len(sources), sources)
+	}
+	for i := range want {
+		if sources[i] != want[i] {
+			t.Errorf("source %d: expected %+v, got %+v", i, want[i], sources[i])
+		}
+	}
+}
+
+func TestGetCodexSourcesDefault(t *testing.T) {
+	t.Setenv(CodexHomeEnv, "")
+
+	sources, err := GetCodexSources(&types.Config{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	home, _ := os.UserHomeDir()
+	if len(sources) != 1 || sources[0].Path != filepath.Join(home, DefaultCodexDir) {
+		t.Errorf("expected default ~/.codex source, got %+v", sources)
+	}
+}
+
+func TestParseCodexDirFlag(t *testing.T) {
+	tests := []struct {
+		value string
+		want  types.CodexSource
+	}{
+		{"work=/home/me/work", types.CodexSource{Label: "work", Path: "/home/me/work"}},
+		{"/home/me/.codex", types.CodexSource{Path: "/home/me/.codex"}},
+		{"/data/a=b", types.CodexSource{Path: "/data/a=b"}},
+	}
+
+	for _, tt := range tests {
+		if got := ParseCodexDirFlag(tt.value); got != tt.want {
+			t.Errorf("ParseCodexDirFlag(%q) = %+v, want %+v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestGetSelectedCodexSourcesUnknown(t *testing.T) {
+	t.Setenv(CodexHomeEnv, "")
+
+	cfg := &types.Config{
+		CodexDirs: []types.CodexSource{{Label: "work", Path: "/w"}, {Label: "personal", Path: "/p"}},
+		Sources:   []string{"personal"},
+	}
+	selected, err := GetSelectedCodexSources(cfg)
+	if err != nil || len(selected) != 1 || selected[0].Label != "personal" {
+		t.Fatalf("expected only personal source, got %+v (err %v)", selected, err)
+	}
+
+	cfg.Sources = []string{"missing"}
+	if _, err := GetSelectedCodexSources(cfg); err == nil {
+		t.Error("expected an error for an unknown source label")
+	}
+
+	// Sources of other agents select no Codex directory
+	cfg.Sources = []string{"claude"}
+	if selected, err := GetSelectedCodexSources(cfg); err != nil || len(selected) != 0 {
+		t.Errorf("expected no Codex directory for the claude source, got %+v (err %v)", selected, err)
+	}
+}
+
+func TestSourceSelectsAgentEntries(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(CodexHomeEnv, "")
+
+	codexDir := filepath.Join(t.TempDir(), "sessions")
+	claudeDir := filepath.Join(t.TempDir(), "projects", "-home-u-app")
+	for dir, content := range map[string]string{codexDir: formatsTestWrapped, claudeDir: `{"type":"assistant","sessionId":"c1","requestId":"req_1","timestamp":"2026-10-18T09:00:05Z","message":{"id":"msg_1","model":"claude-sonnet-4-5","usage":{"input_tokens":10,"output_tokens":20}}}` + "\n"} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "rollout.jsonl"), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	start := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		sources []string
+		want    map[string]int
+	}{
+		{nil, map[string]int{"work": 2, "claude": 1}},
+		{[]string{"work"}, map[string]int{"work": 2}},
+		{[]string{"claude"}, map[string]int{"claude": 1}},
+	} {
+		cfg := &types.Config{
+			CodexDirs:  []types.CodexSource{{Label: "work", Path: filepath.Dir(codexDir)}},
+			ClaudePath: filepath.Dir(filepath.Dir(claudeDir)),
+			Agents:     []string{types.AgentCodex, types.AgentClaude},
+			Sources:    tt.sources,
+		}
+		entries, err := ParseUsageFiles(cfg, start, start.AddDate(0, 0, 1), logrus.New())
+		if err != nil {
+			t.Fatalf("sources %v: %v", tt.sources, err)
+		}
+		got := make(map[string]int)
+		for _, e := range entries {
+			got[e.Source]++
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("sources %v: got entries by source %v, want %v", tt.sources, got, tt.want)
+			continue
+		}
+		for source, n := range tt.want {
+			if got[source] != n {
+				t.Errorf("sources %v: got entries by source %v, want %v", tt.sources, got, tt.want)
+			}
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package codex
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/johanneserhardt/cxusage/internal/jsonl"
+	"github.com/johanneserhardt/cxusage/internal/types"
+	"github.com/sirupsen/logrus"
+)
+
+// ParseRateLimitFiles parses rate-limit snapshots from token_count events in all Codex log files
+func ParseRateLimitFiles(cfg *types.Config, startDate, endDate time.Time, logger *logrus.Logger) ([]types.RateLimitSnapshot, error) {
+	return parseRateLimitFiles(cfg, startDate, endDate, nil, logger)
+}
+
+// RateLimitCache keeps the snapshots of each log file between repeated loads, such as the
+// refreshes of the live monitor, re-reading only files whose modification time or size changed
+type RateLimitCache struct {
+	files map[string]cachedRateLimits
+}
+
+type cachedRateLimits struct {
+	modTime   time.Time
+	size      int64
+	snapshots []types.RateLimitSnapshot
+}
+
+// NewRateLimitCache creates an empty rate-limit cache
+func NewRateLimitCache() *RateLimitCache {
+	return &RateLimitCache{files: make(map[string]cachedRateLimits)}
+}
+
+// ParseRateLimitFiles parses rate-limit snapshots like ParseRateLimitFiles, reusing the
+// snapshots of unchanged files
+func (c *RateLimitCache) ParseRateLimitFiles(cfg *types.Config, startDate, endDate time.Time, logger *logrus.Logger) ([]types.RateLimitSnapshot, error) {
+	return parseRateLimitFiles(cfg, startDate, endDate, c, logger)
+}
+
+// file returns all snapshots of a log file, from the cache while it is unchanged
+func (c *RateLimitCache) file(path string, info os.FileInfo) ([]types.RateLimitSnapshot, error) {
+	if cached, ok := c.files[path]; ok && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		return cached.snapshots, nil
+	}
+	snapshots, err := parseRateLimitFile(path, time.Time{}, time.Time{})
+	if err != nil {
+		delete(c.files, path)
+		return nil, err
+	}
+	c.files[path] = cachedRateLimits{modTime: info.ModTime(), size: info.Size(), snapshots: snapshots}
+	return snapshots, nil
+}
+
+// parseRateLimitFiles parses the snapshots of all log files, through the cache if there is one
+func parseRateLimitFiles(cfg *types.Config, startDate, endDate time.Time, cache *RateLimitCache, logger *logrus.Logger) ([]types.RateLimitSnapshot, error) {
+	files, err := GetSourceLogFiles(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshots []types.RateLimitSnapshot
+	seen := make(map[string]bool, len(files))
+	for _, file := range files {
+		info, statErr := os.Stat(file.Path)
+		// Files untouched since the start date cannot contain newer snapshots
+		if statErr == nil && info.ModTime().Before(startDate) {
+			continue
+		}
+
+		var fileSnapshots []types.RateLimitSnapshot
+		if cache != nil && statErr == nil {
+			seen[file.Path] = true
+			var all []types.RateLimitSnapshot
+			all, err = cache.file(file.Path, info)
+			// Copy the ones in range, the cached snapshots stay untouched
+			for _, snapshot := range all {
+				if !snapshot.Timestamp.Before(startDate) && !snapshot.Timestamp.After(endDate) {
+					fileSnapshots = append(fileSnapshots, snapshot)
+				}
+			}
+		} else {
+			fileSnapshots, err = parseRateLimitFile(file.Path, startDate, endDate)
+		}
+		if err != nil {
+			logger.WithError(err).WithField("file", filepath.Base(file.Path)).Warn("Failed to parse rate limits from log file")
+			continue
+		}
+		for i := range fileSnapshots {
+			fileSnapshots[i].Source = file.Source
+		}
+		snapshots = append(snapshots, fileSnapshots...)
+	}
+
+	// Forget files that were removed or fell out of the range
+	if cache != nil {
+		for path := range cache.files {
+			if !seen[path] {
+				delete(cache.files, path)
+			}
+		}
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Timestamp.Before(snapshots[j].Timestamp)
+	})
+
+	if RedactionEnabled(cfg) {
+		redactor, err := NewRedactor(cfg)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = redactor.Snapshots(snapshots)
+	}
+
+	logger.WithField("snapshots", len(snapshots)).Debug("Parsed Codex rate-limit snapshots")
+	return snapshots, nil
+}
+
+// LatestRateLimitSnapshot returns the most recent snapshot, if any
+func LatestRateLimitSnapshot(snapshots []types.RateLimitSnapshot) *types.RateLimitSnapshot {
+	var latest *types.RateLimitSnapshot
+	for i := range snapshots {
+		if latest == nil || snapshots[i].Timestamp.After(latest.Timestamp) {
+			latest = &snapshots[i]
+		}
+	}
+	return latest
+}
+
+// LatestRateLimitSnapshotsBySource returns the most recent snapshot of each source
+func LatestRateLimitSnapshotsBySource(snapshots []types.RateLimitSnapshot) map[string]*types.RateLimitSnapshot {
+	latest := make(map[string]*types.RateLimitSnapshot)
+	for i := range snapshots {
+		current, ok := latest[snapshots[i].Source]
+		if !ok || snapshots[i].Timestamp.After(current.Timestamp) {
+			latest[snapshots[i].Source] = &snapshots[i]
+		}
+	}
+	return latest
+}
+
+// CurrentUsedPercent returns the used percentage of a window at the given time,
+// treating windows whose reset time has passed as empty
+func CurrentUsedPercent(window *types.RateLimitWindow, now time.Time) float64 {
+	if window == nil {
+		return 0
+	}
+	if window.ResetsAt != nil && !now.Before(*window.ResetsAt) {
+		return 0
+	}
+	return window.UsedPercent
+}
+
+// parseRateLimitFile extracts rate-limit snapshots between the dates from a single session file
+func parseRateLimitFile(filename string, startDate, endDate time.Time) ([]types.RateLimitSnapshot, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var snapshots []types.RateLimitSnapshot
+	var sessionTimestamp time.Time
+	var sessionID string
+
+	err = jsonl.Scan(file, func(lineNum int, line string) {
+		if lineNum == 1 {
+			if sessionData, err := parseSessionMetadata(line); err == nil {
+				sessionTimestamp = sessionData.Timestamp
+				sessionID = sessionData.ID
+			}
+		}
+
+		// Cheap pre-check before decoding the full line
+		if !strings.Contains(line, "rate_limits") {
+			return
+		}
+
+		snapshot, ok := parseRateLimitSnapshot(line, sessionTimestamp, sessionID)
+		if !ok {
+			return
+		}
+
+		// A zero end date keeps every snapshot
+		if !snapshot.Timestamp.Before(startDate) && (endDate.IsZero() || !snapshot.Timestamp.After(endDate)) {
+			snapshots = append(snapshots, snapshot)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return snapshots, nil
+}
+
+// parseRateLimitSnapshot parses the rate_limits object of a token_count event.
+// Supports the event_msg payload wrapper, the older msg wrapper and top-level objects.
+func parseRateLimitSnapshot(line string, sessionTimestamp time.Time, sessionID string) (types.RateLimitSnapshot, bool) {
+	var snapshot types.RateLimitSnapshot
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &raw); err != nil {
+		return snapshot, false
+	}
+
+	var limits map[string]interface{}
+	for _, container := range []string{"payload", "msg"} {
+		if inner, ok := raw[container].(map[string]interface{}); ok {
+			if l, ok := inner["rate_limits"].(map[string]interface{}); ok {
+				limits = l
+				break
+			}
+		}
+	}
+	if limits == nil {
+		if l, ok := raw["rate_limits"].(map[string]interface{}); ok {
+			limits = l
+		}
+	}
+	if limits == nil {
+		return snapshot, false
+	}
+
+	snapshot.Timestamp = sessionTimestamp
+	if timeStr, ok := raw["timestamp"].(string); ok {
+		if timestamp, err := time.Parse(time.RFC3339, timeStr); err == nil {
+			snapshot.Timestamp = timestamp
+		}
+	}
+	snapshot.SessionID = sessionID
+
+	snapshot.Primary = parseRateLimitWindow(limits, "primary", snapshot.Timestamp)
+	snapshot.Secondary = parseRateLimitWindow(limits, "secondary", snapshot.Timestamp)
+	if snapshot.Primary == nil && snapshot.Secondary == nil {
+		return snapshot, false
+	}
+
+	return snapshot, true
+}
+
+// parseRateLimitWindow reads a window either from a nested object ({"primary": {...}})
+// or from flat prefixed keys ({"primary_used_percent": ...})
+func parseRateLimitWindow(limits map[string]interface{}, name string, timestamp time.Time) *types.RateLimitWindow {
+	fields, ok := limits[name].(map[string]interface{})
+	if !ok {
+		fields = make(map[string]interface{})
+		prefix := name + "_"
+		for k, v := range limits {
+			if strings.HasPrefix(k, prefix) {
+				fields[strings.TrimPrefix(k, prefix)] = v
+			}
+		}
+	}
+
+	usedPercent, ok := getFloat(fields, "used_percent")
+	if !ok {
+		return nil
+	}
+
+	window := &types.RateLimitWindow{UsedPercent: usedPercent}
+	if minutes, ok := getNumber(fields, "window_minutes"); ok {
+		window.WindowMinutes = minutes
+	}
+
+	// Prefer absolute reset times, then relative ones
+	if resetsAt, ok := getFloat(fields, "resets_at"); ok {
+		t := time.Unix(int64(resetsAt), 0)
+		window.ResetsAt = &t
+	} else if resetsAtStr, ok := fields["resets_at"].(string); ok {
+		if t, err := time.Parse(time.RFC3339, resetsAtStr); err == nil {
+			window.ResetsAt = &t
+		}
+	} else if seconds, ok := getFloat(fields, "resets_in_seconds"); ok && !timestamp.IsZero() {
+		t := timestamp.Add(time.Duration(seconds) * time.Second)
+		window.ResetsAt = &t
+	}
+
+	return window
+}
+
+func getFloat(m map[string]interface{}, key string) (float64, bool) {
+	if v, ok := m[key].(float64); ok {
+		return v, true
+	}
+	return 0, false
+}
@@ -0,0 +1,122 @@
+package codex
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/johanneserhardt/cxusage/internal/types"
+	"github.com/sirupsen/logrus"
+)
+
+func TestParseRateLimitSnapshot_NestedWindows(t *testing.T) {
+	line := `{"timestamp":"2025-09-20T10:00:00Z","type":"event_msg","payload":{"type":"token_count","rate_limits":{"primary":{"used_percent":42.5,"window_minutes":300,"resets_in_seconds":3600},"secondary":{"used_percent":7,"window_minutes":10080,"resets_at":1758708000}}}}`
+
+	snapshot, ok := parseRateLimitSnapshot(line, time.Time{}, "sess-1")
+	if !ok {
+		t.Fatalf("expected snapshot to be parsed")
+	}
+	if snapshot.SessionID != "sess-1" {
+		t.Fatalf("expected session id sess-1, got %s", snapshot.SessionID)
+	}
+	if snapshot.Primary == nil || snapshot.Primary.UsedPercent != 42.5 || snapshot.Primary.WindowMinutes != 300 {
+		t.Fatalf("unexpected primary window: %+v", snapshot.Primary)
+	}
+	wantReset := time.Date(2025, 9, 20, 11, 0, 0, 0, time.UTC)
+	if snapshot.Primary.ResetsAt == nil || !snapshot.Primary.ResetsAt.Equal(wantReset) {
+		t.Fatalf("expected primary reset at %s, got %v", wantReset, snapshot.Primary.ResetsAt)
+	}
+	if snapshot.Secondary == nil || snapshot.Secondary.ResetsAt == nil || snapshot.Secondary.ResetsAt.Unix() != 1758708000 {
+		t.Fatalf("unexpected secondary window: %+v", snapshot.Secondary)
+	}
+}
+
+func TestParseRateLimitSnapshot_FlatWindows(t *testing.T) {
+	line := `{"timestamp":"2025-09-20T10:00:00Z","type":"event_msg","payload":{"type":"token_count","rate_limits":{"primary_used_percent":12,"primary_window_minutes":300,"secondary_used_percent":3}}}`
+
+	snapshot, ok := parseRateLimitSnapshot(line, time.Time{}, "")
+	if !ok {
+		t.Fatalf("expected snapshot to be parsed")
+	}
+	if snapshot.Primary == nil || snapshot.Primary.UsedPercent != 12 {
+		t.Fatalf("unexpected primary window: %+v", snapshot.Primary)
+	}
+	if snapshot.Secondary == nil || snapshot.Secondary.UsedPercent != 3 {
+		t.Fatalf("unexpected secondary window: %+v", snapshot.Secondary)
+	}
+}
+
+func TestCurrentUsedPercent_ResetWindowIsEmpty(t *testing.T) {
+	now := time.Date(2025, 9, 20, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Minute)
+
+	if got := CurrentUsedPercent(nil, now); got != 0 {
+		t.Fatalf("expected 0 for missing window, got %.1f", got)
+	}
+	window := snapshotWindow(80, &past)
+	if got := CurrentUsedPercent(window, now); got != 0 {
+		t.Fatalf("expected 0 after reset, got %.1f", got)
+	}
+	future := now.Add(time.Hour)
+	window = snapshotWindow(80, &future)
+	if got := CurrentUsedPercent(window, now); got != 80 {
+		t.Fatalf("expected 80 before reset, got %.1f", got)
+	}
+}
+
+func TestRateLimitCacheRereadsChangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	sessions := filepath.Join(dir, "sessions")
+	if err := os.MkdirAll(sessions, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(sessions, "rollout-a.jsonl")
+	line := func(used string) string {
+		return `{"timestamp":"2026-10-18T10:00:00Z","type":"event_msg","payload":{"type":"token_count","rate_limits":{"primary":{"used_percent":` + used + `}}}}` + "\n"
+	}
+	modTime := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	write := func(content string) {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := &types.Config{CodexPath: dir}
+	start, end := modTime.Add(-time.Hour), modTime.Add(time.Hour)
+	cache := NewRateLimitCache()
+	usedPercent := func() float64 {
+		snapshots, err := cache.ParseRateLimitFiles(cfg, start, end, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(snapshots) != 1 || snapshots[0].Primary == nil {
+			t.Fatalf("expected one snapshot, got %+v", snapshots)
+		}
+		return snapshots[0].Primary.UsedPercent
+	}
+
+	write(line("10"))
+	if got := usedPercent(); got != 10 {
+		t.Fatalf("expected 10%%, got %.0f%%", got)
+	}
+
+	// Same size and modification time: served from the cache
+	write(line("20"))
+	if got := usedPercent(); got != 10 {
+		t.Fatalf("expected the cached 10%%, got %.0f%%", got)
+	}
+
+	// A changed size is re-read
+	write(line("30.5"))
+	if got := usedPercent(); got != 30.5 {
+		t.Fatalf("expected 30.5%% after the change, got %.1f%%", got)
+	}
+}
+
+func snapshotWindow(used float64, resetsAt *time.Time) *types.RateLimitWindow {
+	return &types.RateLimitWindow{UsedPercent: used, ResetsAt: resetsAt}
+}
@@ -0,0 +1,232 @@
+package codex
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/johanneserhardt/cxusage/internal/config"
+	"github.com/johanneserhardt/cxusage/internal/types"
+)
+
+// redactionState is the persisted salt and alias mapping, which keeps hashes and aliases
+// stable across runs. Aliases are keyed by hash, so the file holds no original values.
+type redactionState struct {
+	Salt     string            `json:"salt"`
+	Projects map[string]string `json:"projects"`
+	Repos    map[string]string `json:"repos"`
+	Files    map[string]string `json:"files,omitempty"`
+}
+
+// Redactor replaces identifying fields (project paths, repository URLs, session and
+// request ids) with salted hashes or stable aliases
+type Redactor struct {
+	mode      types.RedactMode
+	salt      []byte
+	state     *redactionState
+	statePath string
+	dirty     bool
+}
+
+// ParseRedactMode validates a redaction mode from the command line or config
+func ParseRedactMode(value string) (types.RedactMode, error) {
+	switch types.RedactMode(strings.ToLower(strings.TrimSpace(value))) {
+	case "", types.RedactModeNone:
+		return types.RedactModeNone, nil
+	case types.RedactModeHash:
+		return types.RedactModeHash, nil
+	case types.RedactModeAlias:
+		return types.RedactModeAlias, nil
+	default:
+		return "", fmt.Errorf("unsupported redaction mode: %s (expected none, hash or alias)", value)
+	}
+}
+
+// RedactionEnabled reports whether outputs should be redacted
+func RedactionEnabled(cfg *types.Config) bool {
+	mode, err := ParseRedactMode(string(cfg.Redact))
+	return err == nil && mode != types.RedactModeNone
+}
+
+// NewRedactor creates a redactor for the configured mode, loading (or creating) the
+// persisted salt and alias mapping
+func NewRedactor(cfg *types.Config) (*Redactor, error) {
+	mode, err := ParseRedactMode(string(cfg.Redact))
+	if err != nil {
+		return nil, err
+	}
+
+	statePath, err := config.GetRedactionStatePath()
+	if err != nil {
+		return nil, err
+	}
+	return newRedactor(mode, cfg.RedactSalt, statePath)
+}
+
+func newRedactor(mode types.RedactMode, salt, statePath string) (*Redactor, error) {
+	r := &Redactor{
+		mode:      mode,
+		statePath: statePath,
+		state:     &redactionState{},
+	}
+
+	if raw, err := os.ReadFile(statePath); err == nil {
+		if err := json.Unmarshal(raw, r.state); err != nil {
+			return nil, fmt.Errorf("invalid redaction state %s: %w", statePath, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read redaction state: %w", err)
+	}
+	if r.state.Projects == nil {
+		r.state.Projects = make(map[string]string)
+	}
+	if r.state.Repos == nil {
+		r.state.Repos = make(map[string]string)
+	}
+	if r.state.Files == nil {
+		r.state.Files = make(map[string]string)
+	}
+
+	// A configured salt wins; otherwise a random salt is generated once and kept
+	if salt == "" {
+		if r.state.Salt == "" {
+			buf := make([]byte, 16)
+			if _, err := rand.Read(buf); err != nil {
+				return nil, fmt.Errorf("failed to generate redaction salt: %w", err)
+			}
+			r.state.Salt = hex.EncodeToString(buf)
+			r.dirty = true
+		}
+		salt = r.state.Salt
+	}
+	r.salt = []byte(salt)
+
+	return r, nil
+}
+
+// Entries redacts usage entries in place and returns them
+func (r *Redactor) Entries(entries []types.CodexUsageEntry) []types.CodexUsageEntry {
+	if r.mode == types.RedactModeNone {
+		return entries
+	}
+	for i := range entries {
+		e := &entries[i]
+		if e.EntryID != "" {
+			continue // already redacted, e.g. from a bundle
+		}
+		e.EntryID = r.hash(EntryKey(*e))[:24]
+		e.SessionID = ""
+		e.RequestID = ""
+		e.ProjectPath = r.Project(e.ProjectPath)
+		e.RepoURL = r.alias(e.RepoURL, "repo", r.state.Repos)
+		e.Command = ""
+		// Log file names contain the home directory and the session id
+		e.File = ""
+		e.Line = 0
+	}
+	return entries
+}
+
+// Snapshots drops session ids from rate-limit snapshots
+func (r *Redactor) Snapshots(snapshots []types.RateLimitSnapshot) []types.RateLimitSnapshot {
+	if r.mode == types.RedactModeNone {
+		return snapshots
+	}
+	for i := range snapshots {
+		snapshots[i].SessionID = ""
+	}
+	return snapshots
+}
+
+// ToolCalls redacts tool calls in place and returns them. Session ids are hashed rather
+// than dropped so calls can still be grouped by session.
+func (r *Redactor) ToolCalls(calls []types.ToolCall) []types.ToolCall {
+	if r.mode == types.RedactModeNone {
+		return calls
+	}
+	for i := range calls {
+		c := &calls[i]
+		if c.SessionID != "" {
+			c.SessionID = "session-" + r.hash(c.SessionID)[:12]
+		}
+		c.CallID = ""
+		c.ProjectPath = r.Project(c.ProjectPath)
+		c.File = ""
+		c.Line = 0
+	}
+	return calls
+}
+
+// Project redacts a project path
+func (r *Redactor) Project(path string) string {
+	if r.mode == types.RedactModeNone {
+		return path
+	}
+	return r.alias(path, "project", r.state.Projects)
+}
+
+// File redacts a log file path, which contains the home directory and the session id,
+// keeping its extension
+func (r *Redactor) File(path string) string {
+	if r.mode == types.RedactModeNone || path == "" {
+		return path
+	}
+	return r.alias(path, "file", r.state.Files) + filepath.Ext(path)
+}
+
+// EntryKey redacts a de-duplication key like the entry ids of redacted entries
+func (r *Redactor) EntryKey(key string) string {
+	if r.mode == types.RedactModeNone {
+		return key
+	}
+	return r.hash(key)[:24]
+}
+
+// Save persists newly generated salts and aliases
+func (r *Redactor) Save() error {
+	if !r.dirty {
+		return nil
+	}
+
+	raw, err := json.MarshalIndent(r.state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode redaction state: %w", err)
+	}
+	if err := os.WriteFile(r.statePath, raw, 0600); err != nil {
+		return fmt.Errorf("failed to write redaction state: %w", err)
+	}
+	r.dirty = false
+	return nil
+}
+
+// alias replaces a value with a salted hash or a stable numbered alias
+func (r *Redactor) alias(value, prefix string, mapping map[string]string) string {
+	if value == "" {
+		return ""
+	}
+	if r.mode == types.RedactModeHash {
+		return prefix + "-" + r.hash(value)[:12]
+	}
+
+	key := r.hash(value)
+	if alias, ok := mapping[key]; ok {
+		return alias
+	}
+	alias := fmt.Sprintf("%s-%d", prefix, len(mapping)+1)
+	mapping[key] = alias
+	r.dirty = true
+	return alias
+}
+
+// hash returns the hex HMAC-SHA256 of a value keyed with the salt
+func (r *Redactor) hash(value string) string {
+	mac := hmac.New(sha256.New, r.salt)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,89 @@
+package codex
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/johanneserhardt/cxusage/internal/types"
+)
+
+func redactTestEntries() []types.CodexUsageEntry {
+	ts := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	return []types.CodexUsageEntry{
+		{Timestamp: ts, SessionID: "sess-1", RequestID: "sess-1-msg", ProjectPath: "/home/me/secret-project", RepoURL: "git@github.com:acme/secret.git",
+			File: "/home/me/.codex/sessions/rollout-sess-1.jsonl", Line: 3, Command: "cat secret-project/notes"},
+		{Timestamp: ts.Add(time.Minute), SessionID: "sess-2", RequestID: "sess-2-msg", ProjectPath: "/home/me/other"},
+	}
+}
+
+func TestRedactorHashMode(t *testing.T) {
+	state := filepath.Join(t.TempDir(), "redaction.json")
+	r, err := newRedactor(types.RedactModeHash, "salt", state)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries := r.Entries(redactTestEntries())
+	raw, _ := json.Marshal(entries)
+	for _, secret := range []string{"sess-1", "sess-2", "secret-project", "acme", "/home/me"} {
+		if strings.Contains(string(raw), secret) {
+			t.Errorf("redacted output still contains %q: %s", secret, raw)
+		}
+	}
+	if entries[0].EntryID == "" || entries[0].EntryID == entries[1].EntryID {
+		t.Errorf("expected distinct entry ids, got %q and %q", entries[0].EntryID, entries[1].EntryID)
+	}
+
+	// The same salt gives the same hashes, so redacted exports still de-duplicate
+	again, _ := newRedactor(types.RedactModeHash, "salt", state)
+	if got := again.Entries(redactTestEntries()); got[0] != entries[0] {
+		t.Errorf("expected stable redaction, got %+v and %+v", got[0], entries[0])
+	}
+
+	other, _ := newRedactor(types.RedactModeHash, "other-salt", state)
+	if got := other.Entries(redactTestEntries()); got[0].ProjectPath == entries[0].ProjectPath {
+		t.Error("expected a different salt to give different hashes")
+	}
+}
+
+func TestRedactorAliasModePersists(t *testing.T) {
+	state := filepath.Join(t.TempDir(), "redaction.json")
+	r, err := newRedactor(types.RedactModeAlias, "", state)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries := r.Entries(redactTestEntries())
+	if entries[0].ProjectPath != "project-1" || entries[1].ProjectPath != "project-2" || entries[0].RepoURL != "repo-1" {
+		t.Fatalf("unexpected aliases: %+v", entries)
+	}
+	if err := r.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A new redactor reuses the generated salt and the alias mapping
+	again, err := newRedactor(types.RedactModeAlias, "", state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := again.Project("/home/me/other"); got != "project-2" {
+		t.Errorf("expected persisted alias project-2, got %q", got)
+	}
+}
+
+func TestParseMessageEntryKeepsNoMessageText(t *testing.T) {
+	line := `{"type": "message", "role": "user", "id": "msg-1", "content": [{"type": "input_text", "text": "TOP SECRET prompt text"}], "timestamp": "2026-10-18T12:00:00Z"}`
+
+	entry, err := parseMessageEntry(line, time.Time{}, "sess-1", DefaultCodexModel, NewTokenEstimator())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	raw, _ := json.Marshal(entry)
+	if strings.Contains(string(raw), "SECRET") || strings.Contains(string(raw), "prompt text") {
+		t.Errorf("message text leaked into the usage entry: %s", raw)
+	}
+}
@@ -0,0 +1,252 @@
+package codex
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/johanneserhardt/cxusage/internal/types"
+)
+
+// SessionState collects the usage entries of one session file. Log formats read their
+// records and feed the session metadata, turn settings, messages and logged token counts
+// into it.
+type SessionState struct {
+	file               string
+	startDate, endDate time.Time
+	estimator          *TokenEstimator
+	turns              *turnTracker
+
+	entries []types.CodexUsageEntry
+	roles   []string // role of the message behind each entry, "" for token counts
+
+	timestamp                     time.Time // session start, for records without their own
+	sessionID, projectPath, repoURL string
+	model, provider, effort       string
+
+	responses   []int       // assistant entries since the last token count
+	loggedUsage bool        // token counts replaced the estimates
+	lastTotal   types.Usage // cumulative usage of the last token count
+
+	sampling bool                // collect calibration samples
+	sample   *calibrationSample  // turn being sampled
+	samples  []calibrationSample // turns with message text and exact usage
+}
+
+// newSessionState starts a session with the model and provider its directory is configured to use
+func newSessionState(file string, defaults SessionDefaults, estimator *TokenEstimator, startDate, endDate time.Time) *SessionState {
+	return &SessionState{
+		file:      file,
+		startDate: startDate,
+		endDate:   endDate,
+		estimator: estimator,
+		turns:     newTurnTracker(),
+		model:     defaults.Model,
+		provider:  defaults.Provider,
+	}
+}
+
+// SetSession applies session metadata to the entries that follow
+func (s *SessionState) SetSession(metadata *SessionMetadata) {
+	s.timestamp = metadata.Timestamp
+	s.sessionID = metadata.ID
+	s.projectPath = metadata.Cwd
+	s.repoURL = metadata.RepoURL
+	if metadata.ModelProvider != "" {
+		s.provider = metadata.ModelProvider
+	}
+}
+
+// SetTurn applies the model and reasoning effort of a turn to the entries that follow
+func (s *SessionState) SetTurn(model, effort string) {
+	s.effort = effort
+	if model != "" {
+		s.model = model
+	}
+}
+
+// AddMessage adds the entry of a message item. Its timestamp falls back to the record's
+// timestamp, then to the session start. Lines that are not messages are ignored.
+func (s *SessionState) AddMessage(lineNum int, item string, timestamp time.Time) {
+	if timestamp.IsZero() {
+		timestamp = s.timestamp
+	}
+	entry, err := parseMessageEntry(item, timestamp, s.sessionID, s.model, s.estimator)
+	if err != nil {
+		return
+	}
+
+	role := messageRole(item)
+	if role == "user" {
+		s.turns.begin(s.entries, entry.Timestamp)
+	}
+	if !s.inRange(entry.Timestamp) {
+		return
+	}
+	s.add(entry, lineNum, role)
+	if s.sampling {
+		s.sampleMessage(item, role, entry.Model)
+	}
+	if role == "assistant" {
+		s.turns.respond(len(s.entries) - 1)
+		s.responses = append(s.responses, len(s.entries)-1)
+	}
+}
+
+// AddTokenCount applies the usage Codex logged for a model request. last is the usage of
+// the request, total the cumulative usage of the session; either may be nil. The usage
+// replaces the estimate of the request's final response, or becomes an entry of its own
+// when the request only called tools.
+func (s *SessionState) AddTokenCount(lineNum int, timestamp time.Time, last, total *types.Usage) {
+	responses := s.responses
+	s.responses = nil
+
+	var usage types.Usage
+	switch {
+	case total != nil && *total == s.lastTotal:
+		// Codex repeats the last token count, e.g. when only rate limits changed
+		return
+	case last != nil:
+		usage = *last
+	case total != nil:
+		usage = types.Usage{
+			PromptTokens:     total.PromptTokens - s.lastTotal.PromptTokens,
+			CompletionTokens: total.CompletionTokens - s.lastTotal.CompletionTokens,
+			TotalTokens:      total.TotalTokens - s.lastTotal.TotalTokens,
+		}
+	default:
+		return
+	}
+	if total != nil {
+		s.lastTotal = *total
+	}
+	if usage.TotalTokens <= 0 {
+		return
+	}
+	s.loggedUsage = true
+	if s.sample != nil {
+		s.sample.model = s.model
+		s.sample.input += usage.PromptTokens
+		s.sample.output += usage.CompletionTokens
+	}
+
+	if len(responses) > 0 {
+		// Earlier responses of the same request are part of the logged usage
+		for _, i := range responses {
+			s.entries[i].Usage = types.Usage{}
+			s.entries[i].Cost = 0
+			s.entries[i].Estimated = false
+		}
+		s.entries[responses[len(responses)-1]].Usage = usage
+		return
+	}
+
+	if timestamp.IsZero() {
+		timestamp = s.timestamp
+	}
+	if !s.inRange(timestamp) {
+		return
+	}
+	s.add(types.CodexUsageEntry{
+		Timestamp: timestamp,
+		SessionID: s.sessionID,
+		RequestID: fmt.Sprintf("%s-request-%d", s.sessionID, lineNum),
+		Model:     s.model,
+		Usage:     usage,
+	}, lineNum, "")
+	s.turns.respond(len(s.entries) - 1)
+}
+
+// finish completes the last turn and returns the entries of the session. Once Codex
+// logged token counts, user messages are part of their input tokens and their
+// estimates are dropped.
+func (s *SessionState) finish() []types.CodexUsageEntry {
+	s.turns.finish(s.entries)
+	s.flushSample()
+	if !s.loggedUsage {
+		return s.entries
+	}
+
+	entries := s.entries[:0]
+	for i, entry := range s.entries {
+		if s.roles[i] == "user" && entry.Estimated {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// add appends an entry with the session's context
+func (s *SessionState) add(entry types.CodexUsageEntry, lineNum int, role string) {
+	entry.ProjectPath = s.projectPath
+	entry.RepoURL = s.repoURL
+	entry.File = s.file
+	entry.Line = lineNum
+	entry.ReasoningEffort = s.effort
+	entry.Provider = s.provider
+	s.entries = append(s.entries, entry)
+	s.roles = append(s.roles, role)
+}
+
+// inRange reports whether a timestamp is within the requested dates (inclusive), so
+// boundary events are not dropped
+func (s *SessionState) inRange(timestamp time.Time) bool {
+	return !timestamp.Before(s.startDate) && !timestamp.After(s.endDate)
+}
+
+// parseTokenCount reads the usage of a token_count event payload
+func parseTokenCount(payload json.RawMessage) (last, total *types.Usage, ok bool) {
+	var event struct {
+		Type string `json:"type"`
+		Info *struct {
+			Last  map[string]interface{} `json:"last_token_usage"`
+			Total map[string]interface{} `json:"total_token_usage"`
+		} `json:"info"`
+	}
+	if err := json.Unmarshal(payload, &event); err != nil || event.Type != "token_count" || event.Info == nil {
+		return nil, nil, false
+	}
+	return tokenUsage(event.Info.Last), tokenUsage(event.Info.Total), true
+}
+
+// tokenUsage converts a logged usage map, nil when absent
+func tokenUsage(m map[string]interface{}) *types.Usage {
+	if m == nil {
+		return nil
+	}
+	in, out := extractUsageTokens(m)
+	return &types.Usage{PromptTokens: in, CompletionTokens: out, TotalTokens: in + out}
+}
+
+// sampleMessage adds the text of a message to the turn being sampled; a user message
+// following the exact usage of a turn starts the next one
+func (s *SessionState) sampleMessage(item, role, model string) {
+	msg, err := ParseCodexMessage(item)
+	if err != nil {
+		return
+	}
+	tokens := messageTokens(*msg, model)
+	if role == "user" {
+		if s.sample != nil && s.sample.input > 0 {
+			s.flushSample()
+		}
+		if s.sample == nil {
+			s.sample = &calibrationSample{file: s.file, model: model}
+		}
+		s.sample.userMessages++
+		s.sample.inputText += tokens
+		return
+	}
+	if s.sample != nil && role == "assistant" {
+		s.sample.outputText += tokens
+	}
+}
+
+// flushSample keeps the turn being sampled if Codex logged its usage
+func (s *SessionState) flushSample() {
+	if s.sample != nil && s.sample.input > 0 {
+		s.samples = append(s.samples, *s.sample)
+	}
+	s.sample = nil
+}
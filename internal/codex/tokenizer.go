@@ -0,0 +1,97 @@
+package codex
+
+import (
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/pkoukk/tiktoken-go"
+	tiktoken_loader "github.com/pkoukk/tiktoken-go-loader"
+)
+
+// BPE encodings of OpenAI models, embedded in the binary so counting works offline
+const (
+	EncodingO200kBase  = "o200k_base"
+	EncodingCl100kBase = "cl100k_base"
+)
+
+// encodingPrefixes select the encoding of a model by the longest matching prefix. GPT-5,
+// GPT-4.1, GPT-4o, the o-series and Codex models use o200k_base; earlier models cl100k_base.
+var encodingPrefixes = map[string]string{
+	"gpt-5":              EncodingO200kBase,
+	"gpt-4.1":            EncodingO200kBase,
+	"gpt-4.5":            EncodingO200kBase,
+	"gpt-4o":             EncodingO200kBase,
+	"gpt-oss":            EncodingO200kBase,
+	"o1":                 EncodingO200kBase,
+	"o3":                 EncodingO200kBase,
+	"o4":                 EncodingO200kBase,
+	"codex-":             EncodingO200kBase,
+	"gpt-4":              EncodingCl100kBase,
+	"gpt-3.5":            EncodingCl100kBase,
+	"text-embedding-3":   EncodingCl100kBase,
+	"text-embedding-ada": EncodingCl100kBase,
+}
+
+// defaultEncoding counts models of unknown tokenizers, e.g. local models; Codex CLI's own
+// models all use it
+const defaultEncoding = EncodingO200kBase
+
+// fallbackCharsPerToken is used if an embedded encoding cannot be loaded; it is the usual
+// average of English text and code
+const fallbackCharsPerToken = 4.0
+
+var (
+	encodingsMu sync.Mutex
+	encodings   = make(map[string]*tiktoken.Tiktoken)
+)
+
+func init() {
+	tiktoken.SetBpeLoader(tiktoken_loader.NewOfflineLoader())
+}
+
+// EncodingForModel returns the BPE encoding a model tokenizes with
+func EncodingForModel(model string) string {
+	// Provider-qualified names such as openai/gpt-5 or azure/gpt-4o
+	if i := strings.LastIndex(model, "/"); i >= 0 {
+		model = model[i+1:]
+	}
+	encoding, matched := defaultEncoding, ""
+	for prefix, e := range encodingPrefixes {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(matched) {
+			encoding, matched = e, prefix
+		}
+	}
+	return encoding
+}
+
+// CountTokens returns the exact number of tokens of text in an encoding. Encodings are
+// loaded on first use, which takes a moment for o200k_base.
+func CountTokens(encoding, text string) int {
+	if text == "" {
+		return 0
+	}
+	if enc := loadEncoding(encoding); enc != nil {
+		return len(enc.EncodeOrdinary(text))
+	}
+	count := int(float64(utf8.RuneCountInString(text))/fallbackCharsPerToken + 0.5)
+	if count < 1 {
+		return 1
+	}
+	return count
+}
+
+// loadEncoding returns a cached encoding, nil if it cannot be loaded
+func loadEncoding(name string) *tiktoken.Tiktoken {
+	encodingsMu.Lock()
+	defer encodingsMu.Unlock()
+	if enc, ok := encodings[name]; ok {
+		return enc
+	}
+	enc, err := tiktoken.GetEncoding(name)
+	if err != nil {
+		enc = nil
+	}
+	encodings[name] = enc
+	return enc
+}
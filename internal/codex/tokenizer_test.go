@@ -0,0 +1,55 @@
+package codex
+
+import "testing"
+
+func TestEncodingForModel(t *testing.T) {
+	tests := map[string]string{
+		"gpt-5-codex":   EncodingO200kBase,
+		"gpt-4o-mini":   EncodingO200kBase,
+		"gpt-4.1":       EncodingO200kBase,
+		"o4-mini":       EncodingO200kBase,
+		"gpt-4-turbo":   EncodingCl100kBase,
+		"gpt-3.5-turbo": EncodingCl100kBase,
+		"azure/gpt-4":   EncodingCl100kBase,
+		"openai/gpt-5":  EncodingO200kBase,
+		"qwen2.5-coder": defaultEncoding,
+		"":              defaultEncoding,
+	}
+	for model, want := range tests {
+		if got := EncodingForModel(model); got != want {
+			t.Errorf("EncodingForModel(%q) = %s, want %s", model, got, want)
+		}
+	}
+}
+
+func TestCountTokens(t *testing.T) {
+	if got := CountTokens(EncodingO200kBase, "hello world"); got != 2 {
+		t.Errorf("o200k_base: got %d tokens, want 2", got)
+	}
+	if got := CountTokens(EncodingCl100kBase, "hello world"); got != 2 {
+		t.Errorf("cl100k_base: got %d tokens, want 2", got)
+	}
+	if got := CountTokens(EncodingO200kBase, ""); got != 0 {
+		t.Errorf("empty text: got %d tokens", got)
+	}
+	// Special tokens in logged text are counted as ordinary text
+	if got := CountTokens(EncodingO200kBase, "<|endoftext|>"); got <= 1 {
+		t.Errorf("special token text: got %d tokens, want it split", got)
+	}
+}
+
+func TestEstimateTokensFromMessage(t *testing.T) {
+	e := NewTokenEstimator()
+	text := CodexMessage{Content: []Content{{Text: "hello world"}}}
+
+	user := text
+	user.Role = "user"
+	if in, out := e.EstimateTokensFromMessage(user, "gpt-5"); in != 2+MessageOverheadTokens+DefaultRequestOverheadTokens || out != 0 {
+		t.Errorf("user message: got %d/%d", in, out)
+	}
+	assistant := text
+	assistant.Role = "assistant"
+	if in, out := e.EstimateTokensFromMessage(assistant, "gpt-5"); in != 0 || out != 2+MessageOverheadTokens {
+		t.Errorf("assistant message: got %d/%d", in, out)
+	}
+}
@@ -0,0 +1,351 @@
+package codex
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/johanneserhardt/cxusage/internal/filter"
+	"github.com/johanneserhardt/cxusage/internal/jsonl"
+	"github.com/johanneserhardt/cxusage/internal/types"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	exitCodePattern = regexp.MustCompile(`(?m)^(?:Exit code:|Process exited with code) (-?\d+)`)
+	wallTimePattern = regexp.MustCompile(`(?m)^Wall time: ([0-9.]+) seconds`)
+
+	// commandSeparator splits shell scripts into simple commands
+	commandSeparator = strings.NewReplacer("&&", "\n", "||", "\n", ";", "\n", "|", "\n")
+)
+
+// ParseToolCalls parses tool invocations (function, custom tool and local shell calls) and
+// their outputs from all Codex log files
+func ParseToolCalls(cfg *types.Config, startDate, endDate time.Time, logger *logrus.Logger) ([]types.ToolCall, error) {
+	// Exports and bundles only carry usage entries, so tool calls need the session logs
+	if cfg.Database != "" {
+		return nil, fmt.Errorf("tool calls are not stored in exported databases; run without --db")
+	}
+
+	files, err := GetSourceLogFiles(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var pred filter.Predicate
+	if cfg.Where != "" {
+		if pred, err = filter.Parse(cfg.Where); err != nil {
+			return nil, fmt.Errorf("invalid --where expression: %w", err)
+		}
+	}
+
+	var calls []types.ToolCall
+	seen := make(map[string]struct{})
+	for _, file := range files {
+		// Files untouched since the start date cannot contain calls in range
+		if info, err := os.Stat(file.Path); err == nil && info.ModTime().Before(startDate) {
+			continue
+		}
+
+		fileCalls, err := parseToolCallFile(file.Path, startDate, endDate)
+		if err != nil {
+			logger.WithError(err).WithField("file", filepath.Base(file.Path)).Warn("Failed to parse tool calls from log file")
+			continue
+		}
+		for _, call := range fileCalls {
+			call.Source = file.Source
+			call.User = cfg.User
+
+			// The same session may be found in several Codex directories; the first source wins
+			if call.CallID != "" {
+				key := call.SessionID + "|" + call.CallID
+				if _, ok := seen[key]; ok {
+					continue
+				}
+				seen[key] = struct{}{}
+			}
+			if pred != nil && !pred(toolCallEntry(call)) {
+				continue
+			}
+			calls = append(calls, call)
+		}
+	}
+
+	sort.SliceStable(calls, func(i, j int) bool {
+		return calls[i].Timestamp.Before(calls[j].Timestamp)
+	})
+
+	if RedactionEnabled(cfg) {
+		redactor, err := NewRedactor(cfg)
+		if err != nil {
+			return nil, err
+		}
+		calls = redactor.ToolCalls(calls)
+		if err := redactor.Save(); err != nil {
+			logger.WithError(err).Warn("Failed to save redaction state")
+		}
+	}
+
+	logger.WithField("tool_calls", len(calls)).Info("Parsed Codex tool calls")
+	return calls, nil
+}
+
+// toolCallEntry describes a tool call as a usage entry, so --where expressions on session
+// fields (project, session, source, date, hour, duration_ms, ...) also select tool calls
+func toolCallEntry(call types.ToolCall) types.CodexUsageEntry {
+	return types.CodexUsageEntry{
+		Timestamp:   call.Timestamp,
+		SessionID:   call.SessionID,
+		RequestID:   call.CallID,
+		ProjectPath: call.ProjectPath,
+		Duration:    call.Duration,
+		Source:      call.Source,
+		User:        call.User,
+		File:        call.File,
+		Line:        call.Line,
+	}
+}
+
+// parseToolCallFile extracts tool calls from a single session file, joining each call with
+// its output by call id
+func parseToolCallFile(filename string, startDate, endDate time.Time) ([]types.ToolCall, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	estimator := NewTokenEstimator()
+	var calls []types.ToolCall
+	pending := make(map[string]int) // call id -> index in calls
+	var sessionTimestamp time.Time
+	var sessionID, projectPath string
+
+	err = jsonl.Scan(file, func(lineNum int, line string) {
+		if lineNum == 1 {
+			if sessionData, err := parseSessionMetadata(line); err == nil {
+				sessionTimestamp = sessionData.Timestamp
+				sessionID = sessionData.ID
+				projectPath = sessionData.Cwd
+			}
+		}
+
+		// Cheap pre-check before decoding the full line
+		if !strings.Contains(line, "_call") {
+			return
+		}
+
+		item, timestamp, ok := parseResponseItem(line, sessionTimestamp)
+		if !ok {
+			return
+		}
+		callID, _ := item["call_id"].(string)
+
+		switch item["type"] {
+		case "function_call", "custom_tool_call", "local_shell_call":
+			call := parseToolCallItem(item)
+			call.Timestamp = timestamp
+			call.SessionID = sessionID
+			call.CallID = callID
+			call.ProjectPath = projectPath
+			call.File = filename
+			call.Line = lineNum
+			if callID != "" {
+				pending[callID] = len(calls)
+			}
+			calls = append(calls, call)
+		case "function_call_output", "custom_tool_call_output":
+			i, ok := pending[callID]
+			if !ok {
+				return
+			}
+			delete(pending, callID)
+			applyToolOutput(&calls[i], item["output"], timestamp, estimator)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Filter by date range (inclusive) once outputs have been joined
+	var inRange []types.ToolCall
+	for _, call := range calls {
+		if (call.Timestamp.Equal(startDate) || call.Timestamp.After(startDate)) &&
+			(call.Timestamp.Equal(endDate) || call.Timestamp.Before(endDate)) {
+			inRange = append(inRange, call)
+		}
+	}
+	return inRange, nil
+}
+
+// parseResponseItem decodes a response item, either wrapped in a response_item payload or
+// at the top level of the line as in older rollouts
+func parseResponseItem(line string, sessionTimestamp time.Time) (map[string]interface{}, time.Time, bool) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &raw); err != nil {
+		return nil, time.Time{}, false
+	}
+
+	item := raw
+	if raw["type"] == "response_item" {
+		payload, ok := raw["payload"].(map[string]interface{})
+		if !ok {
+			return nil, time.Time{}, false
+		}
+		item = payload
+	}
+
+	timestamp := sessionTimestamp
+	if timeStr, ok := raw["timestamp"].(string); ok {
+		if t, err := time.Parse(time.RFC3339, timeStr); err == nil {
+			timestamp = t
+		}
+	}
+	return item, timestamp, true
+}
+
+// parseToolCallItem reads the tool name and the command of shell tools from a call item
+func parseToolCallItem(item map[string]interface{}) types.ToolCall {
+	var call types.ToolCall
+	if item["type"] == "local_shell_call" {
+		call.Tool = "local_shell"
+		if action, ok := item["action"].(map[string]interface{}); ok {
+			call.Command = shellProgram(action["command"])
+		}
+		if status, ok := item["status"].(string); ok && status == "incomplete" {
+			call.Failed = true
+		}
+	} else {
+		call.Tool, _ = item["name"].(string)
+		if call.Tool == "" {
+			call.Tool = "unknown"
+		}
+		// Function arguments are a JSON document encoded as a string
+		if arguments, ok := item["arguments"].(string); ok {
+			var args map[string]interface{}
+			if err := json.Unmarshal([]byte(arguments), &args); err == nil {
+				if command, ok := args["command"]; ok {
+					call.Command = shellProgram(command)
+				} else if command, ok := args["cmd"]; ok {
+					call.Command = shellProgram(command)
+				}
+			}
+		}
+	}
+
+	if call.Command == "" {
+		call.Command = call.Tool
+	}
+	return call
+}
+
+// shellProgram returns the program a shell tool runs, from either an argv array
+// (["bash", "-lc", "cd api && pytest -q"]) or a script string
+func shellProgram(command interface{}) string {
+	switch c := command.(type) {
+	case string:
+		return scriptProgram(c)
+	case []interface{}:
+		var argv []string
+		for _, arg := range c {
+			if s, ok := arg.(string); ok {
+				argv = append(argv, s)
+			}
+		}
+		if len(argv) == 0 {
+			return ""
+		}
+		if len(argv) >= 3 && (argv[1] == "-lc" || argv[1] == "-c") {
+			switch filepath.Base(argv[0]) {
+			case "bash", "sh", "zsh":
+				return scriptProgram(argv[2])
+			}
+		}
+		return filepath.Base(argv[0])
+	}
+	return ""
+}
+
+// scriptProgram returns the first program of a shell script, skipping directory changes,
+// variable assignments and wrappers such as env or sudo
+func scriptProgram(script string) string {
+	changesDir := false
+segments:
+	for _, segment := range strings.Split(commandSeparator.Replace(script), "\n") {
+		for _, word := range strings.Fields(segment) {
+			if i := strings.Index(word, "="); i > 0 && !strings.HasPrefix(word, "-") {
+				continue
+			}
+			switch word {
+			case "env", "sudo", "time", "command", "exec", "nohup":
+				continue
+			case "cd", "pushd":
+				changesDir = true
+				continue segments
+			}
+			return filepath.Base(strings.Trim(word, `"'(`))
+		}
+	}
+	if changesDir {
+		return "cd"
+	}
+	return ""
+}
+
+// applyToolOutput records the exit code, duration and estimated context tokens of a call's output
+func applyToolOutput(call *types.ToolCall, output interface{}, timestamp time.Time, estimator *TokenEstimator) {
+	var text string
+	var durationMs int64
+
+	switch out := output.(type) {
+	case string:
+		text = out
+
+		// Shell outputs are often a JSON document with the exit code in its metadata
+		var structured struct {
+			Metadata struct {
+				ExitCode        *int     `json:"exit_code"`
+				DurationSeconds *float64 `json:"duration_seconds"`
+			} `json:"metadata"`
+		}
+		if err := json.Unmarshal([]byte(out), &structured); err == nil && structured.Metadata.ExitCode != nil {
+			call.ExitCode = structured.Metadata.ExitCode
+			if structured.Metadata.DurationSeconds != nil {
+				durationMs = int64(*structured.Metadata.DurationSeconds * 1000)
+			}
+		} else {
+			if m := exitCodePattern.FindStringSubmatch(out); m != nil {
+				if code, err := strconv.Atoi(m[1]); err == nil {
+					call.ExitCode = &code
+				}
+			}
+			if m := wallTimePattern.FindStringSubmatch(out); m != nil {
+				if seconds, err := strconv.ParseFloat(m[1], 64); err == nil {
+					durationMs = int64(seconds * 1000)
+				}
+			}
+		}
+	case map[string]interface{}:
+		text, _ = out["content"].(string)
+		if success, ok := out["success"].(bool); ok && !success {
+			call.Failed = true
+		}
+	}
+
+	if call.ExitCode != nil && *call.ExitCode != 0 {
+		call.Failed = true
+	}
+
+	// Fall back to the time between the call and its output
+	if durationMs == 0 && timestamp.After(call.Timestamp) {
+		durationMs = timestamp.Sub(call.Timestamp).Milliseconds()
+	}
+	call.Duration = durationMs
+	call.OutputTokens = estimator.EstimateTokens(text)
+}
@@ -0,0 +1,70 @@
+package codex
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const toolsTestRollout = `{"timestamp":"2026-10-18T10:00:00Z","type":"session_meta","payload":{"id":"sess-1","cwd":"/home/me/src/api"}}
+{"timestamp":"2026-10-18T10:00:01Z","type":"response_item","payload":{"type":"function_call","name":"shell","arguments":"{\"command\":[\"bash\",\"-lc\",\"cd api && FOO=1 pytest -q\"]}","call_id":"call_1"}}
+{"timestamp":"2026-10-18T10:00:09Z","type":"response_item","payload":{"type":"function_call_output","call_id":"call_1","output":"{\"output\":\"1 failed, 12 passed\",\"metadata\":{\"exit_code\":1,\"duration_seconds\":7.5}}"}}
+{"timestamp":"2026-10-18T10:00:10Z","type":"response_item","payload":{"type":"custom_tool_call","name":"apply_patch","input":"*** Begin Patch","call_id":"call_2"}}
+{"timestamp":"2026-10-18T10:00:11Z","type":"response_item","payload":{"type":"custom_tool_call_output","call_id":"call_2","output":"Success. Updated the following files:\nM api/app.py"}}
+{"type":"local_shell_call","call_id":"call_3","status":"completed","action":{"type":"exec","command":["rg","-n","TODO"]},"timestamp":"2026-10-18T10:00:12Z"}
+{"type":"function_call_output","call_id":"call_3","output":"Exit code: 0\nWall time: 0.2 seconds\nOutput:\napp.py:3: TODO","timestamp":"2026-10-18T10:00:13Z"}
+`
+
+func TestParseToolCallFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rollout.jsonl")
+	if err := os.WriteFile(path, []byte(toolsTestRollout), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	calls, err := parseToolCallFile(path, start, start.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 3 {
+		t.Fatalf("expected 3 tool calls, got %d", len(calls))
+	}
+
+	pytest := calls[0]
+	if pytest.Tool != "shell" || pytest.Command != "pytest" || !pytest.Failed || pytest.ExitCode == nil || *pytest.ExitCode != 1 {
+		t.Errorf("unexpected shell call %+v", pytest)
+	}
+	if pytest.Duration != 7500 || pytest.OutputTokens == 0 || pytest.SessionID != "sess-1" || pytest.ProjectPath != "/home/me/src/api" || pytest.Line != 2 {
+		t.Errorf("unexpected shell call details %+v", pytest)
+	}
+
+	patch := calls[1]
+	if patch.Tool != "apply_patch" || patch.Command != "apply_patch" || patch.Failed || patch.Duration != 1000 {
+		t.Errorf("unexpected custom tool call %+v", patch)
+	}
+
+	rg := calls[2]
+	if rg.Tool != "local_shell" || rg.Command != "rg" || rg.Failed || rg.ExitCode == nil || rg.Duration != 200 {
+		t.Errorf("unexpected local shell call %+v", rg)
+	}
+}
+
+func TestShellProgram(t *testing.T) {
+	tests := []struct {
+		command interface{}
+		want    string
+	}{
+		{[]interface{}{"/usr/bin/git", "status"}, "git"},
+		{[]interface{}{"bash", "-lc", "sed -n '1,80p' main.go | head"}, "sed"},
+		{[]interface{}{"apply_patch", "*** Begin Patch"}, "apply_patch"},
+		{"cd web; sudo env CI=1 npm test", "npm"},
+		{"cd web", "cd"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := shellProgram(tt.command); got != tt.want {
+			t.Errorf("%v: got %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package codex
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/johanneserhardt/cxusage/internal/types"
+)
+
+// turnTracker derives turn durations while a session file is parsed. A turn runs from a
+// user message, through any tool calls, to the last assistant response before the next
+// user message; its duration is recorded on that final response.
+type turnTracker struct {
+	start time.Time
+	final int // index of the latest assistant entry of the turn, -1 if none
+}
+
+func newTurnTracker() *turnTracker {
+	return &turnTracker{final: -1}
+}
+
+// begin finishes the current turn and starts a new one at a user message
+func (t *turnTracker) begin(entries []types.CodexUsageEntry, timestamp time.Time) {
+	t.finish(entries)
+	t.start = timestamp
+}
+
+// respond records an assistant response of the current turn
+func (t *turnTracker) respond(index int) {
+	t.final = index
+}
+
+// finish sets the duration of the current turn's final response
+func (t *turnTracker) finish(entries []types.CodexUsageEntry) {
+	if t.final >= 0 && !t.start.IsZero() {
+		final := &entries[t.final]
+		if final.Timestamp.After(t.start) {
+			final.Duration = final.Timestamp.Sub(t.start).Milliseconds()
+		}
+	}
+	t.start = time.Time{}
+	t.final = -1
+}
+
+// messageRole returns the role of a message line
+func messageRole(line string) string {
+	var msg struct {
+		Role string `json:"role"`
+	}
+	if err := json.Unmarshal([]byte(line), &msg); err != nil {
+		return ""
+	}
+	return msg.Role
+}
@@ -0,0 +1,45 @@
+package codex
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/johanneserhardt/cxusage/internal/pricing"
+	"github.com/sirupsen/logrus"
+)
+
+const turnsTestRollout = `{"timestamp":"2026-10-18T09:00:00Z","type":"session_meta","payload":{"id":"sess-1","cwd":"/home/me/src/web"}}
+{"type":"message","role":"user","content":[{"type":"input_text","text":"add a test"}],"timestamp":"2026-10-18T09:00:00Z"}
+{"type":"message","role":"assistant","model":"gpt-5","content":[{"type":"output_text","text":"looking"}],"timestamp":"2026-10-18T09:00:20Z"}
+{"type":"local_shell_call","call_id":"call_1","status":"completed","action":{"type":"exec","command":["go","test"]},"timestamp":"2026-10-18T09:00:21Z"}
+{"type":"message","role":"assistant","model":"gpt-5","content":[{"type":"output_text","text":"done"}],"timestamp":"2026-10-18T09:01:30Z"}
+{"type":"message","role":"user","content":[{"type":"input_text","text":"now docs"}],"timestamp":"2026-10-18T09:05:00Z"}
+{"type":"message","role":"assistant","model":"gpt-5","content":[{"type":"output_text","text":"done"}],"timestamp":"2026-10-18T09:05:40Z"}
+{"type":"message","role":"user","content":[{"type":"input_text","text":"unanswered"}],"timestamp":"2026-10-18T09:10:00Z"}
+`
+
+func TestParseCodexSessionFileTurnDurations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rollout.jsonl")
+	if err := os.WriteFile(path, []byte(turnsTestRollout), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	entries, err := parseCodexSessionFile(path, ResolveDefaults(nil), pricing.Default(), NewTokenEstimator(), start, start.AddDate(0, 0, 1), logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the final response of each answered turn carries the turn's duration
+	want := []int64{0, 0, 90000, 0, 40000, 0}
+	if len(entries) != len(want) {
+		t.Fatalf("expected %d entries, got %d", len(want), len(entries))
+	}
+	for i, entry := range entries {
+		if entry.Duration != want[i] {
+			t.Errorf("entry %d (line %d): expected duration %d, got %d", i, entry.Line, want[i], entry.Duration)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/johanneserhardt/cxusage/internal/types"
+	"github.com/johanneserhardt/cxusage/internal/utils"
+)
+
+var activityCmd = &cobra.Command{
+	Use:   "activity [days]",
+	Short: "Show turn latency per model and active working time per day",
+	Long: `Compare models on speed and measure active working time.
+A turn runs from a user message, through any tool calls, to the final assistant
+response. Shows the median, p95, mean and max turn latency per model, and the
+active time of each day (gaps longer than --idle minutes excluded) with the cost
+per active hour. By default shows the last 7 days.`,
+	Example: `  cxusage activity
+  cxusage activity 30 --idle 5
+  cxusage activity -o json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runActivity,
+}
+
+// activityReport is the JSON representation of the activity command
+type activityReport struct {
+	Latency []types.TurnLatency   `json:"latency"`
+	Days    []types.DailyActivity `json:"days"`
+}
+
+func runActivity(cmd *cobra.Command, args []string) error {
+	days := 7 // default
+	if len(args) > 0 {
+		var err error
+		days, err = strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid number of days: %s", args[0])
+		}
+		if days < 1 || days > 365 {
+			return fmt.Errorf("days must be between 1 and 365")
+		}
+	}
+
+	// Get flags
+	outputFormat, _ := cmd.Flags().GetString("output")
+	idleMinutes, _ := cmd.Flags().GetInt("idle")
+	if idleMinutes < 1 {
+		return fmt.Errorf("idle must be at least 1 minute")
+	}
+
+	endDate := time.Now()
+	startDate := endDate.AddDate(0, 0, -days)
+
+	logger.WithFields(map[string]interface{}{
+		"start_date": startDate.Format("2006-01-02"),
+		"end_date":   endDate.Format("2006-01-02"),
+		"idle":       idleMinutes,
+	}).Info("Generating activity report")
+
+	latency, daily, err := utils.LoadActivityFromCodex(cfg, startDate, endDate, time.Duration(idleMinutes)*time.Minute, logger)
+	if err != nil {
+		return fmt.Errorf("failed to load usage data: %w", err)
+	}
+	report := activityReport{Latency: latency, Days: daily}
+
+	switch types.OutputFormat(outputFormat) {
+	case types.OutputFormatJSON:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case types.OutputFormatTable:
+		if len(daily) == 0 {
+			fmt.Printf("%s\n", utils.Yellow("No Codex CLI usage data found"))
+			fmt.Println()
+			fmt.Printf("Try:\n")
+			fmt.Printf("• %s - Check if Codex CLI is set up\n", utils.Cyan("cxusage validate"))
+			fmt.Printf("• %s - Look further back\n", utils.Cyan("cxusage activity 30"))
+			return nil
+		}
+		utils.FormatActivityTables(report.Latency, report.Days)
+	default:
+		return fmt.Errorf("unsupported output format: %s", outputFormat)
+	}
+
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(activityCmd)
+
+	// Activity-specific flags
+	activityCmd.Flags().Int("idle", 15, "Minutes without activity after which time counts as idle")
+}
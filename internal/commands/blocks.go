@@ -41,12 +41,35 @@ func runBlocks(cmd *cobra.Command, args []string) error {
 	sessionHours, _ := cmd.Flags().GetInt("session-duration")
 	refreshInterval, _ := cmd.Flags().GetInt("refresh-interval")
 	tokenLimitStr, _ := cmd.Flags().GetString("token-limit")
+	blockModeStr, _ := cmd.Flags().GetString("block-mode")
+	projectionStr, _ := cmd.Flags().GetString("projection")
+	projectionWindow, _ := cmd.Flags().GetInt("projection-window")
+	byUser, _ := cmd.Flags().GetBool("by-user")
 	
 	// Validate session duration
 	if sessionHours < 1 || sessionHours > 24 {
 		return fmt.Errorf("session duration must be between 1 and 24 hours")
 	}
 	
+	// Validate block mode
+	blockMode, err := blocks.ParseBlockMode(blockModeStr)
+	if err != nil {
+		return err
+	}
+	
+	// Validate projection settings
+	projectionStrategy, err := blocks.ParseProjectionStrategy(projectionStr)
+	if err != nil {
+		return err
+	}
+	if projectionWindow < 1 || projectionWindow > 300 {
+		return fmt.Errorf("projection window must be between 1 and 300 minutes")
+	}
+	projectionConfig := types.ProjectionConfig{
+		Strategy: projectionStrategy,
+		Window:   time.Duration(projectionWindow) * time.Minute,
+	}
+	
 	// Parse token limit
 	var tokenLimit *int
 	if tokenLimitStr != "" && tokenLimitStr != "max" {
@@ -56,6 +79,9 @@ func runBlocks(cmd *cobra.Command, args []string) error {
 	}
 	
 	// Handle live mode
+	if liveMode && byUser {
+		return fmt.Errorf("--by-user is not supported in live mode")
+	}
 	if liveMode {
 		refreshDuration := time.Duration(refreshInterval) * time.Second
 		if refreshDuration < live.MinRefreshInterval {
@@ -68,8 +94,10 @@ func runBlocks(cmd *cobra.Command, args []string) error {
 		config := &types.LiveMonitoringConfig{
 			RefreshInterval:      refreshDuration,
 			SessionDurationHours: sessionHours,
+			BlockMode:            blockMode,
 			TokenLimit:           tokenLimit,
 			ShowProjections:      true,
+			Projection:           projectionConfig,
 		}
 		
 		monitor := live.NewLiveMonitor(config, cfg, logger)
@@ -95,6 +123,7 @@ func runBlocks(cmd *cobra.Command, args []string) error {
 		"start_date":     startDate.Format("2006-01-02"),
 		"end_date":       endDate.Format("2006-01-02"),
 		"session_hours":  sessionHours,
+		"block_mode":     blockMode,
 		"active_only":    activeOnly,
 		"recent_only":    recentOnly,
 	}).Info("Generating blocks usage report")
@@ -125,14 +154,49 @@ func runBlocks(cmd *cobra.Command, args []string) error {
 	}
 	
 	// Aggregate into blocks
-	sessionBlocks := blocks.AggregateIntoBlocks(entries, sessionHours)
+	var sessionBlocks []types.SessionBlock
+	if byUser {
+		sessionBlocks = blocks.AggregateIntoBlocksByUser(entries, sessionHours, blockMode)
+	} else {
+		sessionBlocks = blocks.AggregateIntoBlocksWithMode(entries, sessionHours, blockMode)
+	}
+	
+	// Project the active block before filtering (historical projections need past blocks)
+	var projection *types.BlockProjection
+	if activeBlock := blocks.GetActiveBlock(sessionBlocks); activeBlock != nil && !byUser {
+		history := sessionBlocks
+		if projectionStrategy == types.ProjectionHistorical {
+			history, err = utils.LoadBlockHistoryFromCodex(cfg, blocks.HistoricalProjectionDays, sessionHours, blockMode, logger)
+			if err != nil {
+				return fmt.Errorf("failed to load block history: %w", err)
+			}
+		}
+		now := time.Now()
+		projection = blocks.CalculateProjectionsWithConfig(activeBlock, history, projectionConfig, now)
+		
+		// Estimate when the token limit or the plan rate limit will be hit
+		snapshots, err := codex.ParseRateLimitFiles(cfg, now.Add(-time.Duration(sessionHours)*time.Hour), now, logger)
+		if err != nil {
+			logger.WithError(err).Debug("Failed to load rate-limit data")
+		}
+		blocks.EstimateLimitExhaustion(projection, activeBlock, tokenLimit, snapshots, now)
+		activeBlock.Projection = projection
+	}
 	
 	// Apply filters
 	if recentOnly {
 		sessionBlocks = blocks.FilterRecentBlocks(sessionBlocks, recentDays)
 	}
 	
-	if activeOnly {
+	if activeOnly && byUser {
+		var active []types.SessionBlock
+		for _, block := range sessionBlocks {
+			if block.IsActive && !block.IsGap {
+				active = append(active, block)
+			}
+		}
+		sessionBlocks = active
+	} else if activeOnly {
 		if activeBlock := blocks.GetActiveBlock(sessionBlocks); activeBlock != nil {
 			sessionBlocks = []types.SessionBlock{*activeBlock}
 		} else {
@@ -145,7 +209,7 @@ func runBlocks(cmd *cobra.Command, args []string) error {
 	case types.OutputFormatJSON:
 		return outputBlocksJSON(sessionBlocks)
 	case types.OutputFormatTable:
-		utils.FormatBlocksTableProper(sessionBlocks, tokenLimit)
+		utils.FormatBlocksTableProper(sessionBlocks, tokenLimit, projection)
 		return nil
 	default:
 		return fmt.Errorf("unsupported output format: %s", outputFormat)
@@ -340,6 +404,10 @@ func init() {
 	blocksCmd.Flags().Bool("recent", false, "Show only recent blocks")
 	blocksCmd.Flags().Int("recent-days", 3, "Number of recent days to show (with --recent)")
 	blocksCmd.Flags().Int("session-duration", 5, "Block duration in hours (default: 5)")
+	blocksCmd.Flags().String("block-mode", string(types.BlockModeFixed), "Block detection mode: fixed (clock-aligned slots) or rolling (starts at first activity)")
+	blocksCmd.Flags().String("projection", string(types.ProjectionAverage), "Projection strategy: average, recent, ewma or historical")
+	blocksCmd.Flags().Int("projection-window", 30, "Lookback in minutes for recent and ewma projections")
 	blocksCmd.Flags().Int("refresh-interval", 1, "Refresh interval in seconds for live mode")
 	blocksCmd.Flags().String("token-limit", "", "Token limit threshold for warnings (number or 'max')")
+	blocksCmd.Flags().Bool("by-user", false, "Build blocks separately for each user (with --bundle or --team)")
 }
\ No newline at end of file
@@ -0,0 +1,512 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/johanneserhardt/cxusage/internal/aggregate"
+	"github.com/johanneserhardt/cxusage/internal/codex"
+	"github.com/johanneserhardt/cxusage/internal/config"
+	"github.com/johanneserhardt/cxusage/internal/filter"
+	"github.com/johanneserhardt/cxusage/internal/types"
+	"github.com/johanneserhardt/cxusage/internal/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "View, initialize and edit cxusage settings",
+	Long: `View and edit cxusage settings.
+Every command line flag can also be set in the config file and through a CXUSAGE_
+environment variable: global flags as top-level keys (log_level, output, sources, ...),
+command flags under the command name (blocks.session_duration, usage.group_by, ...).
+Flags override environment variables, which override the config file.`,
+	Example: `  cxusage config show
+  cxusage config show blocks
+  cxusage config set blocks.session_duration 6
+  CXUSAGE_LOG_LEVEL=debug cxusage config get log_level`,
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show [prefix]",
+	Short: "Show the effective configuration and where each value comes from",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runConfigShow,
+}
+
+var configInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Write a commented starter config file",
+	Args:  cobra.NoArgs,
+	RunE:  runConfigInit,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print the effective value of a key",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runConfigGet,
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>...",
+	Short: "Set a key in the config file",
+	Long: `Set a key in the config file, keeping the rest of the file and its comments.
+List keys take several values or a comma-separated list; codex_dirs takes
+[label=]path values and pricing.rates takes provider[:model]=input/output prices
+per 1M tokens.`,
+	Example: `  cxusage config set log_level debug
+  cxusage config set sources work,personal
+  cxusage config set codex_dirs work=~/work/.codex
+  cxusage config set pricing.rates azure:gpt-5-codex=1.25/10 openrouter=1/4`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: runConfigSet,
+}
+
+var configPathCmd = &cobra.Command{
+	Use:   "path",
+	Short: "Print the path of the config file",
+	Args:  cobra.NoArgs,
+	RunE:  runConfigPath,
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the config file and CXUSAGE_ environment variables",
+	Args:  cobra.NoArgs,
+	// Problems are already listed; the usage text would only bury them
+	SilenceUsage: true,
+	RunE:         runConfigValidate,
+}
+
+// globalConfigKeys maps global flags to config keys that differ from the flag name
+var globalConfigKeys = map[string]string{
+	"source": "sources",
+	"agent":  "agents",
+	"bundle": "bundles",
+}
+
+// configChecks validate values beyond their type, keyed by config key
+var configChecks = map[string]func(string) error{
+	"log_level": func(v string) error {
+		_, err := logrus.ParseLevel(v)
+		return err
+	},
+	"output": func(v string) error {
+		switch types.OutputFormat(v) {
+		case types.OutputFormatTable, types.OutputFormatJSON, types.OutputFormatNDJSON:
+			return nil
+		}
+		return fmt.Errorf("unsupported output format: %s (expected table, json or ndjson)", v)
+	},
+	"redact": func(v string) error {
+		_, err := codex.ParseRedactMode(v)
+		return err
+	},
+	"where": func(v string) error {
+		_, err := filter.Parse(v)
+		return err
+	},
+	"usage.group_by": func(v string) error {
+		_, err := aggregate.ParseDimensions(v)
+		return err
+	},
+	"tools.group_by": func(v string) error {
+		_, err := aggregate.ParseToolDimensions(v)
+		return err
+	},
+}
+
+// configBindings ties every flag to a config key: global flags to top-level keys, command
+// flags to keys under the command name (e.g. blocks.session_duration)
+func configBindings(root *cobra.Command) []config.Binding {
+	var bindings []config.Binding
+	root.PersistentFlags().VisitAll(func(f *pflag.Flag) {
+		// Labeled directories are configured as the codex_dirs list
+		if f.Name == "codex-dir" {
+			return
+		}
+		key, ok := globalConfigKeys[f.Name]
+		if !ok {
+			key = configKey(f.Name)
+		}
+		bindings = append(bindings, config.Binding{Key: key, Flag: f, Check: configChecks[key]})
+	})
+
+	var walk func(cmd *cobra.Command, prefix string)
+	walk = func(cmd *cobra.Command, prefix string) {
+		for _, sub := range cmd.Commands() {
+			if sub == configCmd || sub.Name() == "help" || sub.Name() == "completion" {
+				continue
+			}
+			section := prefix + configKey(sub.Name())
+			sub.LocalFlags().VisitAll(func(f *pflag.Flag) {
+				if f.Name == "help" {
+					return
+				}
+				key := section + "." + configKey(f.Name)
+				bindings = append(bindings, config.Binding{Key: key, Flag: f, Check: configChecks[key]})
+			})
+			walk(sub, section+".")
+		}
+	}
+	walk(root, "")
+	return bindings
+}
+
+// configKey converts a flag or command name to a config key
+func configKey(name string) string {
+	return strings.ReplaceAll(name, "-", "_")
+}
+
+// configValues returns the effective value and source of every key
+func configValues(bindings []config.Binding) []types.ConfigValue {
+	var values []types.ConfigValue
+	for _, s := range config.Settings {
+		v := types.ConfigValue{Key: s.Key, Value: viper.Get(s.Key), Source: config.Source(s.Key, nil), Env: config.EnvName(s.Key)}
+		switch {
+		case s.Key == "user" && cfg.User != "" && v.Source == config.SourceDefault:
+			v.Value = cfg.User
+		case s.Key == "codex_dirs":
+			var dirs []string
+			for _, dir := range cfg.CodexDirs {
+				dirs = append(dirs, dir.Label+"="+dir.Path)
+			}
+			v.Value = dirs
+		case s.Key == "pricing.rates":
+			var rates []string
+			for _, rate := range cfg.Pricing.Rates {
+				rates = append(rates, formatPriceRate(rate))
+			}
+			v.Value = rates
+		case s.Secret && fmt.Sprint(v.Value) != "" && v.Value != nil:
+			v.Value = "********"
+		}
+		values = append(values, v)
+	}
+	for _, b := range bindings {
+		v := types.ConfigValue{Key: b.Key, Source: config.Source(b.Key, b.Flag), Env: config.EnvName(b.Key)}
+		if slice, ok := b.Flag.Value.(pflag.SliceValue); ok {
+			v.Value = slice.GetSlice()
+		} else {
+			v.Value = b.Flag.Value.String()
+		}
+		values = append(values, v)
+	}
+	sort.SliceStable(values, func(i, j int) bool {
+		// Top-level keys first, then command sections
+		ti, tj := !strings.Contains(values[i].Key, "."), !strings.Contains(values[j].Key, ".")
+		if ti != tj {
+			return ti
+		}
+		return values[i].Key < values[j].Key
+	})
+	return values
+}
+
+// findConfigKey looks up a key among settings and flag bindings
+func findConfigKey(key string, bindings []config.Binding) (*config.Setting, *config.Binding, error) {
+	for i := range config.Settings {
+		if config.Settings[i].Key == key {
+			return &config.Settings[i], nil, nil
+		}
+	}
+	for i := range bindings {
+		if bindings[i].Key == key {
+			return nil, &bindings[i], nil
+		}
+	}
+	return nil, nil, fmt.Errorf("unknown config key: %s (run 'cxusage config show' to list all keys)", key)
+}
+
+func runConfigShow(cmd *cobra.Command, args []string) error {
+	outputFormat, _ := cmd.Flags().GetString("output")
+
+	values := configValues(configBindings(cmd.Root()))
+	if len(args) > 0 {
+		prefix := args[0]
+		var matching []types.ConfigValue
+		for _, v := range values {
+			if v.Key == prefix || strings.HasPrefix(v.Key, prefix+".") {
+				matching = append(matching, v)
+			}
+		}
+		if len(matching) == 0 {
+			return fmt.Errorf("no config keys match %s", prefix)
+		}
+		values = matching
+	}
+
+	switch types.OutputFormat(outputFormat) {
+	case types.OutputFormatJSON:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(values)
+	case types.OutputFormatTable:
+		utils.FormatConfigTable(viper.ConfigFileUsed(), values)
+		return nil
+	default:
+		return fmt.Errorf("unsupported output format: %s", outputFormat)
+	}
+}
+
+func runConfigInit(cmd *cobra.Command, args []string) error {
+	force, _ := cmd.Flags().GetBool("force")
+
+	path, err := config.ConfigPath()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err == nil && !force {
+		return fmt.Errorf("config file already exists: %s (use --force to overwrite)", path)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("could not create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(config.StarterConfig(configBindings(cmd.Root()))), 0644); err != nil {
+		return fmt.Errorf("could not write config file: %w", err)
+	}
+
+	fmt.Printf("✅ Wrote starter config to %s\n", path)
+	return nil
+}
+
+func runConfigGet(cmd *cobra.Command, args []string) error {
+	bindings := configBindings(cmd.Root())
+	if _, _, err := findConfigKey(args[0], bindings); err != nil {
+		return err
+	}
+	for _, v := range configValues(bindings) {
+		if v.Key == args[0] {
+			fmt.Println(utils.FormatConfigValue(v.Value))
+		}
+	}
+	return nil
+}
+
+func runConfigSet(cmd *cobra.Command, args []string) error {
+	key, raw := args[0], args[1:]
+	bindings := configBindings(cmd.Root())
+	setting, binding, err := findConfigKey(key, bindings)
+	if err != nil {
+		return err
+	}
+
+	var value interface{}
+	if binding != nil {
+		value, err = parseBindingValue(*binding, raw)
+	} else {
+		value, err = parseSettingValue(*setting, raw)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid value for %s: %w", key, err)
+	}
+
+	path, err := config.ConfigPath()
+	if err != nil {
+		return err
+	}
+	if err := config.SaveConfigValue(path, key, value); err != nil {
+		return err
+	}
+
+	shown := utils.FormatConfigValue(value)
+	if rates, ok := value.([]types.PriceRate); ok {
+		var parts []string
+		for _, rate := range rates {
+			parts = append(parts, formatPriceRate(rate))
+		}
+		shown = strings.Join(parts, ",")
+	}
+	fmt.Printf("✅ Set %s = %s in %s\n", key, shown, path)
+	if _, ok := os.LookupEnv(config.EnvName(key)); ok {
+		fmt.Printf("%s\n", utils.Yellow(fmt.Sprintf("Note: %s is set and takes precedence over the config file", config.EnvName(key))))
+	}
+	return nil
+}
+
+// splitConfigList splits list arguments given separately or comma-separated
+func splitConfigList(raw []string) []string {
+	var values []string
+	for _, arg := range raw {
+		for _, v := range strings.Split(arg, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+	}
+	return values
+}
+
+// parseBindingValue converts set arguments to the YAML value of a flag binding
+func parseBindingValue(b config.Binding, raw []string) (interface{}, error) {
+	if config.IsList(b.Flag) {
+		return splitConfigList(raw), nil
+	}
+	if len(raw) != 1 {
+		return nil, fmt.Errorf("expected a single value")
+	}
+	if err := config.CheckValue(b, raw[0]); err != nil {
+		return nil, err
+	}
+	switch b.Flag.Value.Type() {
+	case "bool":
+		return strconv.ParseBool(raw[0])
+	case "int":
+		return strconv.Atoi(raw[0])
+	case "float64":
+		return strconv.ParseFloat(raw[0], 64)
+	}
+	return raw[0], nil
+}
+
+// parseSettingValue converts set arguments to the YAML value of a config-only setting
+func parseSettingValue(s config.Setting, raw []string) (interface{}, error) {
+	switch s.Type {
+	case "sources":
+		var dirs []map[string]string
+		for _, arg := range splitConfigList(raw) {
+			source := codex.ParseCodexDirFlag(arg)
+			dirs = append(dirs, map[string]string{"label": source.Label, "path": source.Path})
+		}
+		return dirs, nil
+	case "list":
+		return splitConfigList(raw), nil
+	case "rates":
+		var rates []types.PriceRate
+		for _, arg := range raw {
+			rate, err := parsePriceRate(arg)
+			if err != nil {
+				return nil, err
+			}
+			rates = append(rates, rate)
+		}
+		return rates, nil
+	case "bool":
+		if len(raw) != 1 {
+			return nil, fmt.Errorf("expected true or false")
+		}
+		return strconv.ParseBool(raw[0])
+	case "mappings":
+		return nil, fmt.Errorf("mappings have too many fields to set here; edit the config file instead")
+	}
+	if len(raw) != 1 {
+		return nil, fmt.Errorf("expected a single value")
+	}
+	return raw[0], nil
+}
+
+// parsePriceRate parses a price given as provider[:model]=input/output, e.g. azure:gpt-5=1.25/10
+func parsePriceRate(arg string) (types.PriceRate, error) {
+	var rate types.PriceRate
+	i := strings.LastIndex(arg, "=")
+	if i <= 0 {
+		return rate, fmt.Errorf("expected provider[:model]=input/output, got %q", arg)
+	}
+	rate.Provider = arg[:i]
+	if j := strings.Index(rate.Provider, ":"); j >= 0 {
+		rate.Provider, rate.Model = rate.Provider[:j], rate.Provider[j+1:]
+	}
+
+	prices := strings.Split(arg[i+1:], "/")
+	if rate.Provider == "" || len(prices) != 2 {
+		return rate, fmt.Errorf("expected provider[:model]=input/output, got %q", arg)
+	}
+	var err error
+	if rate.Input, err = strconv.ParseFloat(prices[0], 64); err != nil || rate.Input < 0 {
+		return rate, fmt.Errorf("invalid input price in %q", arg)
+	}
+	if rate.Output, err = strconv.ParseFloat(prices[1], 64); err != nil || rate.Output < 0 {
+		return rate, fmt.Errorf("invalid output price in %q", arg)
+	}
+	return rate, nil
+}
+
+// formatPriceRate formats a price the way parsePriceRate reads it
+func formatPriceRate(rate types.PriceRate) string {
+	key := rate.Provider
+	if rate.Model != "" {
+		key += ":" + rate.Model
+	}
+	return fmt.Sprintf("%s=%g/%g", key, rate.Input, rate.Output)
+}
+
+func runConfigPath(cmd *cobra.Command, args []string) error {
+	path, err := config.ConfigPath()
+	if err != nil {
+		return err
+	}
+	fmt.Println(path)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		fmt.Fprintln(os.Stderr, "(does not exist yet; run 'cxusage config init' to create it)")
+	}
+	return nil
+}
+
+func runConfigValidate(cmd *cobra.Command, args []string) error {
+	bindings := configBindings(cmd.Root())
+	var problems []string
+
+	fmt.Println("🔍 Checking config file...")
+	if used := viper.ConfigFileUsed(); used == "" {
+		fmt.Println("✅ No config file found, using defaults")
+	} else {
+		fileProblems := config.ValidateFile(used, bindings)
+		for _, p := range fileProblems {
+			problems = append(problems, fmt.Sprintf("%s: %v", used, p))
+		}
+		if len(fileProblems) == 0 {
+			fmt.Printf("✅ %s is valid\n", used)
+		}
+	}
+
+	fmt.Println("🔍 Checking environment variables...")
+	envProblems := len(problems)
+	for _, name := range config.UnknownEnv(bindings) {
+		problems = append(problems, fmt.Sprintf("%s does not match any config key", name))
+	}
+	for _, b := range bindings {
+		value, ok := os.LookupEnv(config.EnvName(b.Key))
+		if !ok || config.IsList(b.Flag) {
+			continue
+		}
+		if err := config.CheckValue(b, value); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", config.EnvName(b.Key), err))
+		}
+	}
+	for _, env := range os.Environ() {
+		if strings.HasPrefix(env, "OPENAI_USAGE_") {
+			name := env[:strings.Index(env, "=")]
+			problems = append(problems, fmt.Sprintf("%s is no longer read; use %s instead", name, config.EnvPrefix+strings.TrimPrefix(name, "OPENAI_USAGE")))
+		}
+	}
+	if len(problems) == envProblems {
+		fmt.Println("✅ Environment variables are valid")
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	fmt.Println()
+	for _, p := range problems {
+		fmt.Printf("❌ %s\n", p)
+	}
+	return fmt.Errorf("found %d configuration problem(s)", len(problems))
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configShowCmd, configInitCmd, configGetCmd, configSetCmd, configPathCmd, configValidateCmd)
+
+	// Config-specific flags
+	configInitCmd.Flags().Bool("force", false, "Overwrite an existing config file")
+}
@@ -37,6 +37,9 @@ func runDaily(cmd *cobra.Command, args []string) error {
 	// Get flags
 	outputFormat, _ := cmd.Flags().GetString("output")
 	offline, _ := cmd.Flags().GetBool("offline")
+	byUser, _ := cmd.Flags().GetBool("by-user")
+	byAgent, _ := cmd.Flags().GetBool("by-agent")
+	byProvider, _ := cmd.Flags().GetBool("by-provider")
 	
 	// Calculate date range
 	endDate := time.Now()
@@ -53,7 +56,7 @@ func runDaily(cmd *cobra.Command, args []string) error {
 	var err error
 
 	// Load from Codex CLI local files (no API needed)
-	dailyUsage, err = utils.LoadDailyUsageFromCodex(cfg, startDate, endDate, logger)
+	dailyUsage, err = utils.LoadDailyUsageFromCodex(cfg, startDate, endDate, logger, reportSplit(byUser, byAgent, byProvider)...)
 
 	if err != nil {
 		return fmt.Errorf("failed to load daily usage data: %w", err)
@@ -85,6 +88,21 @@ func runDaily(cmd *cobra.Command, args []string) error {
 	}
 }
 
+// reportSplit returns the dimensions daily and monthly rows are split by
+func reportSplit(byUser, byAgent, byProvider bool) []types.Dimension {
+	var split []types.Dimension
+	if byUser {
+		split = append(split, types.DimensionUser)
+	}
+	if byAgent {
+		split = append(split, types.DimensionAgent)
+	}
+	if byProvider {
+		split = append(split, types.DimensionProvider)
+	}
+	return split
+}
+
 func outputDailyJSON(dailyUsage []types.DailyUsage) error {
 	encoder := json.NewEncoder(os.Stdout)
 	encoder.SetIndent("", "  ")
@@ -103,4 +121,7 @@ func init() {
 	dailyCmd.Flags().String("start-date", "", "Start date (YYYY-MM-DD)")
 	dailyCmd.Flags().String("end-date", "", "End date (YYYY-MM-DD)")
 	dailyCmd.Flags().StringSlice("models", []string{}, "Filter by specific models")
+	dailyCmd.Flags().Bool("by-user", false, "Group usage by user (with --bundle or --team)")
+	dailyCmd.Flags().Bool("by-provider", false, "Group usage by model provider (openai, azure, ollama, ...)")
+	dailyCmd.Flags().Bool("by-agent", false, "Group usage by coding agent (codex, claude)")
 }
\ No newline at end of file
@@ -0,0 +1,87 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/johanneserhardt/cxusage/internal/codex"
+	"github.com/johanneserhardt/cxusage/internal/types"
+	"github.com/johanneserhardt/cxusage/internal/utils"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose problems parsing the Codex CLI logs",
+	Long: `Scan every Codex CLI log file and report anything that could make reports wrong:
+lines that are not valid JSON, record types cxusage does not know, lines too long for
+the parser, models without pricing, sessions without metadata, timestamps in the future
+or out of order, and different entries sharing a de-duplication key.
+Exits with an error when errors are found (or any issue with --strict), so it can run
+after Codex CLI upgrades. Use -o json to attach the full report to a bug report.`,
+	Example: `  cxusage doctor
+  cxusage doctor --limit 20
+  cxusage doctor --strict -o json > doctor.json`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runDoctor,
+	// Execute prints the error once
+	SilenceErrors: true,
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	outputFormat, _ := cmd.Flags().GetString("output")
+	limit, _ := cmd.Flags().GetInt("limit")
+	strict, _ := cmd.Flags().GetBool("strict")
+	if limit < 0 {
+		return fmt.Errorf("limit must not be negative")
+	}
+
+	logger.Info("Diagnosing Codex CLI log files")
+
+	report, err := codex.Diagnose(cfg, time.Now(), logger)
+	if err != nil {
+		return fmt.Errorf("failed to scan Codex logs: %w", err)
+	}
+	report.Version = Version
+
+	if report.Files == 0 && outputFormat != "json" {
+		fmt.Printf("%s\n", utils.Yellow("No Codex CLI log files found"))
+		fmt.Println()
+		fmt.Printf("Try:\n")
+		fmt.Printf("• %s - Check if Codex CLI is set up\n", utils.Cyan("cxusage validate"))
+		return nil
+	}
+
+	// Output results
+	switch types.OutputFormat(outputFormat) {
+	case types.OutputFormatJSON:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return err
+		}
+	case types.OutputFormatTable:
+		utils.FormatDoctorReport(report, limit)
+	default:
+		return fmt.Errorf("unsupported output format: %s", outputFormat)
+	}
+
+	if errors := report.Errors(); errors > 0 {
+		return fmt.Errorf("found %d error(s) in the Codex logs", errors)
+	}
+	if strict && len(report.Issues) > 0 {
+		return fmt.Errorf("found %d issue(s) in the Codex logs", len(report.Issues))
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+
+	// Doctor-specific flags
+	doctorCmd.Flags().Int("limit", 5, "Occurrences to show per check in table output")
+	doctorCmd.Flags().Bool("strict", false, "Exit with an error on warnings too")
+}
@@ -0,0 +1,133 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/johanneserhardt/cxusage/internal/codex"
+	"github.com/johanneserhardt/cxusage/internal/types"
+	"github.com/johanneserhardt/cxusage/internal/utils"
+)
+
+var entriesCmd = &cobra.Command{
+	Use:   "entries [days]",
+	Short: "List the individual usage entries behind the reports",
+	Long: `List per-turn usage entries with timestamp, session, model, project, token
+breakdown, cost, whether the tokens were estimated, and the log file and line each
+entry was parsed from. Use --output ndjson to stream one JSON object per line into
+jq or other tools.
+By default lists the last day; use --date to list a single calendar day.`,
+	Example: `  cxusage entries
+  cxusage entries --date 2026-10-18
+  cxusage entries 7 -o ndjson | jq 'select(.estimated)'`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runEntries,
+}
+
+func runEntries(cmd *cobra.Command, args []string) error {
+	// Get flags
+	outputFormat, _ := cmd.Flags().GetString("output")
+	dateStr, _ := cmd.Flags().GetString("date")
+
+	switch types.OutputFormat(outputFormat) {
+	case types.OutputFormatTable, types.OutputFormatJSON, types.OutputFormatNDJSON:
+	default:
+		return fmt.Errorf("unsupported output format: %s (expected table, json or ndjson)", outputFormat)
+	}
+
+	startDate, endDate, err := entriesDateRange(args, dateStr, time.Now())
+	if err != nil {
+		return err
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"start_date": startDate.Format("2006-01-02"),
+		"end_date":   endDate.Format("2006-01-02"),
+	}).Info("Listing usage entries")
+
+	entries, err := codex.ParseUsageFiles(cfg, startDate, endDate, logger)
+	if err != nil {
+		return fmt.Errorf("failed to load usage data: %w", err)
+	}
+	sortEntries(entries)
+
+	if types.OutputFormat(outputFormat) != types.OutputFormatTable {
+		return writeEntries(os.Stdout, entries, types.OutputFormat(outputFormat))
+	}
+
+	if len(entries) == 0 {
+		fmt.Printf("%s\n", utils.Yellow("No Codex CLI usage data found"))
+		fmt.Println()
+		fmt.Printf("Try:\n")
+		fmt.Printf("• %s - Check if Codex CLI is set up\n", utils.Cyan("cxusage validate"))
+		fmt.Printf("• %s - Look further back\n", utils.Cyan("cxusage entries 7"))
+		return nil
+	}
+
+	utils.FormatEntriesTable(entries)
+	return nil
+}
+
+// entriesDateRange returns the range of the optional days argument (1 by default), or of
+// a single local calendar day, the same day as the daily report, when a date is given
+func entriesDateRange(args []string, dateStr string, now time.Time) (time.Time, time.Time, error) {
+	days := 1 // default
+	if len(args) > 0 {
+		var err error
+		days, err = strconv.Atoi(args[0])
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid number of days: %s", args[0])
+		}
+		if days < 1 || days > 365 {
+			return time.Time{}, time.Time{}, fmt.Errorf("days must be between 1 and 365")
+		}
+	}
+
+	if dateStr != "" {
+		day, err := time.ParseInLocation("2006-01-02", dateStr, time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid date: %s (expected YYYY-MM-DD)", dateStr)
+		}
+		return day, day.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+	}
+	return now.AddDate(0, 0, -days), now, nil
+}
+
+// sortEntries orders entries by time, keeping the log order of entries of the same time
+func sortEntries(entries []types.CodexUsageEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Timestamp.Before(entries[j].Timestamp)
+	})
+}
+
+// writeEntries writes entries as an indented JSON array or as one JSON object per line
+func writeEntries(w io.Writer, entries []types.CodexUsageEntry, format types.OutputFormat) error {
+	encoder := json.NewEncoder(w)
+	if format == types.OutputFormatNDJSON {
+		for _, entry := range entries {
+			if err := encoder.Encode(entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if entries == nil {
+		entries = []types.CodexUsageEntry{}
+	}
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(entries)
+}
+
+func init() {
+	rootCmd.AddCommand(entriesCmd)
+
+	// Entries-specific flags
+	entriesCmd.Flags().String("date", "", "List a single day (YYYY-MM-DD, local time)")
+}
@@ -0,0 +1,136 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/johanneserhardt/cxusage/internal/types"
+)
+
+func TestEntriesDateRange(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name      string
+		args      []string
+		date      string
+		wantStart time.Time
+		wantEnd   time.Time
+		wantErr   string
+	}{
+		{name: "default day", wantStart: now.AddDate(0, 0, -1), wantEnd: now},
+		{name: "days", args: []string{"7"}, wantStart: now.AddDate(0, 0, -7), wantEnd: now},
+		{name: "maximum", args: []string{"365"}, wantStart: now.AddDate(0, 0, -365), wantEnd: now},
+		{name: "zero days", args: []string{"0"}, wantErr: "between 1 and 365"},
+		{name: "too many days", args: []string{"366"}, wantErr: "between 1 and 365"},
+		{name: "not a number", args: []string{"week"}, wantErr: "invalid number of days"},
+		{name: "date", date: "2026-10-01",
+			wantStart: time.Date(2026, 10, 1, 0, 0, 0, 0, time.Local),
+			wantEnd:   time.Date(2026, 10, 2, 0, 0, 0, 0, time.Local).Add(-time.Nanosecond)},
+		{name: "invalid date", date: "01.10.2026", wantErr: "invalid date"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, err := entriesDateRange(tt.args, tt.date, now)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !start.Equal(tt.wantStart) || !end.Equal(tt.wantEnd) {
+				t.Errorf("got %s - %s, want %s - %s", start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestSortEntries(t *testing.T) {
+	ts := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	entries := []types.CodexUsageEntry{
+		{Timestamp: ts.Add(time.Minute), RequestID: "c"},
+		{Timestamp: ts, RequestID: "a"},
+		{Timestamp: ts.Add(time.Minute), RequestID: "d"},
+		{Timestamp: ts, RequestID: "b"},
+	}
+
+	sortEntries(entries)
+	var got []string
+	for _, entry := range entries {
+		got = append(got, entry.RequestID)
+	}
+	if strings.Join(got, "") != "abcd" {
+		t.Errorf("expected entries by time in log order, got %v", got)
+	}
+}
+
+func TestWriteEntries(t *testing.T) {
+	ts := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	entries := []types.CodexUsageEntry{
+		{Timestamp: ts, SessionID: "s1", RequestID: "r1", Model: "gpt-5", Provenance: types.ProvenanceExact,
+			Usage: types.Usage{PromptTokens: 100, CompletionTokens: 20, TotalTokens: 120}, File: "/logs/a.jsonl", Line: 3},
+		{Timestamp: ts.Add(time.Minute), SessionID: "s1", RequestID: "r2", Model: "gpt-5", Provenance: types.ProvenanceEstimatedTokens, Estimated: true},
+	}
+
+	tests := []struct {
+		name    string
+		entries []types.CodexUsageEntry
+		format  types.OutputFormat
+		check   func(t *testing.T, out string)
+	}{
+		{name: "json array", entries: entries, format: types.OutputFormatJSON, check: func(t *testing.T, out string) {
+			var decoded []map[string]interface{}
+			if err := json.Unmarshal([]byte(out), &decoded); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			if len(decoded) != 2 || decoded[0]["request_id"] != "r1" || decoded[0]["file"] != "/logs/a.jsonl" || decoded[0]["line"] != float64(3) {
+				t.Errorf("unexpected entries %v", decoded)
+			}
+			if decoded[1]["provenance"] != "estimated_tokens" || decoded[1]["estimated"] != true {
+				t.Errorf("expected the estimated entry to be marked, got %v", decoded[1])
+			}
+		}},
+		{name: "json without entries", entries: nil, format: types.OutputFormatJSON, check: func(t *testing.T, out string) {
+			if strings.TrimSpace(out) != "[]" {
+				t.Errorf("expected an empty array, got %q", out)
+			}
+		}},
+		{name: "ndjson", entries: entries, format: types.OutputFormatNDJSON, check: func(t *testing.T, out string) {
+			lines := strings.Split(strings.TrimSpace(out), "\n")
+			if len(lines) != 2 {
+				t.Fatalf("expected one line per entry, got %q", out)
+			}
+			for i, line := range lines {
+				var decoded types.CodexUsageEntry
+				if err := json.Unmarshal([]byte(line), &decoded); err != nil {
+					t.Fatalf("line %d is not a JSON object: %v", i+1, err)
+				}
+				if decoded.RequestID != entries[i].RequestID {
+					t.Errorf("line %d: got request %q", i+1, decoded.RequestID)
+				}
+			}
+		}},
+		{name: "ndjson without entries", entries: nil, format: types.OutputFormatNDJSON, check: func(t *testing.T, out string) {
+			if out != "" {
+				t.Errorf("expected no output, got %q", out)
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeEntries(&buf, tt.entries, tt.format); err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, buf.String())
+		})
+	}
+}
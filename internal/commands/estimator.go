@@ -0,0 +1,145 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/johanneserhardt/cxusage/internal/codex"
+	"github.com/johanneserhardt/cxusage/internal/config"
+	"github.com/johanneserhardt/cxusage/internal/types"
+	"github.com/johanneserhardt/cxusage/internal/utils"
+)
+
+var estimatorCmd = &cobra.Command{
+	Use:   "estimator",
+	Short: "Calibrate the token estimates of logs without usage",
+	Long: `Older Codex CLI logs only contain message text, so their usage is estimated.
+Sessions of recent Codex CLI versions have both the message text and the exact usage
+of every model request; the estimator can be calibrated on them, fitting per-model
+parameters that are then used for every estimate.`,
+	Example: `  cxusage estimator calibrate
+  cxusage estimator calibrate 90 --dry-run
+  cxusage estimator report -o json`,
+}
+
+var estimatorCalibrateCmd = &cobra.Command{
+	Use:   "calibrate [days]",
+	Short: "Fit estimator parameters to sessions with exact usage",
+	Long: `Fit the estimator parameters of each model to the turns of the last days' sessions
+that have both message text and logged token counts, and store them for later
+estimates. Models with fewer than 10 turns use parameters fitted from all models.
+By default looks at the last 30 days.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runEstimatorCalibrate,
+}
+
+var estimatorReportCmd = &cobra.Command{
+	Use:   "report [days]",
+	Short: "Show the error of estimates against exact usage",
+	Long: `Estimate the turns of the last days' sessions that have exact usage and compare the
+estimates with it, using the built-in and the stored calibrated parameters.
+By default looks at the last 30 days.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runEstimatorReport,
+}
+
+func runEstimatorCalibrate(cmd *cobra.Command, args []string) error {
+	startDate, endDate, err := estimatorDates(args)
+	if err != nil {
+		return err
+	}
+	outputFormat, _ := cmd.Flags().GetString("output")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	path, err := config.GetCalibrationPath()
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Calibrating the token estimator")
+
+	cal, report, err := codex.CalibrateEstimator(cfg, startDate, endDate, time.Now(), logger)
+	if err != nil {
+		return fmt.Errorf("failed to calibrate the estimator: %w", err)
+	}
+	report.Path = path
+
+	// Without enough turns the built-in parameters stay in use
+	if cal.Default != nil && !dryRun {
+		if err := codex.SaveCalibration(path, cal); err != nil {
+			return err
+		}
+		report.Saved = true
+	}
+	return printEstimatorReport(report, outputFormat)
+}
+
+func runEstimatorReport(cmd *cobra.Command, args []string) error {
+	startDate, endDate, err := estimatorDates(args)
+	if err != nil {
+		return err
+	}
+	outputFormat, _ := cmd.Flags().GetString("output")
+
+	path, err := config.GetCalibrationPath()
+	if err != nil {
+		return err
+	}
+	cal, err := codex.LoadCalibration(path)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Evaluating the token estimator")
+
+	report, err := codex.EstimatorReport(cfg, cal, startDate, endDate, time.Now(), logger)
+	if err != nil {
+		return fmt.Errorf("failed to evaluate the estimator: %w", err)
+	}
+	report.Path = path
+	report.Saved = cal != nil
+	return printEstimatorReport(report, outputFormat)
+}
+
+// estimatorDates returns the date range of the optional days argument
+func estimatorDates(args []string) (time.Time, time.Time, error) {
+	days := 30 // default
+	if len(args) > 0 {
+		var err error
+		days, err = strconv.Atoi(args[0])
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid number of days: %s", args[0])
+		}
+		if days < 1 || days > 365 {
+			return time.Time{}, time.Time{}, fmt.Errorf("days must be between 1 and 365")
+		}
+	}
+	endDate := time.Now()
+	return endDate.AddDate(0, 0, -days), endDate, nil
+}
+
+func printEstimatorReport(report *types.CalibrationReport, outputFormat string) error {
+	switch types.OutputFormat(outputFormat) {
+	case types.OutputFormatJSON:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case types.OutputFormatTable:
+		utils.FormatCalibrationReport(report, codex.MinCalibrationSamples)
+		return nil
+	default:
+		return fmt.Errorf("unsupported output format: %s", outputFormat)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(estimatorCmd)
+	estimatorCmd.AddCommand(estimatorCalibrateCmd, estimatorReportCmd)
+
+	// Calibrate-specific flags
+	estimatorCalibrateCmd.Flags().Bool("dry-run", false, "Show the fitted parameters without storing them")
+}
@@ -0,0 +1,216 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/johanneserhardt/cxusage/internal/blocks"
+	"github.com/johanneserhardt/cxusage/internal/codex"
+	"github.com/johanneserhardt/cxusage/internal/otlp"
+	"github.com/johanneserhardt/cxusage/internal/store"
+	"github.com/johanneserhardt/cxusage/internal/types"
+	"github.com/johanneserhardt/cxusage/internal/utils"
+)
+
+// Watch mode re-reads recent logs, and closes a session's trace once it has been idle
+const (
+	otlpWatchLookback = time.Hour
+	otlpSessionIdle   = 30 * time.Minute
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export [days]",
+	Short: "Export usage into a database or an OpenTelemetry collector",
+	Long: `Export usage for your own queries and dashboards.
+
+--format sqlite writes entries, sessions, blocks and model pricing into a normalized,
+indexed SQLite database that can be queried with any SQLite client. Reports can read
+the database instead of rescanning the logs with --db.
+
+--format otlp sends per-turn token, cost and turn metrics plus one trace per Codex
+session (with a span per turn) to an OpenTelemetry collector over OTLP/HTTP. With
+--watch it keeps running after the backfill and exports new turns as they happen.
+
+By default exports the last 30 days.`,
+	Example: `  cxusage export
+  cxusage export 90 --file usage.db
+  cxusage daily --db usage.db
+  cxusage export 7 --format otlp --endpoint http://localhost:4318
+  cxusage export 1 --format otlp --watch`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runExport,
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	days := 30 // default
+	if len(args) > 0 {
+		var err error
+		days, err = strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid number of days: %s", args[0])
+		}
+		if days < 1 || days > 365 {
+			return fmt.Errorf("days must be between 1 and 365")
+		}
+	}
+
+	// Get flags
+	format, _ := cmd.Flags().GetString("format")
+	path, _ := cmd.Flags().GetString("file")
+	sessionHours, _ := cmd.Flags().GetInt("session-duration")
+	blockModeStr, _ := cmd.Flags().GetString("block-mode")
+
+	if sessionHours < 1 || sessionHours > 24 {
+		return fmt.Errorf("session duration must be between 1 and 24 hours")
+	}
+	blockMode, err := blocks.ParseBlockMode(blockModeStr)
+	if err != nil {
+		return err
+	}
+	switch types.ExportFormat(format) {
+	case types.ExportFormatSQLite, types.ExportFormatOTLP:
+	default:
+		return fmt.Errorf("unsupported export format: %s (expected sqlite or otlp)", format)
+	}
+	if watch, _ := cmd.Flags().GetBool("watch"); watch && types.ExportFormat(format) != types.ExportFormatOTLP {
+		return fmt.Errorf("--watch is only supported with --format otlp")
+	}
+
+	endDate := time.Now()
+	startDate := endDate.AddDate(0, 0, -days)
+
+	logger.WithFields(map[string]interface{}{
+		"start_date": startDate.Format("2006-01-02"),
+		"end_date":   endDate.Format("2006-01-02"),
+		"format":     format,
+	}).Info("Exporting usage")
+
+	entries, err := codex.ParseUsageFiles(cfg, startDate, endDate, logger)
+	if err != nil {
+		return fmt.Errorf("failed to load usage data: %w", err)
+	}
+
+	switch types.ExportFormat(format) {
+	case types.ExportFormatSQLite:
+		if path == "" {
+			path = "cxusage.db"
+		}
+		data := &store.Dataset{
+			Entries:   entries,
+			Blocks:    blocks.AggregateIntoBlocksByUser(entries, sessionHours, blockMode),
+			Pricing:   codex.LoadPriceTable(cfg).Rates(),
+			StartDate: startDate,
+			EndDate:   endDate,
+		}
+		if err := store.Export(path, data); err != nil {
+			return err
+		}
+	case types.ExportFormatOTLP:
+		return runExportOTLP(cmd, entries, startDate, endDate)
+	}
+
+	fmt.Printf("✅ Exported %s entries (%s to %s) to %s\n",
+		utils.FormatNumber(len(entries)),
+		startDate.Format("2006-01-02"),
+		endDate.Format("2006-01-02"),
+		path)
+	return nil
+}
+
+// runExportOTLP backfills entries to an OpenTelemetry collector and optionally keeps
+// exporting new turns
+func runExportOTLP(cmd *cobra.Command, entries []types.CodexUsageEntry, startDate, endDate time.Time) error {
+	endpoint, _ := cmd.Flags().GetString("endpoint")
+	headerFlags, _ := cmd.Flags().GetStringArray("header")
+	watch, _ := cmd.Flags().GetBool("watch")
+	interval, _ := cmd.Flags().GetInt("interval")
+
+	if interval < 1 || interval > 3600 {
+		return fmt.Errorf("interval must be between 1 and 3600 seconds")
+	}
+	headers := make(map[string]string)
+	for _, h := range headerFlags {
+		for k, v := range otlp.ParseHeaders(h) {
+			headers[k] = v
+		}
+	}
+
+	exporter := otlp.NewExporter(otlp.Config{Endpoint: endpoint, Headers: headers, ServiceVersion: Version})
+	stream := otlp.NewStream(exporter, otlpSessionIdle)
+	ctx := context.Background()
+
+	stats, err := stream.Push(ctx, entries, endDate)
+	if err != nil {
+		return err
+	}
+	if !watch {
+		flushed, err := stream.Flush(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✅ Exported %s turns in %s sessions (%s to %s) to %s\n",
+			utils.FormatNumber(stats.Entries),
+			utils.FormatNumber(stats.Sessions+flushed.Sessions),
+			startDate.Format("2006-01-02"),
+			endDate.Format("2006-01-02"),
+			exporter.Endpoint())
+		return nil
+	}
+
+	fmt.Printf("✅ Backfilled %s turns to %s\n", utils.FormatNumber(stats.Entries), exporter.Endpoint())
+	fmt.Printf("Watching for new turns every %ds (Ctrl+C to stop)...\n", interval)
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	ticker := time.NewTicker(time.Duration(interval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-sigChan:
+			// Close the traces of sessions that are still open
+			if _, err := stream.Flush(ctx); err != nil {
+				return err
+			}
+			return nil
+		case <-ticker.C:
+			now := time.Now()
+			since := now.Add(-otlpWatchLookback)
+			entries, err := codex.ParseUsageFiles(cfg, since, now, logger)
+			if err != nil {
+				logger.WithError(err).Error("Failed to load usage data")
+				continue
+			}
+			stats, err := stream.Push(ctx, entries, now)
+			if err != nil {
+				// Unsent turns are retried on the next tick
+				logger.WithError(err).Error("Failed to export to OTLP collector")
+				continue
+			}
+			stream.Forget(since)
+			if stats.Entries > 0 {
+				logger.WithField("turns", stats.Entries).Info("Exported new turns")
+			}
+		}
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+
+	// Export-specific flags
+	exportCmd.Flags().String("format", string(types.ExportFormatSQLite), "Export format (sqlite, otlp)")
+	exportCmd.Flags().String("file", "", "File to write (default: cxusage.db)")
+	exportCmd.Flags().String("endpoint", "", "OTLP/HTTP collector endpoint (default: $OTEL_EXPORTER_OTLP_ENDPOINT or http://localhost:4318)")
+	exportCmd.Flags().StringArray("header", nil, "Extra OTLP request header as key=value (repeatable)")
+	exportCmd.Flags().Bool("watch", false, "Keep exporting new turns after the backfill (otlp only)")
+	exportCmd.Flags().Int("interval", 30, "Seconds between checks for new turns in watch mode")
+	exportCmd.Flags().Int("session-duration", 5, "Block duration in hours (default: 5)")
+	exportCmd.Flags().String("block-mode", string(types.BlockModeFixed), "Block detection mode: fixed (clock-aligned slots) or rolling (starts at first activity)")
+}
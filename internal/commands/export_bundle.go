@@ -0,0 +1,99 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/johanneserhardt/cxusage/internal/bundle"
+	"github.com/johanneserhardt/cxusage/internal/codex"
+	"github.com/johanneserhardt/cxusage/internal/types"
+	"github.com/johanneserhardt/cxusage/internal/utils"
+)
+
+var exportBundleCmd = &cobra.Command{
+	Use:   "export-bundle [days]",
+	Short: "Export your usage as a portable bundle for team reports",
+	Long: `Write a portable, versioned bundle file with your per-entry usage (timestamps,
+models, token counts and costs; never prompt text), tagged with your user identity.
+Project paths, repository URLs and session ids are hashed unless --redact=none is given;
+set the same redact_salt on every machine so teammates' hashes match.
+Teammates can merge bundles with import-bundle or --bundle to build team reports.
+By default exports the last 30 days.`,
+	Example: `  cxusage export-bundle
+  cxusage export-bundle 90 --user alice --file alice.json.gz`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runExportBundle,
+}
+
+func runExportBundle(cmd *cobra.Command, args []string) error {
+	days := 30 // default
+	if len(args) > 0 {
+		var err error
+		days, err = strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid number of days: %s", args[0])
+		}
+		if days < 1 || days > 365 {
+			return fmt.Errorf("days must be between 1 and 365")
+		}
+	}
+
+	// Get flags
+	user, _ := cmd.Flags().GetString("user")
+	path, _ := cmd.Flags().GetString("file")
+
+	if user != "" {
+		cfg.User = user
+	}
+	if path == "" {
+		path = fmt.Sprintf("cxusage-bundle-%s-%s.json", cfg.User, time.Now().Format("20060102"))
+	}
+
+	// Only export local usage, never bundles merged from others
+	cfg.Bundles = nil
+
+	// Bundles leave the machine, so they are redacted unless explicitly disabled
+	if cfg.Redact == "" {
+		cfg.Redact = types.RedactModeHash
+	}
+	// Teammates' hashes only match, and merged bundles only de-duplicate, with a shared salt
+	if cfg.Redact == types.RedactModeHash && cfg.RedactSalt == "" {
+		logger.Warn("Hashing with this machine's random salt; set a redact_salt shared by the team so merged bundles can be correlated and de-duplicated")
+	}
+
+	endDate := time.Now()
+	startDate := endDate.AddDate(0, 0, -days)
+
+	logger.WithFields(map[string]interface{}{
+		"start_date": startDate.Format("2006-01-02"),
+		"end_date":   endDate.Format("2006-01-02"),
+		"user":       cfg.User,
+	}).Info("Exporting usage bundle")
+
+	entries, err := codex.ParseUsageFiles(cfg, startDate, endDate, logger)
+	if err != nil {
+		return fmt.Errorf("failed to load usage data: %w", err)
+	}
+
+	if err := bundle.Write(path, bundle.New(cfg.User, entries, startDate, endDate)); err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Exported %s entries for %s (%s to %s) to %s\n",
+		utils.FormatNumber(len(entries)),
+		utils.Cyan(cfg.User),
+		startDate.Format("2006-01-02"),
+		endDate.Format("2006-01-02"),
+		path)
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(exportBundleCmd)
+
+	// Export-specific flags
+	exportBundleCmd.Flags().String("user", "", "User identity to tag the usage with (default: config user or OS user)")
+	exportBundleCmd.Flags().String("file", "", "Bundle file to write, gzip-compressed if it ends in .gz (default: cxusage-bundle-<user>-<date>.json)")
+}
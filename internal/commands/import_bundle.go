@@ -0,0 +1,52 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/johanneserhardt/cxusage/internal/bundle"
+	"github.com/johanneserhardt/cxusage/internal/config"
+	"github.com/johanneserhardt/cxusage/internal/utils"
+)
+
+var importBundleCmd = &cobra.Command{
+	Use:   "import-bundle <file>...",
+	Short: "Import teammates' usage bundles for team reports",
+	Long: `Validate usage bundles written by export-bundle and store them locally.
+Imported bundles are merged into daily, monthly and blocks reports with --team;
+add --by-user to group the result per person. Entries that appear in several
+bundles are only counted once.`,
+	Example: `  cxusage import-bundle alice.json bob.json.gz
+  cxusage daily 30 --team --by-user`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runImportBundle,
+}
+
+func runImportBundle(cmd *cobra.Command, args []string) error {
+	storeDir, err := config.GetBundlesDir()
+	if err != nil {
+		return err
+	}
+
+	for _, path := range args {
+		dest, b, err := bundle.Import(path, storeDir)
+		if err != nil {
+			return fmt.Errorf("failed to import %s: %w", path, err)
+		}
+
+		fmt.Printf("✅ Imported %s entries for %s (%s to %s)\n",
+			utils.FormatNumber(len(b.Entries)),
+			utils.Cyan(b.User),
+			b.StartDate.Local().Format("2006-01-02"),
+			b.EndDate.Local().Format("2006-01-02"))
+		logger.WithField("path", dest).Debug("Stored usage bundle")
+	}
+
+	fmt.Println()
+	fmt.Printf("Run %s to see the team report\n", utils.Cyan("cxusage daily --team --by-user"))
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(importBundleCmd)
+}
@@ -0,0 +1,109 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/johanneserhardt/cxusage/internal/codex"
+	"github.com/johanneserhardt/cxusage/internal/types"
+	"github.com/johanneserhardt/cxusage/internal/utils"
+)
+
+var limitsCmd = &cobra.Command{
+	Use:   "limits [days]",
+	Short: "Show plan rate-limit usage and history",
+	Long: `Display the plan rate-limit usage reported by Codex CLI in token_count events.
+Shows the current primary (5-hour) and secondary (weekly) window usage with reset
+countdowns, followed by the peak usage of each primary window over the last days.
+By default looks at the last 7 days.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runLimits,
+}
+
+// limitsReport is the JSON representation of the limits command
+type limitsReport struct {
+	Latest         *types.RateLimitSnapshot            `json:"latest"`
+	LatestBySource map[string]*types.RateLimitSnapshot `json:"latest_by_source,omitempty"`
+	Windows        []types.RateLimitWindowUsage        `json:"windows"`
+	Snapshots      []types.RateLimitSnapshot           `json:"snapshots,omitempty"`
+}
+
+func runLimits(cmd *cobra.Command, args []string) error {
+	days := 7 // default
+	if len(args) > 0 {
+		var err error
+		days, err = strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid number of days: %s", args[0])
+		}
+		if days < 1 || days > 365 {
+			return fmt.Errorf("days must be between 1 and 365")
+		}
+	}
+
+	// Get flags
+	outputFormat, _ := cmd.Flags().GetString("output")
+	showAll, _ := cmd.Flags().GetBool("all")
+
+	// Calculate date range
+	endDate := time.Now()
+	startDate := endDate.AddDate(0, 0, -days)
+
+	logger.WithFields(map[string]interface{}{
+		"start_date": startDate.Format("2006-01-02"),
+		"end_date":   endDate.Format("2006-01-02"),
+		"days":       days,
+	}).Info("Generating rate-limit report")
+
+	snapshots, err := codex.ParseRateLimitFiles(cfg, startDate, endDate, logger)
+	if err != nil {
+		return fmt.Errorf("failed to load rate-limit data: %w", err)
+	}
+
+	report := limitsReport{
+		Latest:  codex.LatestRateLimitSnapshot(snapshots),
+		Windows: utils.AggregateRateLimitWindows(snapshots),
+	}
+	if showAll {
+		report.Snapshots = snapshots
+	}
+
+	// Each Codex directory may belong to a different account with its own limits
+	if bySource := codex.LatestRateLimitSnapshotsBySource(snapshots); len(bySource) > 1 {
+		report.LatestBySource = bySource
+	}
+
+	if len(snapshots) == 0 && outputFormat != "json" {
+		fmt.Printf("%s\n", utils.Yellow("No Codex CLI rate-limit data found"))
+		fmt.Println()
+		fmt.Printf("Rate limits are only recorded by recent Codex CLI versions.\n")
+		fmt.Printf("Try:\n")
+		fmt.Printf("• Update Codex CLI and use it, then run %s\n", utils.Cyan("cxusage limits"))
+		fmt.Printf("• %s - Check if Codex CLI is set up\n", utils.Cyan("cxusage validate"))
+		return nil
+	}
+
+	// Output results
+	switch types.OutputFormat(outputFormat) {
+	case types.OutputFormatJSON:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case types.OutputFormatTable:
+		utils.FormatRateLimitsTableProper(report.Latest, report.LatestBySource, report.Windows)
+		return nil
+	default:
+		return fmt.Errorf("unsupported output format: %s", outputFormat)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(limitsCmd)
+
+	// Limits-specific flags
+	limitsCmd.Flags().Bool("all", false, "Include every raw snapshot in JSON output")
+}
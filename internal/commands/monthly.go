@@ -37,6 +37,9 @@ func runMonthly(cmd *cobra.Command, args []string) error {
 	// Get flags
 	outputFormat, _ := cmd.Flags().GetString("output")
 	offline, _ := cmd.Flags().GetBool("offline")
+	byUser, _ := cmd.Flags().GetBool("by-user")
+	byAgent, _ := cmd.Flags().GetBool("by-agent")
+	byProvider, _ := cmd.Flags().GetBool("by-provider")
 	
 	// Calculate date range
 	endDate := time.Now()
@@ -53,7 +56,7 @@ func runMonthly(cmd *cobra.Command, args []string) error {
 	var err error
 
 	// Load from Codex CLI local files (no API needed)
-	monthlyUsage, err = utils.LoadMonthlyUsageFromCodex(cfg, startDate, endDate, logger)
+	monthlyUsage, err = utils.LoadMonthlyUsageFromCodex(cfg, startDate, endDate, logger, reportSplit(byUser, byAgent, byProvider)...)
 
 	if err != nil {
 		return fmt.Errorf("failed to load monthly usage data: %w", err)
@@ -88,4 +91,7 @@ func init() {
 	monthlyCmd.Flags().String("start-month", "", "Start month (YYYY-MM)")
 	monthlyCmd.Flags().String("end-month", "", "End month (YYYY-MM)")
 	monthlyCmd.Flags().StringSlice("models", []string{}, "Filter by specific models")
+	monthlyCmd.Flags().Bool("by-user", false, "Group usage by user (with --bundle or --team)")
+	monthlyCmd.Flags().Bool("by-provider", false, "Group usage by model provider (openai, azure, ollama, ...)")
+	monthlyCmd.Flags().Bool("by-agent", false, "Group usage by coding agent (codex, claude)")
 }
\ No newline at end of file
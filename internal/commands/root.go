@@ -6,7 +6,9 @@ import (
 
     "github.com/sirupsen/logrus"
     "github.com/spf13/cobra"
+    "github.com/johanneserhardt/cxusage/internal/codex"
     "github.com/johanneserhardt/cxusage/internal/config"
+    "github.com/johanneserhardt/cxusage/internal/filter"
     "github.com/johanneserhardt/cxusage/internal/types"
     "github.com/johanneserhardt/cxusage/internal/utils"
 )
@@ -40,9 +42,10 @@ real-time live monitoring, similar to ccusage for Claude Code.
 		// Initialize logger
 		logger = logrus.New()
 		
-		// Load configuration
+		// Load configuration; flags the user did not set take their value from the
+		// environment (CXUSAGE_*) or the config file
 		var err error
-		cfg, err = config.LoadConfig()
+		cfg, err = config.LoadConfig(configBindings(cmd.Root())...)
 		if err != nil {
 			return fmt.Errorf("failed to load configuration: %w", err)
 		}
@@ -54,6 +57,58 @@ real-time live monitoring, similar to ccusage for Claude Code.
 		}
 		logger.SetLevel(level)
 
+		// Additional Codex directories and source filter from the command line
+		if dirs, err := cmd.Flags().GetStringArray("codex-dir"); err == nil {
+			for _, dir := range dirs {
+				cfg.CodexDirs = append(cfg.CodexDirs, codex.ParseCodexDirFlag(dir))
+			}
+		}
+		if sources, err := cmd.Flags().GetStringSlice("source"); err == nil && len(sources) > 0 {
+			cfg.Sources = sources
+		}
+
+		// Coding agents whose logs are read
+		if agents, err := cmd.Flags().GetStringSlice("agent"); err == nil && len(agents) > 0 {
+			cfg.Agents = agents
+		}
+		if err := codex.CheckAgents(cfg); err != nil {
+			return err
+		}
+
+		// Redaction of identifying fields in all outputs
+		if cmd.Flags().Changed("redact") {
+			mode, _ := cmd.Flags().GetString("redact")
+			cfg.Redact = types.RedactMode(mode)
+		}
+		if _, err := codex.ParseRedactMode(string(cfg.Redact)); err != nil {
+			return err
+		}
+
+		// Teammates' usage bundles to merge into reports
+		if bundles, err := cmd.Flags().GetStringArray("bundle"); err == nil {
+			cfg.Bundles = append(cfg.Bundles, bundles...)
+		}
+		if team, err := cmd.Flags().GetBool("team"); err == nil && team {
+			bundlesDir, err := config.GetBundlesDir()
+			if err != nil {
+				return err
+			}
+			cfg.Bundles = append(cfg.Bundles, bundlesDir)
+		}
+
+		// Filter expression applied to entries before aggregation
+		if where, err := cmd.Flags().GetString("where"); err == nil && where != "" {
+			if _, err := filter.Parse(where); err != nil {
+				return fmt.Errorf("invalid --where expression: %w", err)
+			}
+			cfg.Where = where
+		}
+
+		// Read reports from an exported database instead of rescanning logs
+		if db, err := cmd.Flags().GetString("db"); err == nil && db != "" {
+			cfg.Database = db
+		}
+
 			// Set compact mode for tables
 			if val, err := cmd.Flags().GetBool("compact"); err == nil {
 				utils.SetCompactMode(val)
@@ -78,12 +133,18 @@ func Execute() {
 
 func init() {
     // Global flags
-    rootCmd.PersistentFlags().StringP("output", "o", "table", "Output format (table, json)")
-    rootCmd.PersistentFlags().String("log-level", "info", "Log level (debug, info, warn, error)")
+    rootCmd.PersistentFlags().StringP("output", "o", "table", "Output format (table, json; entries also supports ndjson)")
+    rootCmd.PersistentFlags().String("log-level", config.DefaultLogLevel, "Log level (debug, info, warn, error)")
     rootCmd.PersistentFlags().Bool("offline", false, "Use local logs only (no API calls)")
     rootCmd.PersistentFlags().Bool("compact", false, "Force compact table layout")
     rootCmd.PersistentFlags().Int("width", 0, "Override table width (useful for compact testing)")
-
-    // Bind flags to viper
-    // viper.BindPFlag("log_level", rootCmd.PersistentFlags().Lookup("log-level"))
+    rootCmd.PersistentFlags().StringArray("codex-dir", nil, "Additional Codex directory as [label=]path (repeatable)")
+    rootCmd.PersistentFlags().StringSlice("source", nil, "Only include these Codex directory labels (comma-separated)")
+    rootCmd.PersistentFlags().StringSlice("agent", nil, "Read the logs of these coding agents: codex, claude or a mapping name (comma-separated, default codex and all mappings)")
+    rootCmd.PersistentFlags().StringArray("bundle", nil, "Merge a usage bundle file or directory into reports (repeatable)")
+    rootCmd.PersistentFlags().Bool("team", false, "Merge all bundles imported with import-bundle into reports")
+    rootCmd.PersistentFlags().String("where", "", "Only include entries matching an expression, e.g. 'model =~ \"gpt-5.*\" and cost > 0.05'")
+    rootCmd.PersistentFlags().String("db", "", "Read usage from a database written by 'cxusage export' instead of the Codex logs")
+    rootCmd.PersistentFlags().String("redact", "", "Redact project paths, repo URLs and session ids: hash, alias or none (--redact alone means hash; use --redact=none to disable)")
+    rootCmd.PersistentFlags().Lookup("redact").NoOptDefVal = string(types.RedactModeHash)
 }
@@ -0,0 +1,106 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/johanneserhardt/cxusage/internal/blocks"
+	"github.com/johanneserhardt/cxusage/internal/codex"
+	"github.com/johanneserhardt/cxusage/internal/config"
+	"github.com/johanneserhardt/cxusage/internal/types"
+	"github.com/johanneserhardt/cxusage/internal/utils"
+)
+
+var statuslineCmd = &cobra.Command{
+	Use:   "statusline",
+	Short: "Print a compact one-line usage summary",
+	Long: `Print a single line with the active block's tokens and cost, the burn rate,
+the time left in the block, today's cost and the current project. Intended for
+shell prompts, tmux status bars and editor status lines.
+
+The layout is a template with {placeholder} fields:
+  ` + strings.Join(utils.StatuslinePlaceholders, ", ") + `
+
+Computed values are cached for a few seconds so frequent refreshes stay fast.`,
+	Example: `  cxusage statusline
+  cxusage statusline --format "{block_cost} ({remaining}) | {today_cost} today"
+  cxusage statusline --color --cache-ttl 10`,
+	Args: cobra.NoArgs,
+	RunE: runStatusline,
+}
+
+func runStatusline(cmd *cobra.Command, args []string) error {
+	// Get flags
+	template, _ := cmd.Flags().GetString("format")
+	useColor, _ := cmd.Flags().GetBool("color")
+	cacheTTL, _ := cmd.Flags().GetInt("cache-ttl")
+	sessionHours, _ := cmd.Flags().GetInt("session-duration")
+	blockModeStr, _ := cmd.Flags().GetString("block-mode")
+
+	if sessionHours < 1 || sessionHours > 24 {
+		return fmt.Errorf("session duration must be between 1 and 24 hours")
+	}
+	if cacheTTL < 0 {
+		return fmt.Errorf("cache TTL must not be negative")
+	}
+
+	blockMode, err := blocks.ParseBlockMode(blockModeStr)
+	if err != nil {
+		return err
+	}
+
+	// Status bars usually capture output, so color must be requested explicitly
+	color.NoColor = !useColor
+
+	var cachePath string
+	if cacheDir, err := config.GetCacheDir(); err != nil {
+		logger.WithError(err).Debug("Statusline cache disabled")
+	} else {
+		cachePath = utils.StatuslineCachePath(cacheDir, cfg)
+	}
+
+	ttl := time.Duration(cacheTTL) * time.Second
+	data, cached := utils.ReadStatuslineCache(cachePath, ttl, sessionHours, blockMode)
+	if !cached {
+		data, err = utils.BuildStatuslineData(cfg, sessionHours, blockMode, logger)
+		if err != nil {
+			return err
+		}
+		if cachePath != "" && ttl > 0 {
+			if err := utils.WriteStatuslineCache(cachePath, data); err != nil {
+				logger.WithError(err).Debug("Failed to write statusline cache")
+			}
+		}
+	}
+
+	// The project depends on where the statusline is rendered, so it is never cached
+	project := ""
+	if cwd, err := os.Getwd(); err == nil {
+		project = filepath.Base(cwd)
+		if codex.RedactionEnabled(cfg) {
+			if redactor, err := codex.NewRedactor(cfg); err == nil {
+				project = redactor.Project(cwd)
+				_ = redactor.Save()
+			}
+		}
+	}
+
+	fmt.Println(utils.RenderStatusline(template, data, project, time.Now()))
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(statuslineCmd)
+
+	// Statusline-specific flags
+	statuslineCmd.Flags().String("format", utils.DefaultStatuslineTemplate, "Statusline template with {placeholder} fields")
+	statuslineCmd.Flags().Bool("color", false, "Color values with ANSI escape codes")
+	statuslineCmd.Flags().Int("cache-ttl", 15, "Seconds to reuse cached usage data (0 disables the cache)")
+	statuslineCmd.Flags().Int("session-duration", 5, "Block duration in hours (default: 5)")
+	statuslineCmd.Flags().String("block-mode", string(types.BlockModeFixed), "Block detection mode: fixed (clock-aligned slots) or rolling (starts at first activity)")
+}
@@ -0,0 +1,113 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/johanneserhardt/cxusage/internal/aggregate"
+	"github.com/johanneserhardt/cxusage/internal/codex"
+	"github.com/johanneserhardt/cxusage/internal/types"
+	"github.com/johanneserhardt/cxusage/internal/utils"
+)
+
+var toolsCmd = &cobra.Command{
+	Use:   "tools [days]",
+	Short: "Show tool call and shell command analytics",
+	Long: `Analyze the tool calls recorded in Codex CLI session logs: how often each tool
+and shell command (rg, pytest, apply_patch, ...) runs, how often it fails, the time
+spent in it and the estimated tokens its output adds to the context.
+By default shows the last 7 days grouped by tool and command, largest outputs first.`,
+	Example: `  cxusage tools
+  cxusage tools 30 --group-by project,command
+  cxusage tools --group-by session --sort time
+  cxusage tools -o json --all`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runTools,
+}
+
+// toolsReport is the JSON representation of the tools command
+type toolsReport struct {
+	Groups []types.ToolGroup `json:"groups"`
+	Calls  []types.ToolCall  `json:"calls,omitempty"`
+}
+
+func runTools(cmd *cobra.Command, args []string) error {
+	days := 7 // default
+	if len(args) > 0 {
+		var err error
+		days, err = strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid number of days: %s", args[0])
+		}
+		if days < 1 || days > 365 {
+			return fmt.Errorf("days must be between 1 and 365")
+		}
+	}
+
+	// Get flags
+	outputFormat, _ := cmd.Flags().GetString("output")
+	groupBy, _ := cmd.Flags().GetString("group-by")
+	sortBy, _ := cmd.Flags().GetString("sort")
+	showAll, _ := cmd.Flags().GetBool("all")
+
+	dims, err := aggregate.ParseToolDimensions(groupBy)
+	if err != nil {
+		return err
+	}
+
+	endDate := time.Now()
+	startDate := endDate.AddDate(0, 0, -days)
+
+	logger.WithFields(map[string]interface{}{
+		"start_date": startDate.Format("2006-01-02"),
+		"end_date":   endDate.Format("2006-01-02"),
+		"group_by":   groupBy,
+	}).Info("Generating tool call report")
+
+	calls, err := codex.ParseToolCalls(cfg, startDate, endDate, logger)
+	if err != nil {
+		return fmt.Errorf("failed to load tool calls: %w", err)
+	}
+
+	report := toolsReport{Groups: aggregate.GroupToolCalls(calls, dims)}
+	if err := aggregate.SortToolGroups(report.Groups, sortBy); err != nil {
+		return err
+	}
+	if showAll {
+		report.Calls = calls
+	}
+
+	switch types.OutputFormat(outputFormat) {
+	case types.OutputFormatJSON:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case types.OutputFormatTable:
+		if len(calls) == 0 {
+			fmt.Printf("%s\n", utils.Yellow("No Codex CLI tool calls found"))
+			fmt.Println()
+			fmt.Printf("Try:\n")
+			fmt.Printf("• %s - Check if Codex CLI is set up\n", utils.Cyan("cxusage validate"))
+			fmt.Printf("• %s - Look further back\n", utils.Cyan("cxusage tools 30"))
+			return nil
+		}
+		utils.FormatToolGroupsTable(report.Groups, dims)
+	default:
+		return fmt.Errorf("unsupported output format: %s", outputFormat)
+	}
+
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(toolsCmd)
+
+	// Tools-specific flags
+	toolsCmd.Flags().String("group-by", "tool,command", "Comma-separated dimensions: tool, command, project, session, day, week, month, hour, source, user")
+	toolsCmd.Flags().String("sort", "tokens", "Sort order: tokens, calls, failures, time or key")
+	toolsCmd.Flags().Bool("all", false, "Include every tool call in JSON output")
+}
@@ -0,0 +1,97 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/johanneserhardt/cxusage/internal/aggregate"
+	"github.com/johanneserhardt/cxusage/internal/types"
+	"github.com/johanneserhardt/cxusage/internal/utils"
+)
+
+var usageCmd = &cobra.Command{
+	Use:   "usage [days]",
+	Short: "Show usage grouped by any combination of dimensions",
+	Long: `Group usage by any combination of day, week, month, hour, model, project,
+session, source, user, reasoning_effort and provider, e.g. cost by project by week.
+By default shows the last 30 days grouped by day.`,
+	Example: `  cxusage usage --group-by week,project
+  cxusage usage 90 --group-by model,reasoning_effort --sort cost
+  cxusage usage 7 --group-by hour -o json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runUsage,
+}
+
+func runUsage(cmd *cobra.Command, args []string) error {
+	days := 30 // default
+	if len(args) > 0 {
+		var err error
+		days, err = strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid number of days: %s", args[0])
+		}
+		if days < 1 || days > 365 {
+			return fmt.Errorf("days must be between 1 and 365")
+		}
+	}
+
+	// Get flags
+	outputFormat, _ := cmd.Flags().GetString("output")
+	groupBy, _ := cmd.Flags().GetString("group-by")
+	sortBy, _ := cmd.Flags().GetString("sort")
+
+	dims, err := aggregate.ParseDimensions(groupBy)
+	if err != nil {
+		return err
+	}
+
+	endDate := time.Now()
+	startDate := endDate.AddDate(0, 0, -days)
+
+	logger.WithFields(map[string]interface{}{
+		"start_date": startDate.Format("2006-01-02"),
+		"end_date":   endDate.Format("2006-01-02"),
+		"group_by":   groupBy,
+	}).Info("Generating grouped usage report")
+
+	groups, err := utils.LoadUsageGroupsFromCodex(cfg, startDate, endDate, dims, logger)
+	if err != nil {
+		return fmt.Errorf("failed to load usage data: %w", err)
+	}
+	if err := aggregate.SortGroups(groups, sortBy); err != nil {
+		return err
+	}
+
+	switch types.OutputFormat(outputFormat) {
+	case types.OutputFormatJSON:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(groups)
+	case types.OutputFormatTable:
+		if len(groups) == 0 {
+			fmt.Printf("%s\n", utils.Yellow("No Codex CLI usage data found"))
+			fmt.Println()
+			fmt.Printf("Try:\n")
+			fmt.Printf("• %s - Check if Codex CLI is set up\n", utils.Cyan("cxusage validate"))
+			fmt.Printf("• %s - Look further back\n", utils.Cyan("cxusage usage 90"))
+			return nil
+		}
+		utils.FormatUsageGroupsTable(groups, dims)
+	default:
+		return fmt.Errorf("unsupported output format: %s", outputFormat)
+	}
+
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(usageCmd)
+
+	// Usage-specific flags
+	usageCmd.Flags().String("group-by", string(types.DimensionDay), "Comma-separated dimensions: day, week, month, hour, model, project, session, source, user, reasoning_effort, provider")
+	usageCmd.Flags().String("sort", "key", "Sort order: key, cost, tokens or requests")
+}
@@ -3,11 +3,15 @@ package commands
 import (
     "bufio"
     "encoding/json"
+    "errors"
     "fmt"
+    "io/fs"
     "os"
+    "sort"
     "strings"
 
     "github.com/johanneserhardt/cxusage/internal/codex"
+    "github.com/johanneserhardt/cxusage/internal/types"
     "github.com/spf13/cobra"
 )
 
@@ -39,7 +43,7 @@ func runValidate(cmd *cobra.Command, args []string) error {
 	fmt.Println("✅ Codex CLI directory found")
 
 	// Get Codex paths
-	paths, err := codex.GetCodexPaths(cfg)
+	allPaths, err := codex.GetAllCodexPaths(cfg)
 	if err != nil {
 		fmt.Printf("❌ Failed to get Codex paths: %v\n", err)
 		return err
@@ -59,12 +63,32 @@ func runValidate(cmd *cobra.Command, args []string) error {
 
 	// Show configuration
 	fmt.Println("\n⚙️ Configuration:")
-	fmt.Printf("   Codex Directory: %s\n", paths.ConfigDir)
-	fmt.Printf("   Config File: %s\n", paths.ConfigFile)
-	fmt.Printf("   Instructions File: %s\n", paths.InstructionsFile)
+	if len(allPaths) == 1 {
+		fmt.Printf("   Codex Directory: %s\n", allPaths[0].ConfigDir)
+		fmt.Printf("   Config File: %s\n", allPaths[0].ConfigFile)
+		fmt.Printf("   Instructions File: %s\n", allPaths[0].InstructionsFile)
+	} else {
+		fmt.Println("   Codex Directories:")
+		for _, paths := range allPaths {
+			status := "✅"
+			if info, err := os.Stat(paths.ConfigDir); err != nil || !info.IsDir() {
+				status = "❌"
+			}
+			fmt.Printf("     %s %s: %s\n", status, paths.Label, paths.ConfigDir)
+		}
+	}
 	fmt.Printf("   Log Level: %s\n", cfg.LogLevel)
 	fmt.Printf("   Local Logging: %v\n", cfg.LocalLogging)
 
+	// Show the model and provider Codex itself is configured to use
+	fmt.Println("\n🤖 Codex Model Settings:")
+	for _, paths := range allPaths {
+		if len(allPaths) > 1 {
+			fmt.Printf("   %s:\n", paths.Label)
+		}
+		printCodexConfig(paths)
+	}
+
     // Optional: Analyze a sample of files to report explicit vs estimated usage presence
     if len(files) > 0 {
         explicit, estimated, costPresent, totalMessages := analyzeUsageQuality(files)
@@ -80,6 +104,40 @@ func runValidate(cmd *cobra.Command, args []string) error {
     return nil
 }
 
+// printCodexConfig shows the default model and provider from a Codex directory's config.toml
+func printCodexConfig(paths *types.CodexPaths) {
+	config, err := codex.LoadCodexConfig(paths.ConfigFile)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		fmt.Printf("   ⚠️ %s not found, using Codex defaults\n", paths.ConfigFile)
+	case err != nil:
+		fmt.Printf("   ❌ %v\n", err)
+	default:
+		fmt.Printf("   ✅ Read %s\n", paths.ConfigFile)
+	}
+
+	defaults := codex.ResolveDefaults(config)
+	fmt.Printf("   Default Model: %s\n", defaults.Model)
+	if defaults.ProviderName != "" {
+		fmt.Printf("   Provider: %s (%s)\n", defaults.Provider, defaults.ProviderName)
+	} else {
+		fmt.Printf("   Provider: %s\n", defaults.Provider)
+	}
+	if defaults.Profile != "" {
+		fmt.Printf("   Profile: %s\n", defaults.Profile)
+	} else if config != nil && config.Profile != "" {
+		fmt.Printf("   ⚠️ Profile %q is not defined in [profiles]\n", config.Profile)
+	}
+	if config != nil && len(config.ModelProviders) > 0 {
+		ids := make([]string, 0, len(config.ModelProviders))
+		for id := range config.ModelProviders {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		fmt.Printf("   Custom Providers: %s\n", strings.Join(ids, ", "))
+	}
+}
+
 func init() {
     rootCmd.AddCommand(validateCmd)
 }
@@ -3,24 +3,34 @@ package config
 import (
 	"fmt"
 	"os"
+	"os/user"
 	"path/filepath"
+	"strings"
 
 	"github.com/spf13/viper"
 	"github.com/johanneserhardt/cxusage/internal/types"
+	"gopkg.in/yaml.v3"
 )
 
 const (
 	DefaultLogLevel = "warn" // Reduce default log noise
 	DefaultLogsDir  = "logs"
 	ConfigFileName  = "cxusage"
+	EnvPrefix       = "CXUSAGE"
 )
 
-// LoadConfig loads configuration from file and environment variables
-func LoadConfig() (*types.Config, error) {
+// LoadConfig loads configuration from file and environment variables. Bound flags take
+// precedence; flags the user did not set receive their value from the environment or file.
+func LoadConfig(bindings ...Binding) (*types.Config, error) {
 	// Set default values
 	viper.SetDefault("log_level", DefaultLogLevel)
-	viper.SetDefault("local_logging", false)
-	viper.SetDefault("logs_dir", DefaultLogsDir)
+	for _, s := range Settings {
+		if s.Default != nil {
+			viper.SetDefault(s.Key, s.Default)
+		}
+		// Bound by full name: the prefix is not set yet, and bare names like USER must not apply
+		viper.BindEnv(s.Key, EnvName(s.Key))
+	}
 
 	// Set config file name and type
 	viper.SetConfigName(ConfigFileName)
@@ -34,9 +44,14 @@ func LoadConfig() (*types.Config, error) {
 	}
 	viper.AddConfigPath(".")
 
-	// Enable environment variable reading
+	// Enable environment variable reading (CXUSAGE_LOG_LEVEL, CXUSAGE_BLOCKS_SESSION_DURATION, ...)
+	viper.SetEnvPrefix(EnvPrefix)
+	viper.SetEnvKeyReplacer(envKeyReplacer)
 	viper.AutomaticEnv()
-	viper.SetEnvPrefix("OPENAI_USAGE")
+	for _, b := range bindings {
+		viper.BindPFlag(b.Key, b.Flag)
+		viper.BindEnv(b.Key, EnvName(b.Key))
+	}
 
 	// Read config file (optional) - ignore errors for missing or invalid config
 	if err := viper.ReadInConfig(); err != nil {
@@ -47,42 +62,176 @@ func LoadConfig() (*types.Config, error) {
 		}
 	}
 
+	// Invalid values are ignored like invalid config files, and reported by config validate
+	for _, b := range bindings {
+		if err := applyBinding(b); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+
 	// Unmarshal config
 	var config types.Config
 	if err := viper.Unmarshal(&config); err != nil {
 		return nil, fmt.Errorf("error unmarshaling config: %w", err)
 	}
 
+	// Tag local usage with the OS user unless an identity is configured
+	if config.User == "" {
+		config.User = DefaultUser()
+	}
+
 	// No API key validation needed for local file reading
 
 	return &config, nil
 }
 
-// SaveConfig saves the configuration to a file
-func SaveConfig(config *types.Config) error {
+// ConfigPath returns the config file in use, or the default location for a new one
+func ConfigPath() (string, error) {
+	if used := viper.ConfigFileUsed(); used != "" {
+		return used, nil
+	}
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		return fmt.Errorf("could not get user home directory: %w", err)
+		return "", fmt.Errorf("could not get user home directory: %w", err)
 	}
+	return filepath.Join(homeDir, ".config", ConfigFileName+".yaml"), nil
+}
 
-	configDir := filepath.Join(homeDir, ".config")
-	if err := os.MkdirAll(configDir, 0755); err != nil {
-		return fmt.Errorf("could not create config directory: %w", err)
+// SaveConfigValue sets a key in a config file, keeping the rest of the file and its comments
+func SaveConfigValue(path, key string, value interface{}) error {
+	var doc yaml.Node
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("could not read config file: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("could not parse config file: %w", err)
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		// A file holding only comments has no mapping yet
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode, HeadComment: doc.HeadComment}}
+		doc.HeadComment = ""
 	}
 
-	configPath := filepath.Join(configDir, ConfigFileName+".yaml")
+	var valueNode yaml.Node
+	if err := valueNode.Encode(value); err != nil {
+		return err
+	}
 
-	viper.Set("log_level", config.LogLevel)
-	viper.Set("local_logging", config.LocalLogging)
-	viper.Set("logs_dir", config.LogsDir)
+	// Walk or create the nested mappings of a dotted key
+	node := doc.Content[0]
+	parts := strings.Split(key, ".")
+	for i, part := range parts {
+		var child *yaml.Node
+		for j := 0; j+1 < len(node.Content); j += 2 {
+			if node.Content[j].Value == part {
+				child = node.Content[j+1]
+				break
+			}
+		}
+		last := i == len(parts)-1
+		if child == nil {
+			child = &yaml.Node{Kind: yaml.MappingNode}
+			if last {
+				child = &valueNode
+			}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: part}, child)
+		} else if last {
+			valueNode.LineComment = child.LineComment
+			*child = valueNode
+		} else if child.Kind != yaml.MappingNode {
+			return fmt.Errorf("%s is not a section", strings.Join(parts[:i+1], "."))
+		}
+		node = child
+	}
 
-	if err := viper.WriteConfigAs(configPath); err != nil {
+	out, err := yaml.Marshal(&doc)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("could not create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, out, 0644); err != nil {
 		return fmt.Errorf("could not write config file: %w", err)
 	}
-
 	return nil
 }
 
+// DefaultUser returns the identity attached to local usage when none is configured
+func DefaultUser() string {
+	if current, err := user.Current(); err == nil && current.Username != "" {
+		return current.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return "unknown"
+}
+
+// GetBundlesDir returns the directory where imported usage bundles are stored
+func GetBundlesDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("could not get user home directory: %w", err)
+	}
+
+	bundlesDir := filepath.Join(homeDir, ".local", "share", "cxusage", "bundles")
+	if err := os.MkdirAll(bundlesDir, 0755); err != nil {
+		return "", fmt.Errorf("could not create bundles directory: %w", err)
+	}
+
+	return bundlesDir, nil
+}
+
+// GetRedactionStatePath returns the file holding the redaction salt and alias mapping
+func GetRedactionStatePath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("could not get user home directory: %w", err)
+	}
+
+	dataDir := filepath.Join(homeDir, ".local", "share", "cxusage")
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return "", fmt.Errorf("could not create data directory: %w", err)
+	}
+
+	return filepath.Join(dataDir, "redaction.json"), nil
+}
+
+// GetCalibrationPath returns the file holding the token estimator calibration
+func GetCalibrationPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("could not get user home directory: %w", err)
+	}
+
+	dataDir := filepath.Join(homeDir, ".local", "share", "cxusage")
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return "", fmt.Errorf("could not create data directory: %w", err)
+	}
+
+	return filepath.Join(dataDir, "calibration.json"), nil
+}
+
+// GetCacheDir returns the directory for cached data, creating it if needed
+func GetCacheDir() (string, error) {
+	baseDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("could not get user cache directory: %w", err)
+	}
+
+	cacheDir := filepath.Join(baseDir, "cxusage")
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return "", fmt.Errorf("could not create cache directory: %w", err)
+	}
+
+	return cacheDir, nil
+}
+
 // GetLogsDir returns the absolute path to the logs directory
 func GetLogsDir(config *types.Config) (string, error) {
 	logsDir := config.LogsDir
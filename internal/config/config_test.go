@@ -0,0 +1,46 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestLoadConfigIgnoresUnprefixedEnv(t *testing.T) {
+	home := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(home, ".config"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, ".config", ConfigFileName+".yaml"), []byte("user: alice\ncodex_path: /from/file\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", home)
+	t.Setenv("USER", "bob")
+	t.Setenv("CODEX_PATH", "/from/env")
+
+	viper.Reset()
+	defer viper.Reset()
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.User != "alice" || cfg.CodexPath != "/from/file" {
+		t.Errorf("expected the file values to win over USER and CODEX_PATH, got user %q and codex_path %q", cfg.User, cfg.CodexPath)
+	}
+	if got := Source("user", nil); got != "file" {
+		t.Errorf("expected the user to come from the file, got %s", got)
+	}
+
+	// The prefixed variable still overrides the file
+	t.Setenv("CXUSAGE_USER", "carol")
+	viper.Reset()
+	cfg, err = LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.User != "carol" {
+		t.Errorf("expected CXUSAGE_USER to override the file, got %q", cfg.User)
+	}
+}
@@ -0,0 +1,490 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/johanneserhardt/cxusage/internal/types"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// Sources of an effective configuration value, from lowest to highest precedence
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+// Binding ties a command line flag to a configuration key, so the option can also be set
+// in the config file and through a CXUSAGE_ environment variable
+type Binding struct {
+	Key   string
+	Flag  *pflag.Flag
+	Check func(value string) error // optional validation beyond the flag's type
+}
+
+// Setting describes a configuration key that has no command line flag
+type Setting struct {
+	Key     string
+	Type    string // bool, string, list, sources, rates or mappings
+	Default interface{}
+	Help    string
+	Secret  bool // value is masked by config show
+}
+
+// Settings lists the configuration keys that are only set in the config file or environment
+var Settings = []Setting{
+	{Key: "local_logging", Type: "bool", Default: false, Help: "Write cxusage logs to files"},
+	{Key: "logs_dir", Type: "string", Default: DefaultLogsDir, Help: "Directory for log files, relative to ~/.local/share/cxusage"},
+	{Key: "codex_path", Type: "string", Help: "Codex CLI directory to read instead of ~/.codex"},
+	{Key: "codex_dirs", Type: "sources", Help: "Additional labeled Codex directories, a list of {label, path}"},
+	{Key: "claude_path", Type: "string", Help: "Claude Code directory to read with --agent claude instead of CLAUDE_CONFIG_DIR or ~/.claude"},
+	{Key: "user", Type: "string", Help: "Identity attached to local usage (default: the OS user)"},
+	{Key: "redact_salt", Type: "string", Help: "Fixed salt for redaction hashes", Secret: true},
+	{Key: "mappings", Type: "mappings", Help: "JSONL logs of other tools, a list of {name, glob, timestamp, model, input_tokens, ...} JSON paths"},
+	{Key: "pricing.local_providers", Type: "list", Help: "More providers that run locally and cost nothing (oss, ollama, lmstudio, llamacpp, vllm and localhost providers always do)"},
+	{Key: "pricing.rates", Type: "rates", Help: "Prices in USD per 1M tokens by provider, overriding the list prices (omit model for all models)"},
+}
+
+// EnvName returns the environment variable that sets a key, e.g. CXUSAGE_BLOCKS_SESSION_DURATION
+func EnvName(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(envKeyReplacer.Replace(key))
+}
+
+var envKeyReplacer = strings.NewReplacer(".", "_", "-", "_")
+
+// Source reports where the effective value of a key comes from
+func Source(key string, flag *pflag.Flag) string {
+	if flag != nil && flag.Changed {
+		return SourceFlag
+	}
+	if _, ok := os.LookupEnv(EnvName(key)); ok {
+		return SourceEnv
+	}
+	if viper.InConfig(key) {
+		return SourceFile
+	}
+	return SourceDefault
+}
+
+// applyBinding copies a value from the environment or config file into a flag the user
+// did not set, so commands reading their flags see the configured value
+func applyBinding(b Binding) error {
+	source := Source(b.Key, b.Flag)
+	if source != SourceEnv && source != SourceFile {
+		return nil
+	}
+
+	var err error
+	if slice, ok := b.Flag.Value.(pflag.SliceValue); ok {
+		// Lists are comma-separated in environment variables and plain strings
+		values := viper.GetStringSlice(b.Key)
+		if s, ok := viper.Get(b.Key).(string); ok {
+			values = nil
+			for _, v := range strings.Split(s, ",") {
+				if v = strings.TrimSpace(v); v != "" {
+					values = append(values, v)
+				}
+			}
+		}
+		err = slice.Replace(values)
+	} else {
+		value := viper.GetString(b.Key)
+		if err = CheckValue(b, value); err == nil {
+			err = b.Flag.Value.Set(value)
+		}
+	}
+	if err != nil {
+		origin := "config file"
+		if source == SourceEnv {
+			origin = EnvName(b.Key)
+		}
+		return fmt.Errorf("invalid value for %s from %s: %w", b.Key, origin, err)
+	}
+	return nil
+}
+
+// CheckValue validates a value for a flag binding by its type and check function
+func CheckValue(b Binding, value string) error {
+	switch b.Flag.Value.Type() {
+	case "bool":
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("expected true or false, got %q", value)
+		}
+	case "int":
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("expected a whole number, got %q", value)
+		}
+	case "float64":
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Errorf("expected a number, got %q", value)
+		}
+	}
+	if b.Check != nil {
+		return b.Check(value)
+	}
+	return nil
+}
+
+// IsList reports whether a flag takes a list of values
+func IsList(flag *pflag.Flag) bool {
+	_, ok := flag.Value.(pflag.SliceValue)
+	return ok
+}
+
+// ValidateFile checks a config file for syntax errors, unknown keys and invalid values
+func ValidateFile(path string, bindings []Binding) []error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return []error{err}
+	}
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return []error{fmt.Errorf("invalid YAML: %w", err)}
+	}
+
+	byKey := make(map[string]Binding)
+	sections := make(map[string]bool)
+	for _, b := range bindings {
+		byKey[b.Key] = b
+		if i := strings.LastIndex(b.Key, "."); i > 0 {
+			sections[b.Key[:i]] = true
+		}
+	}
+	settings := make(map[string]Setting)
+	for _, s := range Settings {
+		settings[s.Key] = s
+		if i := strings.LastIndex(s.Key, "."); i > 0 {
+			sections[s.Key[:i]] = true
+		}
+	}
+
+	var problems []error
+	var walk func(prefix string, values map[string]interface{})
+	walk = func(prefix string, values map[string]interface{}) {
+		keys := make([]string, 0, len(values))
+		for k := range values {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			key := prefix + k
+			value := values[k]
+			if b, ok := byKey[key]; ok {
+				if err := validateBindingValue(b, value); err != nil {
+					problems = append(problems, fmt.Errorf("%s: %w", key, err))
+				}
+				continue
+			}
+			if s, ok := settings[key]; ok {
+				if err := validateSettingValue(s, value); err != nil {
+					problems = append(problems, fmt.Errorf("%s: %w", key, err))
+				}
+				continue
+			}
+			if nested, ok := value.(map[string]interface{}); ok && sections[key] {
+				walk(key+".", nested)
+				continue
+			}
+			problems = append(problems, fmt.Errorf("%s: unknown key%s", key, suggestKey(key, bindings)))
+		}
+	}
+	walk("", raw)
+	return problems
+}
+
+// validateBindingValue checks a config file value for a flag binding
+func validateBindingValue(b Binding, value interface{}) error {
+	if IsList(b.Flag) {
+		items, ok := value.([]interface{})
+		if !ok {
+			if _, isMap := value.(map[string]interface{}); isMap || value == nil {
+				return fmt.Errorf("expected a list")
+			}
+			items = []interface{}{value}
+		}
+		for _, item := range items {
+			if _, ok := item.(map[string]interface{}); ok {
+				return fmt.Errorf("expected a list of values")
+			}
+		}
+		return nil
+	}
+
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		return fmt.Errorf("expected a single value")
+	case nil:
+		return fmt.Errorf("missing value")
+	}
+	return CheckValue(b, fmt.Sprint(value))
+}
+
+// validateSettingValue checks a config file value for a setting without a flag
+func validateSettingValue(s Setting, value interface{}) error {
+	switch s.Type {
+	case "bool":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("expected true or false, got %v", value)
+		}
+	case "string":
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+			return fmt.Errorf("expected a single value")
+		}
+	case "list":
+		switch v := value.(type) {
+		case string:
+		case []interface{}:
+			for _, item := range v {
+				if _, ok := item.(string); !ok {
+					return fmt.Errorf("expected a list of names")
+				}
+			}
+		default:
+			return fmt.Errorf("expected a list of names")
+		}
+	case "rates":
+		return validateRates(value)
+	case "mappings":
+		return validateMappings(value)
+	case "sources":
+		items, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("expected a list of {label, path} entries")
+		}
+		for i, item := range items {
+			entry, ok := item.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("entry %d: expected {label, path}", i+1)
+			}
+			if path, _ := entry["path"].(string); path == "" {
+				return fmt.Errorf("entry %d: missing path", i+1)
+			}
+			for k := range entry {
+				if k != "label" && k != "path" {
+					return fmt.Errorf("entry %d: unknown key %q", i+1, k)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// validateRates checks a list of {provider, model, input, output} prices
+func validateRates(value interface{}) error {
+	items, ok := value.([]interface{})
+	if !ok {
+		return fmt.Errorf("expected a list of {provider, model, input, output} entries")
+	}
+	for i, item := range items {
+		entry, ok := item.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("entry %d: expected {provider, model, input, output}", i+1)
+		}
+		if provider, _ := entry["provider"].(string); provider == "" {
+			return fmt.Errorf("entry %d: missing provider", i+1)
+		}
+		for k, v := range entry {
+			switch k {
+			case "provider", "model":
+				if _, ok := v.(string); !ok {
+					return fmt.Errorf("entry %d: %s must be a string", i+1, k)
+				}
+			case "input", "output", "cache_write", "cache_read":
+				price, ok := toFloat(v)
+				if !ok || price < 0 {
+					return fmt.Errorf("entry %d: %s must be a price of 0 or more, got %v", i+1, k, v)
+				}
+			default:
+				return fmt.Errorf("entry %d: unknown key %q", i+1, k)
+			}
+		}
+	}
+	return nil
+}
+
+// mappingKeys are the keys of a log mapping besides name and glob; all hold JSON paths
+var mappingKeys = map[string]bool{
+	"timestamp": true, "model": true, "provider": true, "session": true, "project": true, "request": true,
+	"input_tokens": true, "output_tokens": true, "cache_creation_tokens": true, "cache_read_tokens": true,
+	"total_tokens": true, "cost": true,
+}
+
+// validateMappings checks a list of log mappings: each needs a unique name that is not a
+// built-in agent, a glob, a timestamp path and at least one token path
+func validateMappings(value interface{}) error {
+	items, ok := value.([]interface{})
+	if !ok {
+		return fmt.Errorf("expected a list of {name, glob, timestamp, ...} entries")
+	}
+	names := make(map[string]bool)
+	for i, item := range items {
+		entry, ok := item.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("entry %d: expected {name, glob, timestamp, ...}", i+1)
+		}
+		for k, v := range entry {
+			if k != "name" && k != "glob" && !mappingKeys[k] {
+				return fmt.Errorf("entry %d: unknown key %q", i+1, k)
+			}
+			if _, ok := v.(string); !ok {
+				return fmt.Errorf("entry %d: %s must be a string", i+1, k)
+			}
+		}
+
+		name, _ := entry["name"].(string)
+		switch {
+		case name == "":
+			return fmt.Errorf("entry %d: missing name", i+1)
+		case name == types.AgentCodex || name == types.AgentClaude:
+			return fmt.Errorf("entry %d: name %q is a built-in agent", i+1, name)
+		case names[name]:
+			return fmt.Errorf("entry %d: duplicate name %q", i+1, name)
+		}
+		names[name] = true
+		for _, k := range []string{"glob", "timestamp"} {
+			if v, _ := entry[k].(string); v == "" {
+				return fmt.Errorf("entry %d: missing %s", i+1, k)
+			}
+		}
+		hasTokens := false
+		for _, k := range []string{"input_tokens", "output_tokens", "cache_creation_tokens", "cache_read_tokens", "total_tokens"} {
+			hasTokens = hasTokens || entry[k] != nil
+		}
+		if !hasTokens {
+			return fmt.Errorf("entry %d: no token fields", i+1)
+		}
+	}
+	return nil
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// suggestKey returns a hint for a misspelled or misplaced key
+func suggestKey(key string, bindings []Binding) string {
+	name := key[strings.LastIndex(key, ".")+1:]
+	var matches []string
+	for _, b := range bindings {
+		if b.Key != key && strings.HasSuffix("."+b.Key, "."+name) {
+			matches = append(matches, b.Key)
+		}
+	}
+	if len(matches) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (did you mean %s?)", strings.Join(matches, " or "))
+}
+
+// UnknownEnv returns CXUSAGE_ environment variables that do not set any key
+func UnknownEnv(bindings []Binding) []string {
+	known := make(map[string]bool)
+	for _, b := range bindings {
+		known[EnvName(b.Key)] = true
+	}
+	for _, s := range Settings {
+		known[EnvName(s.Key)] = true
+	}
+
+	var unknown []string
+	for _, env := range os.Environ() {
+		name := env[:strings.Index(env, "=")]
+		if strings.HasPrefix(name, EnvPrefix+"_") && !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// StarterConfig returns a commented config file listing every key with its default
+func StarterConfig(bindings []Binding) string {
+	var b strings.Builder
+	b.WriteString("# cxusage configuration\n")
+	b.WriteString("#\n")
+	b.WriteString("# Uncomment and edit the options you want to change. Every command line flag has a\n")
+	b.WriteString("# key here: global flags at the top level, command flags under the command name.\n")
+	b.WriteString("# Environment variables (CXUSAGE_LOG_LEVEL, CXUSAGE_BLOCKS_SESSION_DURATION, ...)\n")
+	b.WriteString("# override this file, and flags override both. Run 'cxusage config show' to see\n")
+	b.WriteString("# the effective values and where each one comes from.\n")
+
+	b.WriteString("\n")
+	section := ""
+	for _, s := range Settings {
+		name := s.Key
+		indent := ""
+		if i := strings.LastIndex(s.Key, "."); i > 0 {
+			if s.Key[:i] != section {
+				section = s.Key[:i]
+				fmt.Fprintf(&b, "\n# %s:\n", section)
+			}
+			name = s.Key[i+1:]
+			indent = "  "
+		}
+		fmt.Fprintf(&b, "# %s# %s\n", indent, s.Help)
+		switch {
+		case s.Type == "sources":
+			fmt.Fprintf(&b, "# %s:\n#   - label: work\n#     path: ~/work/.codex\n", name)
+		case s.Type == "rates":
+			fmt.Fprintf(&b, "# %s%s:\n", indent, name)
+			fmt.Fprintf(&b, "# %s  - provider: azure\n# %s    model: gpt-5-codex\n", indent, indent)
+			fmt.Fprintf(&b, "# %s    input: 1.25\n# %s    output: 10\n", indent, indent)
+		case s.Type == "mappings":
+			fmt.Fprintf(&b, "# %s:\n#   - name: gateway\n#     glob: ~/gateway/logs/**/*.jsonl\n", name)
+			fmt.Fprintf(&b, "#     timestamp: ts\n#     model: request.model\n")
+			fmt.Fprintf(&b, "#     input_tokens: usage.prompt_tokens\n#     output_tokens: usage.completion_tokens\n")
+		case s.Type == "list":
+			fmt.Fprintf(&b, "# %s%s: []\n", indent, name)
+		case s.Default != nil:
+			fmt.Fprintf(&b, "# %s%s: %v\n", indent, name, s.Default)
+		default:
+			fmt.Fprintf(&b, "# %s%s: \"\"\n", indent, name)
+		}
+	}
+
+	section = ""
+	for _, binding := range bindings {
+		name := binding.Key
+		indent := ""
+		if i := strings.LastIndex(binding.Key, "."); i > 0 {
+			if binding.Key[:i] != section {
+				section = binding.Key[:i]
+				fmt.Fprintf(&b, "\n# %s:\n", section)
+			}
+			name = binding.Key[i+1:]
+			indent = "  "
+		} else {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "# %s# %s\n", indent, binding.Flag.Usage)
+		fmt.Fprintf(&b, "# %s%s: %s\n", indent, name, starterValue(binding.Flag))
+	}
+	return b.String()
+}
+
+// starterValue formats a flag default as YAML
+func starterValue(flag *pflag.Flag) string {
+	if IsList(flag) {
+		return "[]"
+	}
+	switch flag.Value.Type() {
+	case "bool", "int", "float64":
+		return flag.DefValue
+	}
+	return strconv.Quote(flag.DefValue)
+}
@@ -0,0 +1,149 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func settingsTestBindings() []Binding {
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.String("log-level", "warn", "Log level")
+	flags.Int("session-duration", 5, "Session duration in hours")
+	flags.StringSlice("source", nil, "Sources")
+	return []Binding{
+		{Key: "log_level", Flag: flags.Lookup("log-level")},
+		{Key: "blocks.session_duration", Flag: flags.Lookup("session-duration")},
+		{Key: "sources", Flag: flags.Lookup("source")},
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	if got := EnvName("blocks.session_duration"); got != "CXUSAGE_BLOCKS_SESSION_DURATION" {
+		t.Errorf("EnvName = %s", got)
+	}
+	if got := EnvName("log-level"); got != "CXUSAGE_LOG_LEVEL" {
+		t.Errorf("EnvName = %s", got)
+	}
+}
+
+func TestValidateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cxusage.yaml")
+	data := `log_level: debug
+local_logging: yes please
+session_duration: 6
+sources: [work, personal]
+codex_dirs:
+  - label: work
+blocks:
+  session_duration: six
+  colour: true
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, err := range ValidateFile(path, settingsTestBindings()) {
+		got = append(got, err.Error())
+	}
+	want := []string{
+		"blocks.colour: unknown key",
+		"blocks.session_duration: expected a whole number",
+		"codex_dirs: entry 1: missing path",
+		"local_logging: expected true or false",
+		"session_duration: unknown key (did you mean blocks.session_duration?)",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d problems, want %d: %v", len(got), len(want), got)
+	}
+	for i := range want {
+		if !strings.HasPrefix(got[i], want[i]) {
+			t.Errorf("problem %d = %q, want prefix %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestSaveConfigValue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cxusage.yaml")
+	data := "# my settings\nlog_level: warn # keep quiet\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SaveConfigValue(path, "log_level", "debug"); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveConfigValue(path, "blocks.session_duration", 6); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := string(out)
+	for _, want := range []string{"# my settings", "log_level: debug # keep quiet", "blocks:\n", "session_duration: 6"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("saved config missing %q:\n%s", want, text)
+		}
+	}
+	if problems := ValidateFile(path, settingsTestBindings()); len(problems) > 0 {
+		t.Errorf("saved config has problems: %v", problems)
+	}
+}
+
+func TestValidateFilePricing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cxusage.yaml")
+	data := `pricing:
+  local_providers: [gpu-box]
+  rates:
+    - provider: azure
+      model: gpt-5-codex
+      input: 1.25
+      output: 10
+    - provider: anthropic
+      model: claude-sonnet-4-5
+      input: 3
+      output: 15
+      cache_write: 3.75
+      cache_read: 0.3
+    - model: gpt-5
+      input: 1
+      output: 2
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	problems := ValidateFile(path, settingsTestBindings())
+	if len(problems) != 1 || problems[0].Error() != "pricing.rates: entry 3: missing provider" {
+		t.Errorf("unexpected problems: %v", problems)
+	}
+}
+
+func TestValidateFileMappings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cxusage.yaml")
+	data := `mappings:
+  - name: gateway
+    glob: ~/gateway/**/*.jsonl
+    timestamp: ts
+    model: req.model
+    input_tokens: usage.prompt
+  - name: claude
+    glob: /tmp/*.jsonl
+    timestamp: ts
+    total_tokens: tokens
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	problems := ValidateFile(path, settingsTestBindings())
+	if len(problems) != 1 || problems[0].Error() != `mappings: entry 2: name "claude" is a built-in agent` {
+		t.Errorf("unexpected problems: %v", problems)
+	}
+}
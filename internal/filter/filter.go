@@ -0,0 +1,422 @@
+package filter
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/johanneserhardt/cxusage/internal/types"
+)
+
+// Predicate reports whether a usage entry matches a filter expression
+type Predicate func(entry types.CodexUsageEntry) bool
+
+// Error is a parse error with the position in the expression it refers to
+type Error struct {
+	Expr string
+	Pos  int
+	Msg  string
+}
+
+func newError(expr string, pos int, format string, args ...interface{}) *Error {
+	return &Error{Expr: expr, Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// Error shows the message, followed by the expression with a caret under the position
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s at position %d\n  %s\n  %s^", e.Msg, e.Pos+1, e.Expr, strings.Repeat(" ", e.Pos))
+}
+
+type valueKind int
+
+const (
+	kindString valueKind = iota
+	kindNumber
+	kindBool
+)
+
+func (k valueKind) String() string {
+	switch k {
+	case kindNumber:
+		return "number"
+	case kindBool:
+		return "boolean"
+	default:
+		return "string"
+	}
+}
+
+// field describes an entry attribute that expressions can refer to
+type field struct {
+	kind   valueKind
+	str    func(e types.CodexUsageEntry) string
+	num    func(e types.CodexUsageEntry) float64
+	truthy func(e types.CodexUsageEntry) bool
+}
+
+var fields = map[string]field{
+	"model":        {kind: kindString, str: func(e types.CodexUsageEntry) string { return e.Model }},
+	"project":      {kind: kindString, str: projectName},
+	"project_path": {kind: kindString, str: func(e types.CodexUsageEntry) string { return e.ProjectPath }},
+	"repo":         {kind: kindString, str: func(e types.CodexUsageEntry) string { return e.RepoURL }},
+	"session":      {kind: kindString, str: func(e types.CodexUsageEntry) string { return e.SessionID }},
+	"request":      {kind: kindString, str: func(e types.CodexUsageEntry) string { return e.RequestID }},
+	"source":       {kind: kindString, str: func(e types.CodexUsageEntry) string { return e.Source }},
+	"user":         {kind: kindString, str: func(e types.CodexUsageEntry) string { return e.User }},
+	"provider":     {kind: kindString, str: func(e types.CodexUsageEntry) string { return e.Provider }},
+	"agent": {kind: kindString, str: func(e types.CodexUsageEntry) string {
+		if e.Agent == "" {
+			return types.AgentCodex
+		}
+		return e.Agent
+	}},
+	"file":       {kind: kindString, str: func(e types.CodexUsageEntry) string { return e.File }},
+	"provenance": {kind: kindString, str: func(e types.CodexUsageEntry) string { return string(e.Provenance) }},
+	"date":       {kind: kindString, str: func(e types.CodexUsageEntry) string { return e.Timestamp.Local().Format("2006-01-02") }},
+	"weekday": {kind: kindString, str: func(e types.CodexUsageEntry) string {
+		return strings.ToLower(e.Timestamp.Local().Weekday().String()[:3])
+	}},
+	"hour":                  {kind: kindNumber, num: func(e types.CodexUsageEntry) float64 { return float64(e.Timestamp.Local().Hour()) }},
+	"cost":                  {kind: kindNumber, num: func(e types.CodexUsageEntry) float64 { return e.Cost }},
+	"input_tokens":          {kind: kindNumber, num: func(e types.CodexUsageEntry) float64 { return float64(e.Usage.PromptTokens) }},
+	"output_tokens":         {kind: kindNumber, num: func(e types.CodexUsageEntry) float64 { return float64(e.Usage.CompletionTokens) }},
+	"cache_creation_tokens": {kind: kindNumber, num: func(e types.CodexUsageEntry) float64 { return float64(e.Usage.CacheCreationTokens) }},
+	"cache_read_tokens":     {kind: kindNumber, num: func(e types.CodexUsageEntry) float64 { return float64(e.Usage.CacheReadTokens) }},
+	"tokens":                {kind: kindNumber, num: func(e types.CodexUsageEntry) float64 { return float64(e.Usage.TotalTokens) }},
+	"duration_ms":           {kind: kindNumber, num: func(e types.CodexUsageEntry) float64 { return float64(e.Duration) }},
+	"line":                  {kind: kindNumber, num: func(e types.CodexUsageEntry) float64 { return float64(e.Line) }},
+	"estimated":             {kind: kindBool, truthy: func(e types.CodexUsageEntry) bool { return e.Estimated }},
+}
+
+// Fields returns the names of the fields expressions can use
+func Fields() []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// projectName is the last element of the project path, e.g. "api" for /home/me/src/api
+func projectName(e types.CodexUsageEntry) string {
+	if e.ProjectPath == "" {
+		return ""
+	}
+	return filepath.Base(e.ProjectPath)
+}
+
+// Parse compiles a filter expression such as
+//
+//	model =~ "gpt-5.*" and project == "api" and cost > 0.05
+//
+// into a predicate. An empty expression matches every entry.
+func Parse(expr string) (Predicate, error) {
+	if strings.TrimSpace(expr) == "" {
+		return func(types.CodexUsageEntry) bool { return true }, nil
+	}
+
+	tokens, err := lex(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{expr: expr, tokens: tokens}
+	pred, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, p.errorf(tok, "unexpected %s", describe(tok))
+	}
+	return pred, nil
+}
+
+// Apply filters entries in place and returns the ones matching the predicate
+func Apply(entries []types.CodexUsageEntry, pred Predicate) []types.CodexUsageEntry {
+	matched := entries[:0]
+	for _, entry := range entries {
+		if pred(entry) {
+			matched = append(matched, entry)
+		}
+	}
+	return matched
+}
+
+// parser is a recursive-descent parser over the token stream:
+//
+//	or         = and { ("or" | "||") and }
+//	and        = unary { ("and" | "&&") unary }
+//	unary      = ("not" | "!") unary | "(" or ")" | comparison
+//	comparison = field [ op value | "in" "(" value { "," value } ")" ]
+type parser struct {
+	expr   string
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) errorf(tok token, format string, args ...interface{}) error {
+	return newError(p.expr, tok.pos, format, args...)
+}
+
+// keyword reports whether the next token is one of the given operators or keywords
+func (p *parser) keyword(words ...string) bool {
+	tok := p.peek()
+	if tok.kind != tokenOp && tok.kind != tokenIdent {
+		return false
+	}
+	for _, w := range words {
+		if strings.EqualFold(tok.text, w) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) parseOr() (Predicate, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or", "||") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(e types.CodexUsageEntry) bool { return l(e) || right(e) }
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Predicate, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and", "&&") {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(e types.CodexUsageEntry) bool { return l(e) && right(e) }
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (Predicate, error) {
+	if p.keyword("not", "!") {
+		p.next()
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(e types.CodexUsageEntry) bool { return !inner(e) }, nil
+	}
+
+	tok := p.peek()
+	if tok.kind == tokenLParen {
+		p.next()
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, p.errorf(closing, "expected \")\" to close the group opened at position %d, found %s", tok.pos+1, describe(closing))
+		}
+		return inner, nil
+	}
+
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (Predicate, error) {
+	tok := p.next()
+	if tok.kind != tokenIdent {
+		return nil, p.errorf(tok, "expected a field name, found %s", describe(tok))
+	}
+	name := strings.ToLower(tok.text)
+	f, ok := fields[name]
+	if !ok {
+		return nil, p.errorf(tok, "unknown field %q (available: %s)", tok.text, strings.Join(Fields(), ", "))
+	}
+
+	// A boolean field on its own is a condition
+	opTok := p.peek()
+	if f.kind == kindBool && opTok.text != "==" && opTok.text != "!=" {
+		return f.truthy, nil
+	}
+
+	if p.keyword("in") {
+		p.next()
+		return p.parseIn(name, f)
+	}
+	if opTok.kind != tokenOp || opTok.text == "!" || opTok.text == "&&" || opTok.text == "||" {
+		return nil, p.errorf(opTok, "expected an operator after %q (==, !=, <, <=, >, >=, =~, !~ or in), found %s", tok.text, describe(opTok))
+	}
+	p.next()
+
+	valueTok := p.next()
+	switch f.kind {
+	case kindString:
+		return p.stringComparison(name, f, opTok, valueTok)
+	case kindNumber:
+		return p.numberComparison(name, f, opTok, valueTok)
+	default:
+		return p.boolComparison(name, f, opTok, valueTok)
+	}
+}
+
+func (p *parser) stringComparison(name string, f field, op, value token) (Predicate, error) {
+	if value.kind != tokenString {
+		return nil, p.errorf(value, "%s is a string field, expected a quoted string after %q, found %s", name, op.text, describe(value))
+	}
+	want := value.text
+
+	switch op.text {
+	case "=~", "!~":
+		re, err := regexp.Compile(want)
+		if err != nil {
+			return nil, p.errorf(value, "invalid regular expression: %v", err)
+		}
+		negate := op.text == "!~"
+		return func(e types.CodexUsageEntry) bool { return re.MatchString(f.str(e)) != negate }, nil
+	case "==":
+		return func(e types.CodexUsageEntry) bool { return f.str(e) == want }, nil
+	case "!=":
+		return func(e types.CodexUsageEntry) bool { return f.str(e) != want }, nil
+	case "<":
+		return func(e types.CodexUsageEntry) bool { return f.str(e) < want }, nil
+	case "<=":
+		return func(e types.CodexUsageEntry) bool { return f.str(e) <= want }, nil
+	case ">":
+		return func(e types.CodexUsageEntry) bool { return f.str(e) > want }, nil
+	case ">=":
+		return func(e types.CodexUsageEntry) bool { return f.str(e) >= want }, nil
+	}
+	return nil, p.errorf(op, "operator %q is not supported for string field %s", op.text, name)
+}
+
+func (p *parser) numberComparison(name string, f field, op, value token) (Predicate, error) {
+	if value.kind != tokenNumber {
+		return nil, p.errorf(value, "%s is a number field, expected a number after %q, found %s", name, op.text, describe(value))
+	}
+	want, err := strconv.ParseFloat(value.text, 64)
+	if err != nil {
+		return nil, p.errorf(value, "invalid number %q", value.text)
+	}
+
+	switch op.text {
+	case "==":
+		return func(e types.CodexUsageEntry) bool { return f.num(e) == want }, nil
+	case "!=":
+		return func(e types.CodexUsageEntry) bool { return f.num(e) != want }, nil
+	case "<":
+		return func(e types.CodexUsageEntry) bool { return f.num(e) < want }, nil
+	case "<=":
+		return func(e types.CodexUsageEntry) bool { return f.num(e) <= want }, nil
+	case ">":
+		return func(e types.CodexUsageEntry) bool { return f.num(e) > want }, nil
+	case ">=":
+		return func(e types.CodexUsageEntry) bool { return f.num(e) >= want }, nil
+	}
+	return nil, p.errorf(op, "operator %q is not supported for number field %s", op.text, name)
+}
+
+func (p *parser) boolComparison(name string, f field, op, value token) (Predicate, error) {
+	if value.kind != tokenIdent || (value.text != "true" && value.text != "false") {
+		return nil, p.errorf(value, "%s is a boolean field, expected true or false after %q, found %s", name, op.text, describe(value))
+	}
+	want := value.text == "true"
+
+	switch op.text {
+	case "==":
+		return func(e types.CodexUsageEntry) bool { return f.truthy(e) == want }, nil
+	case "!=":
+		return func(e types.CodexUsageEntry) bool { return f.truthy(e) != want }, nil
+	}
+	return nil, p.errorf(op, "operator %q is not supported for boolean field %s", op.text, name)
+}
+
+// parseIn parses the value list of an "in" condition
+func (p *parser) parseIn(name string, f field) (Predicate, error) {
+	if open := p.next(); open.kind != tokenLParen {
+		return nil, p.errorf(open, "expected \"(\" after in, found %s", describe(open))
+	}
+
+	var strs []string
+	var nums []float64
+	for {
+		value := p.next()
+		switch {
+		case f.kind == kindString && value.kind == tokenString:
+			strs = append(strs, value.text)
+		case f.kind == kindNumber && value.kind == tokenNumber:
+			n, err := strconv.ParseFloat(value.text, 64)
+			if err != nil {
+				return nil, p.errorf(value, "invalid number %q", value.text)
+			}
+			nums = append(nums, n)
+		default:
+			return nil, p.errorf(value, "expected a %s value in the list for %s, found %s", f.kind, name, describe(value))
+		}
+
+		sep := p.next()
+		if sep.kind == tokenRParen {
+			break
+		}
+		if sep.kind != tokenComma {
+			return nil, p.errorf(sep, "expected \",\" or \")\" in the list, found %s", describe(sep))
+		}
+	}
+
+	if f.kind == kindString {
+		return func(e types.CodexUsageEntry) bool {
+			v := f.str(e)
+			for _, s := range strs {
+				if v == s {
+					return true
+				}
+			}
+			return false
+		}, nil
+	}
+	return func(e types.CodexUsageEntry) bool {
+		v := f.num(e)
+		for _, n := range nums {
+			if v == n {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// describe names a token for error messages
+func describe(tok token) string {
+	switch tok.kind {
+	case tokenEOF:
+		return "end of expression"
+	case tokenString:
+		return fmt.Sprintf("string %q", tok.text)
+	default:
+		return fmt.Sprintf("%q", tok.text)
+	}
+}
@@ -0,0 +1,102 @@
+package filter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/johanneserhardt/cxusage/internal/types"
+)
+
+func filterTestEntry() types.CodexUsageEntry {
+	return types.CodexUsageEntry{
+		Timestamp:   time.Date(2026, 10, 18, 12, 0, 0, 0, time.Local),
+		SessionID:   "sess-1",
+		Model:       "gpt-5-codex",
+		ProjectPath: "/home/me/src/api",
+		Usage:       types.Usage{PromptTokens: 1000, CompletionTokens: 200, TotalTokens: 1200},
+		Cost:        0.08,
+		Estimated:   true,
+	}
+}
+
+func TestParseMatches(t *testing.T) {
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`model =~ "gpt-5.*" and project == "api" and cost > 0.05`, true},
+		{`model =~ "^gpt-4"`, false},
+		{`model !~ "^gpt-4"`, true},
+		{`project == "src"`, false},
+		{`project_path == "/home/me/src/api"`, true},
+		{`tokens >= 1200 && input_tokens < 1000`, false},
+		{`cost > 1 or output_tokens == 200`, true},
+		{`not (cost > 1 or tokens < 100)`, true},
+		{`estimated`, true},
+		{`!estimated`, false},
+		{`estimated == false or model in ("gpt-5-codex", "o3")`, true},
+		{`hour in (9, 10)`, false},
+		{`date >= "2026-10-01" and date <= '2026-10-31'`, true},
+		{`MODEL == "gpt-5-codex" AND Cost > 0`, true},
+		{``, true},
+	}
+
+	entry := filterTestEntry()
+	for _, tt := range tests {
+		pred, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.expr, err)
+			continue
+		}
+		if got := pred(entry); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+		pos  int
+	}{
+		{`modle == "x"`, `unknown field "modle"`, 0},
+		{`cost > "cheap"`, "cost is a number field", 7},
+		{`model == 5`, "model is a string field", 9},
+		{`model =~ "("`, "invalid regular expression", 9},
+		{`model == "x" and`, "expected a field name, found end of expression", 16},
+		{`(cost > 1`, `expected ")"`, 9},
+		{`model "x"`, "expected an operator", 6},
+		{`model == "x`, "unterminated string", 9},
+		{`cost > 1 cost`, `unexpected "cost"`, 9},
+		{`cost @ 1`, "unexpected character", 5},
+	}
+
+	for _, tt := range tests {
+		_, err := Parse(tt.expr)
+		if err == nil {
+			t.Errorf("%s: expected an error", tt.expr)
+			continue
+		}
+		perr, ok := err.(*Error)
+		if !ok {
+			t.Errorf("%s: expected *Error, got %T", tt.expr, err)
+			continue
+		}
+		if !strings.Contains(perr.Msg, tt.want) || perr.Pos != tt.pos {
+			t.Errorf("%s: got %q at %d, want %q at %d", tt.expr, perr.Msg, perr.Pos, tt.want, tt.pos)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	cheap := filterTestEntry()
+	cheap.Cost = 0.01
+	entries := []types.CodexUsageEntry{filterTestEntry(), cheap}
+
+	pred, _ := Parse("cost > 0.05")
+	if got := Apply(entries, pred); len(got) != 1 || got[0].Cost != 0.08 {
+		t.Errorf("unexpected result %+v", got)
+	}
+}
@@ -0,0 +1,55 @@
+package jsonl
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// MaxLineSize is the longest line the log parsers read; longer lines, e.g. with whole tool
+// results, are skipped
+const MaxLineSize = 10 * 1024 * 1024
+
+// Scan calls fn with every non-empty line of a JSONL file and its line number. Lines
+// longer than MaxLineSize are skipped, so one huge message does not hide the rest of the file.
+func Scan(r io.Reader, fn func(lineNum int, line string)) error {
+	reader := bufio.NewReaderSize(r, 64*1024)
+
+	for lineNum := 1; ; lineNum++ {
+		raw, tooLong, err := ReadLine(reader, MaxLineSize)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if tooLong {
+			continue
+		}
+		if line := strings.TrimSpace(string(raw)); line != "" {
+			fn(lineNum, line)
+		}
+	}
+}
+
+// ReadLine reads the next line without its line ending. A line longer than max is read
+// to its end and reported as too long instead of returned.
+func ReadLine(reader *bufio.Reader, max int) ([]byte, bool, error) {
+	var line []byte
+	tooLong := false
+	for {
+		chunk, more, err := reader.ReadLine()
+		if err != nil {
+			return nil, false, err
+		}
+		if !tooLong && len(line)+len(chunk) > max {
+			tooLong, line = true, nil
+		}
+		if !tooLong {
+			line = append(line, chunk...)
+		}
+		if !more {
+			return line, tooLong, nil
+		}
+	}
+}
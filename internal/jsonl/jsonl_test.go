@@ -0,0 +1,44 @@
+package jsonl
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestScanSkipsOverlongLines(t *testing.T) {
+	content := "{\"a\":1}\n\n" + strings.Repeat("x", MaxLineSize+1) + "\n  {\"b\":2}  \r\n{\"c\":3}"
+
+	var lines []int
+	var texts []string
+	err := Scan(strings.NewReader(content), func(lineNum int, line string) {
+		lines = append(lines, lineNum)
+		texts = append(texts, line)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Empty and overlong lines are skipped but still counted
+	if len(lines) != 3 || lines[0] != 1 || lines[1] != 4 || lines[2] != 5 {
+		t.Fatalf("got lines %v", lines)
+	}
+	if texts[1] != `{"b":2}` || texts[2] != `{"c":3}` {
+		t.Errorf("got texts %q", texts)
+	}
+}
+
+func TestReadLine(t *testing.T) {
+	reader := bufio.NewReaderSize(strings.NewReader("12345\n123456789\n12\n"), 16)
+	for _, want := range []struct {
+		line    string
+		tooLong bool
+	}{{"12345", false}, {"", true}, {"12", false}} {
+		line, tooLong, err := ReadLine(reader, 8)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(line) != want.line || tooLong != want.tooLong {
+			t.Errorf("got %q (too long %v), want %q (too long %v)", line, tooLong, want.line, want.tooLong)
+		}
+	}
+}
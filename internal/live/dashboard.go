@@ -7,7 +7,9 @@ import (
     "time"
 
     "github.com/charmbracelet/lipgloss"
+    "github.com/johanneserhardt/cxusage/internal/aggregate"
     "github.com/johanneserhardt/cxusage/internal/blocks"
+    "github.com/johanneserhardt/cxusage/internal/codex"
     "github.com/johanneserhardt/cxusage/internal/types"
     "github.com/johanneserhardt/cxusage/internal/utils"
 )
@@ -22,6 +24,7 @@ const (
 	UsageEmoji = "🔥"
 	ProjectionEmoji = "📈"
 	ModelsEmoji = "⚙️"
+	LimitsEmoji = "⏱️"
 	RefreshEmoji = "🔄"
 )
 
@@ -29,6 +32,8 @@ const (
 type DashboardRenderer struct {
 	width int
 	tokenLimit int
+	rateLimits *types.RateLimitSnapshot
+	projection *types.BlockProjection
 }
 
 // NewDashboardRenderer creates a new dashboard renderer
@@ -39,6 +44,16 @@ func NewDashboardRenderer(tokenLimit int) *DashboardRenderer {
 	}
 }
 
+// SetRateLimits sets the latest plan rate-limit snapshot reported by Codex CLI
+func (d *DashboardRenderer) SetRateLimits(snapshot *types.RateLimitSnapshot) {
+	d.rateLimits = snapshot
+}
+
+// SetProjection sets a precomputed projection for the active block
+func (d *DashboardRenderer) SetProjection(projection *types.BlockProjection) {
+	d.projection = projection
+}
+
 // RenderFullDashboard renders the complete ccusage-style dashboard with dual views
 func (d *DashboardRenderer) RenderFullDashboard(block *types.SessionBlock, now time.Time) {
 	// Move cursor to top without clearing (reduces flicker)
@@ -48,11 +63,14 @@ func (d *DashboardRenderer) RenderFullDashboard(block *types.SessionBlock, now t
 	projectData := ExtractProjectUsageData(block)
 	
 	// Render header
-	d.renderHeader()
+	d.renderHeader(&block.Estimation)
 	
 	// Render session section
 	d.renderSessionSection(block, now)
 	
+	// Render real plan limits when Codex reported them
+	d.renderRateLimitSection(now)
+	
 	// Render global usage section
 	d.renderGlobalUsageSection(projectData.GlobalBlock, now)
 	
@@ -60,7 +78,7 @@ func (d *DashboardRenderer) RenderFullDashboard(block *types.SessionBlock, now t
 	d.renderProjectUsageSection(projectData.ProjectBlock, projectData.ProjectName, now)
 	
 	// Render projection section (based on global data)
-	d.renderProjectionSection(block)
+	d.renderProjectionSection(block, now)
 	
 	// Render models section
 	d.renderModelsSection(block)
@@ -74,7 +92,7 @@ func (d *DashboardRenderer) RenderWaitingState(now time.Time) {
 	// Move cursor to top without clearing (reduces flicker)
 	fmt.Print("\033[H")
 	
-	d.renderHeader()
+	d.renderHeader(nil)
 	
 	// Waiting message
     waitingTitle := "⏳ WAITING FOR CODEX CLI ACTIVITY..."
@@ -101,9 +119,12 @@ func (d *DashboardRenderer) RenderWaitingState(now time.Time) {
 	d.renderFooter()
 }
 
-// renderHeader renders the dashboard header
-func (d *DashboardRenderer) renderHeader() {
-    title := "CODEX CLI - LIVE USAGE MONITOR (~estimated)"
+// renderHeader renders the dashboard header, with the estimated share of the block
+func (d *DashboardRenderer) renderHeader(est *types.Estimation) {
+    title := "CODEX CLI - LIVE USAGE MONITOR"
+    if est != nil && utils.FormatEstimated(*est) != "-" {
+        title += " (" + utils.FormatEstimated(*est) + " estimated)"
+    }
     titleStyled := utils.BoldWhite(title)
     padding := (d.width - lipgloss.Width(titleStyled)) / 2
 	
@@ -170,10 +191,11 @@ func (d *DashboardRenderer) renderGlobalUsageSection(block *types.SessionBlock,
 	elapsed := now.Sub(block.StartTime)
 	burnRate := float64(block.TotalTokens) / elapsed.Minutes()
 	
-	// Calculate usage percentage against limit
+	// Calculate usage percentage against limit; without one there is nothing to measure against
 	var usagePercent float64
 	var usageColorName string
 	var status string
+	hasLimit := true
 	
 	if d.tokenLimit > 0 {
 		usagePercent = float64(block.TotalTokens) / float64(d.tokenLimit) * 100
@@ -190,8 +212,12 @@ func (d *DashboardRenderer) renderGlobalUsageSection(block *types.SessionBlock,
 			usageColorName = "green"
 			status = "NORMAL"
 		}
+	} else if d.rateLimits != nil && d.rateLimits.Primary != nil {
+		// Use the real plan usage reported by Codex CLI
+		usagePercent = codex.CurrentUsedPercent(d.rateLimits.Primary, now)
+		usageColorName, status = usageSeverity(usagePercent)
 	} else {
-		usagePercent = math.Min(float64(block.TotalTokens) / 50000 * 100, 100)
+		hasLimit = false
 		usageColorName = "green"
 		status = "TRACKING"
 	}
@@ -204,7 +230,10 @@ func (d *DashboardRenderer) renderGlobalUsageSection(block *types.SessionBlock,
 		utils.FormatNumber(d.tokenLimit))
 	
 	if d.tokenLimit == 0 {
-		usagePercentText = fmt.Sprintf("%s tokens", utils.FormatNumber(block.TotalTokens))
+		usagePercentText = fmt.Sprintf("%s tokens (no plan data)", utils.FormatNumber(block.TotalTokens))
+		if d.rateLimits != nil && d.rateLimits.Primary != nil {
+			usagePercentText = fmt.Sprintf("%.1f%% of plan limit (%s tokens)", usagePercent, utils.FormatNumber(block.TotalTokens))
+		}
 	}
 	
     headerPadding := d.width - lipgloss.Width(utils.BoldWhite(usageTitle)) - lipgloss.Width(utils.BoldWhite(usagePercentText)) - 2
@@ -250,11 +279,81 @@ func (d *DashboardRenderer) renderGlobalUsageSection(block *types.SessionBlock,
 	fmt.Printf("│ %s%s │\n", usageDetails, strings.Repeat(" ", detailsPadding))
 	
 	// Usage progress bar
-	d.renderProgressBar(usagePercent/100, usageColorName, "USAGE")
+	if hasLimit {
+		d.renderProgressBar(usagePercent/100, usageColorName, "USAGE")
+	}
 	
 	d.renderSectionBorder()
 }
 
+// renderRateLimitSection renders the plan rate-limit windows reported by Codex CLI
+func (d *DashboardRenderer) renderRateLimitSection(now time.Time) {
+	if d.rateLimits == nil {
+		return
+	}
+	
+	d.renderRateLimitWindow("PRIMARY", d.rateLimits.Primary, now)
+	d.renderRateLimitWindow("SECONDARY", d.rateLimits.Secondary, now)
+}
+
+// renderRateLimitWindow renders one rate-limit window with its reset countdown
+func (d *DashboardRenderer) renderRateLimitWindow(name string, window *types.RateLimitWindow, now time.Time) {
+	if window == nil {
+		return
+	}
+	
+	usedPercent := codex.CurrentUsedPercent(window, now)
+	colorName, _ := usageSeverity(usedPercent)
+	
+	// Window header
+	limitTitle := fmt.Sprintf("%s PLAN LIMIT (%s, %s)", LimitsEmoji, name, utils.FormatWindowLabel(window.WindowMinutes))
+	limitPercentText := fmt.Sprintf("%.1f%% used", usedPercent)
+	
+	headerPadding := d.width - lipgloss.Width(utils.BoldWhite(limitTitle)) - lipgloss.Width(utils.BoldWhite(limitPercentText)) - 2
+	if headerPadding < 0 {
+		headerPadding = 0
+	}
+	fmt.Printf("│ %s%s%s │\n", 
+		utils.BoldWhite(limitTitle),
+		strings.Repeat(" ", headerPadding),
+		utils.BoldWhite(limitPercentText))
+	
+	// Reset countdown
+	resetInfo := "Reset time unknown"
+	if window.ResetsAt != nil {
+		if now.Before(*window.ResetsAt) {
+			resetInfo = fmt.Sprintf("Resets in %s (%s)  Reported: %s",
+				utils.Cyan(utils.FormatCountdown(window.ResetsAt.Sub(now))),
+				utils.Gray(window.ResetsAt.Local().Format("Mon 03:04 PM")),
+				utils.Gray(d.rateLimits.Timestamp.Local().Format("03:04:05 PM")))
+		} else {
+			resetInfo = fmt.Sprintf("Window reset at %s", utils.Gray(window.ResetsAt.Local().Format("Mon 03:04 PM")))
+		}
+	}
+	
+	resetPadding := d.width - lipgloss.Width(resetInfo) - 2
+	if resetPadding < 0 {
+		resetPadding = 0
+	}
+	fmt.Printf("│ %s%s │\n", resetInfo, strings.Repeat(" ", resetPadding))
+	
+	d.renderProgressBar(usedPercent/100, colorName, name)
+	
+	d.renderSectionBorder()
+}
+
+// usageSeverity maps a usage percentage onto a color and status label
+func usageSeverity(percent float64) (colorName string, status string) {
+	switch {
+	case percent > 80:
+		return "red", "HIGH"
+	case percent > 50:
+		return "yellow", "MODERATE"
+	default:
+		return "green", "NORMAL"
+	}
+}
+
 // renderProjectUsageSection renders the project-specific token usage section
 func (d *DashboardRenderer) renderProjectUsageSection(projectBlock *types.SessionBlock, projectName string, now time.Time) {
 	elapsed := now.Sub(projectBlock.StartTime)
@@ -281,7 +380,6 @@ func (d *DashboardRenderer) renderProjectUsageSection(projectBlock *types.Sessio
 			status = "NORMAL"
 		}
 	} else {
-		usagePercent = math.Min(float64(projectBlock.TotalTokens) / 25000 * 100, 100)
 		usageColorName = "green"
 		status = "TRACKING"
 	}
@@ -333,15 +431,20 @@ func (d *DashboardRenderer) renderProjectUsageSection(projectBlock *types.Sessio
 	}
 	fmt.Printf("│ %s%s │\n", usageDetails, strings.Repeat(" ", detailsPadding))
 	
-	// Project usage progress bar
-	d.renderProgressBar(usagePercent/100, usageColorName, "PROJECT")
+	// Project usage progress bar, only against a token limit
+	if d.tokenLimit > 0 {
+		d.renderProgressBar(usagePercent/100, usageColorName, "PROJECT")
+	}
 	
 	d.renderSectionBorder()
 }
 
 // renderProjectionSection renders the projection section
-func (d *DashboardRenderer) renderProjectionSection(block *types.SessionBlock) {
-	projection := blocks.CalculateProjections(block)
+func (d *DashboardRenderer) renderProjectionSection(block *types.SessionBlock, now time.Time) {
+	projection := d.projection
+	if projection == nil {
+		projection = blocks.CalculateProjections(block)
+	}
 	if projection == nil {
 		return
 	}
@@ -367,11 +470,16 @@ func (d *DashboardRenderer) renderProjectionSection(block *types.SessionBlock) {
 			status = "✅ WITHIN LIMIT"
 		}
 	} else {
-		projectionPercent = math.Min(float64(projection.ProjectedTokens) / 100000 * 100, 100)
 		projectionColorName = "green"
 		status = "📊 PROJECTED"
 	}
 	
+	// A predicted exhaustion time overrides the status
+	if projection.LimitReachedAt != nil {
+		projectionColorName = "red"
+		status = "❌ WILL EXCEED LIMIT"
+	}
+	
 	// Projection header
 	projectionTitle := fmt.Sprintf("%s PROJECTION", ProjectionEmoji)
 	projectionPercentText := fmt.Sprintf("%.1f%% (%s/%s)", 
@@ -416,8 +524,39 @@ func (d *DashboardRenderer) renderProjectionSection(block *types.SessionBlock) {
     }
 	fmt.Printf("│ %s%s │\n", projectionDetails, strings.Repeat(" ", detailsPadding))
 	
-	// Projection progress bar
-	d.renderProgressBar(projectionPercent/100, projectionColorName, "PROJECTION")
+	// Projection range and strategy
+	rangeDetails := fmt.Sprintf("Range: %s - %s tokens (%s - %s)  Burn Rate: %s token/min  Strategy: %s",
+		utils.FormatNumber(projection.ProjectedTokensLow),
+		utils.FormatNumber(projection.ProjectedTokensHigh),
+		utils.FormatCurrency(projection.ProjectedCostLow),
+		utils.FormatCurrency(projection.ProjectedCostHigh),
+		utils.Yellow(fmt.Sprintf("%.0f", projection.BurnRate)),
+		utils.Gray(string(projection.Strategy)))
+	
+	rangePadding := d.width - lipgloss.Width(rangeDetails) - 2
+	if rangePadding < 0 {
+		rangePadding = 0
+	}
+	fmt.Printf("│ %s%s │\n", rangeDetails, strings.Repeat(" ", rangePadding))
+	
+	// Estimated time the limit is hit
+	if projection.LimitReachedAt != nil {
+		limitDetails := fmt.Sprintf("⏰ %s at %s (in %s)",
+			utils.FormatLimitType(projection.LimitType),
+			utils.Red(projection.LimitReachedAt.Local().Format("03:04 PM")),
+			utils.FormatCountdown(projection.LimitReachedAt.Sub(now)))
+		
+		limitPadding := d.width - lipgloss.Width(limitDetails) - 2
+		if limitPadding < 0 {
+			limitPadding = 0
+		}
+		fmt.Printf("│ %s%s │\n", limitDetails, strings.Repeat(" ", limitPadding))
+	}
+	
+	// Projection progress bar, only against a token limit
+	if d.tokenLimit > 0 {
+		d.renderProgressBar(projectionPercent/100, projectionColorName, "PROJECTION")
+	}
 	
 	d.renderSectionBorder()
 }
@@ -437,6 +576,20 @@ func (d *DashboardRenderer) renderModelsSection(block *types.SessionBlock) {
     fmt.Printf("│ %s%s │\n", 
         utils.BoldWhite(modelsTitle),
         strings.Repeat(" ", modelsPadding))
+
+	// Split the block by coding agent when it combines several
+	if groups := aggregate.GroupBy(block.Entries, []types.Dimension{types.DimensionAgent}); len(groups) > 1 {
+		var agents []string
+		for _, group := range groups {
+			agents = append(agents, fmt.Sprintf("%s %s (%s tokens)", group.Values[0], d.formatCost(group.TotalCost), utils.FormatNumber(group.TotalTokens)))
+		}
+		agentsTitle := "   Agents: " + strings.Join(agents, "  •  ")
+		agentsPadding := d.width - lipgloss.Width(agentsTitle) - 2
+		if agentsPadding < 0 {
+			agentsPadding = 0
+		}
+		fmt.Printf("│ %s%s │\n", agentsTitle, strings.Repeat(" ", agentsPadding))
+	}
 	
 	d.renderSectionBorder()
 }
@@ -24,6 +24,9 @@ const (
 	
 	// MaxRefreshInterval is the maximum allowed refresh interval
 	MaxRefreshInterval = 60 * time.Second
+	
+	// HistoryRefreshInterval is how often past blocks for historical projections are reloaded
+	HistoryRefreshInterval = 15 * time.Minute
 )
 
 // LiveMonitor handles real-time monitoring of Codex usage
@@ -33,6 +36,13 @@ type LiveMonitor struct {
 	logger     *logrus.Logger
 	ctx        context.Context
 	cancel     context.CancelFunc
+	
+	// Past blocks for historical projections, reloaded periodically
+	history         []types.SessionBlock
+	historyLoadedAt time.Time
+	
+	// Rate-limit snapshots of each log file, re-read only when the file changes
+	rateLimits *codex.RateLimitCache
 }
 
 // NewLiveMonitor creates a new live monitor instance
@@ -45,6 +55,8 @@ func NewLiveMonitor(config *types.LiveMonitoringConfig, cfg *types.Config, logge
 		logger: logger,
 		ctx:    ctx,
 		cancel: cancel,
+		
+		rateLimits: codex.NewRateLimitCache(),
 	}
 }
 
@@ -102,7 +114,7 @@ func (m *LiveMonitor) renderUpdate() error {
 	}
 	
 	// Aggregate into blocks
-	sessionBlocks := blocks.AggregateIntoBlocks(entries, m.config.SessionDurationHours)
+	sessionBlocks := blocks.AggregateIntoBlocksWithMode(entries, m.config.SessionDurationHours, m.config.BlockMode)
 	
 	// Filter to recent blocks only
 	recentBlocks := blocks.FilterRecentBlocks(sessionBlocks, 1) // Last 24 hours
@@ -115,31 +127,63 @@ func (m *LiveMonitor) renderUpdate() error {
 		return nil
 	}
 	
+	// Load the latest plan rate limits (the weekly window can span several days)
+	snapshots, err := m.rateLimits.ParseRateLimitFiles(m.cfg, now.AddDate(0, 0, -7), endTime, m.logger)
+	if err != nil {
+		m.logger.WithError(err).Debug("Failed to load rate-limit data")
+	}
+	
+	// Project the active block with the configured strategy
+	projection := blocks.CalculateProjectionsWithConfig(activeBlock, m.projectionHistory(sessionBlocks, now), m.config.Projection, now)
+	blocks.EstimateLimitExhaustion(projection, activeBlock, m.config.TokenLimit, snapshots, now)
+	
 	// Render active block with projections
-	m.renderActiveBlock(activeBlock, now)
+	m.renderActiveBlock(activeBlock, codex.LatestRateLimitSnapshot(snapshots), projection, now)
 	
 	return nil
 }
 
+// projectionHistory returns past blocks for projections; historical projections need
+// more history than the live window, so it is loaded separately and cached
+func (m *LiveMonitor) projectionHistory(recent []types.SessionBlock, now time.Time) []types.SessionBlock {
+	if m.config.Projection.Strategy != types.ProjectionHistorical {
+		return recent
+	}
+	
+	if m.history == nil || now.Sub(m.historyLoadedAt) > HistoryRefreshInterval {
+		history, err := utils.LoadBlockHistoryFromCodex(m.cfg, blocks.HistoricalProjectionDays, m.config.SessionDurationHours, m.config.BlockMode, m.logger)
+		if err != nil {
+			m.logger.WithError(err).Debug("Failed to load block history")
+			return recent
+		}
+		m.history = history
+		m.historyLoadedAt = now
+	}
+	
+	return m.history
+}
+
 // renderWaitingState renders the display when no active block exists
 func (m *LiveMonitor) renderWaitingState(now time.Time) {
 	// Clear screen and move to top
 	fmt.Print("\033[2J\033[H")
 	
-	dashboard := NewDashboardRenderer(50000) // Default limit for display
+	dashboard := NewDashboardRenderer(0)
 	dashboard.RenderWaitingState(now)
 }
 
 // renderActiveBlock renders the active block with live data and projections
-func (m *LiveMonitor) renderActiveBlock(block *types.SessionBlock, now time.Time) {
-	// Use token limit from config or default
-	tokenLimit := 50000 // Default reference limit
+func (m *LiveMonitor) renderActiveBlock(block *types.SessionBlock, rateLimits *types.RateLimitSnapshot, projection *types.BlockProjection, now time.Time) {
+	// Use token limit from config; without one, real plan limits (if reported) drive the usage bars
+	tokenLimit := 0
 	if m.config.TokenLimit != nil {
 		tokenLimit = *m.config.TokenLimit
 	}
 	
 	// Create and use the beautiful dashboard renderer
 	dashboard := NewDashboardRenderer(tokenLimit)
+	dashboard.SetRateLimits(rateLimits)
+	dashboard.SetProjection(projection)
 	dashboard.RenderFullDashboard(block, now)
 }
 
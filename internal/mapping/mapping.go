@@ -0,0 +1,273 @@
+package mapping
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/johanneserhardt/cxusage/internal/jsonl"
+	"github.com/johanneserhardt/cxusage/internal/pricing"
+	"github.com/johanneserhardt/cxusage/internal/types"
+	"github.com/sirupsen/logrus"
+)
+
+// timestampLayouts are the string timestamps read besides RFC 3339; they are local time
+var timestampLayouts = []string{
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+}
+
+// ParseUsageFiles reads the usage entries of one mapping between the dates
+func ParseUsageFiles(cfg *types.Config, m types.LogMapping, prices *pricing.Table, startDate, endDate time.Time, logger *logrus.Logger) ([]types.CodexUsageEntry, error) {
+	files, err := Files(m.Glob)
+	if err != nil {
+		return nil, fmt.Errorf("mapping %s: %w", m.Name, err)
+	}
+	logger.WithFields(logrus.Fields{"mapping": m.Name, "files_count": len(files)}).Info("Found mapped log files")
+
+	var allEntries []types.CodexUsageEntry
+	for _, file := range files {
+		// Files untouched since the start date cannot contain entries in range
+		if info, err := os.Stat(file); err == nil && info.ModTime().Before(startDate) {
+			continue
+		}
+
+		entries, err := parseFile(file, m, prices, startDate, endDate)
+		if err != nil {
+			logger.WithError(err).WithField("file", filepath.Base(file)).Warn("Failed to parse mapped log file")
+			continue
+		}
+		for i := range entries {
+			entries[i].User = cfg.User
+		}
+		allEntries = append(allEntries, entries...)
+	}
+	return allEntries, nil
+}
+
+// Files returns the files matching a glob, sorted. A leading ~ is the home directory and
+// ** matches any number of directories, e.g. ~/gateway/**/*.jsonl.
+func Files(glob string) ([]string, error) {
+	if glob == "~" || strings.HasPrefix(glob, "~/") {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		glob = filepath.Join(homeDir, glob[1:])
+	}
+	glob = filepath.Clean(glob)
+
+	i := strings.Index(glob, "**")
+	if i < 0 {
+		files, err := filepath.Glob(glob)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob %q: %w", glob, err)
+		}
+		sort.Strings(files)
+		return files, nil
+	}
+
+	// Walk the directory before ** and match the rest against the path below it
+	root := filepath.Clean(glob[:i])
+	rest := strings.TrimPrefix(glob[i+2:], string(filepath.Separator))
+	if _, err := filepath.Match(rest, ""); err != nil {
+		return nil, fmt.Errorf("invalid glob %q: %w", glob, err)
+	}
+	var files []string
+	_ = filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil // skip unreadable entries
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil
+		}
+		// The rest matches the last elements of the path, at any depth
+		parts := strings.Split(rel, string(filepath.Separator))
+		want := strings.Count(rest, string(filepath.Separator)) + 1
+		if len(parts) >= want {
+			if ok, _ := filepath.Match(rest, filepath.Join(parts[len(parts)-want:]...)); ok {
+				files = append(files, path)
+			}
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, nil
+}
+
+// parseFile reads the entries of one log file. Lines without a timestamp or tokens, and
+// lines too long to read, are skipped.
+func parseFile(filename string, m types.LogMapping, prices *pricing.Table, startDate, endDate time.Time) ([]types.CodexUsageEntry, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []types.CodexUsageEntry
+	err = jsonl.Scan(file, func(lineNum int, line string) {
+		var record interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			return
+		}
+		entry, ok := mapEntry(record, m)
+		if !ok || entry.Timestamp.Before(startDate) || entry.Timestamp.After(endDate) {
+			return
+		}
+		entry.Provenance = types.ProvenanceExact
+		if entry.Cost == 0 {
+			var priced bool
+			if entry.Cost, priced = prices.Cost(entry.Provider, entry.Model, entry.Usage); !priced {
+				entry.Provenance = types.ProvenanceEstimatedCost
+			}
+		}
+		// Without a request id, records of the same second would share a de-duplication key
+		if entry.RequestID == "" {
+			entry.RequestID = fmt.Sprintf("%s:%d", filename, lineNum)
+		}
+		entry.File = filename
+		entry.Line = lineNum
+		entries = append(entries, entry)
+	})
+	return entries, err
+}
+
+// mapEntry reads the fields of a mapping from one record
+func mapEntry(record interface{}, m types.LogMapping) (types.CodexUsageEntry, bool) {
+	timestamp, ok := timeAt(record, m.Timestamp)
+	if !ok {
+		return types.CodexUsageEntry{}, false
+	}
+
+	usage := types.Usage{
+		PromptTokens:        intAt(record, m.InputTokens),
+		CompletionTokens:    intAt(record, m.OutputTokens),
+		CacheCreationTokens: intAt(record, m.CacheCreationTokens),
+		CacheReadTokens:     intAt(record, m.CacheReadTokens),
+	}
+	usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens + usage.CacheCreationTokens + usage.CacheReadTokens
+	if total := intAt(record, m.TotalTokens); total > usage.TotalTokens {
+		// Like Codex logs with only a total, count tokens that are not split as output
+		if usage.PromptTokens == 0 && usage.CompletionTokens == 0 {
+			usage.CompletionTokens = total - usage.TotalTokens
+		}
+		usage.TotalTokens = total
+	}
+	if usage.TotalTokens == 0 {
+		return types.CodexUsageEntry{}, false
+	}
+
+	cost, _ := floatAt(record, m.Cost)
+	return types.CodexUsageEntry{
+		Timestamp:   timestamp,
+		SessionID:   stringAt(record, m.Session),
+		RequestID:   stringAt(record, m.Request),
+		Model:       stringAt(record, m.Model),
+		Usage:       usage,
+		Cost:        cost,
+		ProjectPath: stringAt(record, m.Project),
+		Source:      m.Name,
+		Provider:    stringAt(record, m.Provider),
+		Agent:       m.Name,
+	}, true
+}
+
+// lookup returns the value at a dot-separated JSON path; numeric elements index arrays
+func lookup(record interface{}, path string) (interface{}, bool) {
+	if path == "" {
+		return nil, false
+	}
+	cur := record
+	for _, key := range strings.Split(path, ".") {
+		switch v := cur.(type) {
+		case map[string]interface{}:
+			next, ok := v[key]
+			if !ok {
+				return nil, false
+			}
+			cur = next
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			cur = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return cur, cur != nil
+}
+
+// stringAt returns the value at a path as a string; numbers are formatted
+func stringAt(record interface{}, path string) string {
+	value, ok := lookup(record, path)
+	if !ok {
+		return ""
+	}
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return ""
+}
+
+// floatAt returns the value at a path as a number; numeric strings are parsed
+func floatAt(record interface{}, path string) (float64, bool) {
+	value, ok := lookup(record, path)
+	if !ok {
+		return 0, false
+	}
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// intAt returns the value at a path as a whole number, 0 if missing
+func intAt(record interface{}, path string) int {
+	f, _ := floatAt(record, path)
+	return int(f)
+}
+
+// timeAt returns the timestamp at a path: an RFC 3339 or local date-time string, or Unix
+// seconds or milliseconds
+func timeAt(record interface{}, path string) (time.Time, bool) {
+	value, ok := lookup(record, path)
+	if !ok {
+		return time.Time{}, false
+	}
+	if s, ok := value.(string); ok {
+		if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+			return t, true
+		}
+		for _, layout := range timestampLayouts {
+			if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+				return t, true
+			}
+		}
+	}
+	seconds, ok := floatAt(record, path)
+	if !ok || seconds <= 0 {
+		return time.Time{}, false
+	}
+	// Milliseconds since the epoch are past the year 33658 as seconds
+	if seconds > 1e12 {
+		return time.UnixMilli(int64(seconds)), true
+	}
+	return time.Unix(0, int64(seconds*1e9)), true
+}
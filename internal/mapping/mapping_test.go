@@ -0,0 +1,143 @@
+package mapping
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/johanneserhardt/cxusage/internal/jsonl"
+	"github.com/johanneserhardt/cxusage/internal/pricing"
+	"github.com/johanneserhardt/cxusage/internal/types"
+	"github.com/sirupsen/logrus"
+)
+
+// An in-house gateway log: nested usage, Unix millisecond and string timestamps, numeric
+// strings, a logged cost, and lines without usage
+const mappingTestLog = `{"ts":1792314000000,"req":{"id":"r1","model":"gpt-5"},"meta":{"team":"api","conn":"c1"},"usage":{"prompt":1000000,"completion":"100000"}}
+{"ts":"2026-10-18T10:00:00Z","req":{"id":"r2","model":"in-house-7b"},"meta":{"conn":"c1"},"usage":{"total":500},"cost_usd":0.25}
+{"ts":"2026-10-18T11:00:00Z","event":"healthcheck"}
+not json
+{"ts":"2025-01-01T00:00:00Z","req":{"model":"gpt-5"},"usage":{"prompt":10}}
+`
+
+var mappingTestMapping = types.LogMapping{
+	Name:         "gateway",
+	Timestamp:    "ts",
+	Model:        "req.model",
+	Request:      "req.id",
+	Session:      "meta.conn",
+	Project:      "meta.team",
+	InputTokens:  "usage.prompt",
+	OutputTokens: "usage.completion",
+	TotalTokens:  "usage.total",
+	Cost:         "cost_usd",
+}
+
+func TestParseUsageFiles(t *testing.T) {
+	dir := t.TempDir()
+	nested := filepath.Join(dir, "2026", "10")
+	if err := os.MkdirAll(nested, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(nested, "gw.jsonl"), []byte(mappingTestLog), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "gw.txt"), []byte(mappingTestLog), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := mappingTestMapping
+	m.Glob = filepath.Join(dir, "**", "*.jsonl")
+	start := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	entries, err := ParseUsageFiles(&types.Config{User: "me"}, m, pricing.Default(), start, start.AddDate(0, 0, 1), logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d: %+v", len(entries), entries)
+	}
+
+	first := entries[0]
+	if !first.Timestamp.Equal(time.UnixMilli(1792314000000)) || first.Model != "gpt-5" || first.RequestID != "r1" ||
+		first.SessionID != "c1" || first.ProjectPath != "api" || first.Agent != "gateway" || first.Source != "gateway" ||
+		first.User != "me" || first.Line != 1 {
+		t.Errorf("unexpected entry: %+v", first)
+	}
+	if first.Usage != (types.Usage{PromptTokens: 1000000, CompletionTokens: 100000, TotalTokens: 1100000}) || first.Cost != 2.25 {
+		t.Errorf("usage %+v cost %v, want 1.1M tokens for $2.25", first.Usage, first.Cost)
+	}
+
+	// Only a total: counted as output, with the logged cost
+	second := entries[1]
+	if second.Usage != (types.Usage{CompletionTokens: 500, TotalTokens: 500}) || second.Cost != 0.25 || second.ProjectPath != "" {
+		t.Errorf("unexpected entry: %+v", second)
+	}
+}
+
+func TestParseRecordsWithoutRequestID(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "gw.jsonl")
+	log := `{"ts":"2026-10-18T10:00:00Z","model":"gpt-5","usage":{"prompt":100}}
+{"ts":"2026-10-18T10:00:00Z","model":"gpt-5","usage":{"prompt":100}}
+`
+	if err := os.WriteFile(path, []byte(log), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := types.LogMapping{Name: "gateway", Glob: path, Timestamp: "ts", Model: "model", InputTokens: "usage.prompt"}
+	start := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	entries, err := ParseUsageFiles(&types.Config{}, m, pricing.Default(), start, start.AddDate(0, 0, 1), logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+
+	// Same session (none) and timestamp: only the file and line tell them apart
+	if entries[0].RequestID != path+":1" || entries[1].RequestID != path+":2" {
+		t.Errorf("expected request ids from file and line, got %q and %q", entries[0].RequestID, entries[1].RequestID)
+	}
+}
+
+func TestParseSkipsOverlongLine(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "gw.jsonl")
+	log := `{"ts":"2026-10-18T10:00:00Z","model":"gpt-5","usage":{"prompt":100}}
+{"ts":"2026-10-18T10:00:01Z","model":"gpt-5","body":"` + strings.Repeat("x", jsonl.MaxLineSize) + `"}
+{"ts":"2026-10-18T10:00:02Z","model":"gpt-5","usage":{"prompt":200}}
+`
+	if err := os.WriteFile(path, []byte(log), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := types.LogMapping{Name: "gateway", Glob: path, Timestamp: "ts", Model: "model", InputTokens: "usage.prompt"}
+	start := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	entries, err := ParseUsageFiles(&types.Config{}, m, pricing.Default(), start, start.AddDate(0, 0, 1), logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Line != 1 || entries[1].Line != 3 {
+		t.Fatalf("expected entries at lines 1 and 3, got %+v", entries)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	record := map[string]interface{}{
+		"choices": []interface{}{map[string]interface{}{"model": "m"}},
+		"n":       float64(3),
+	}
+	if got := stringAt(record, "choices.0.model"); got != "m" {
+		t.Errorf("array path: got %q", got)
+	}
+	if got := stringAt(record, "n"); got != "3" {
+		t.Errorf("number as string: got %q", got)
+	}
+	for _, path := range []string{"", "choices.1.model", "n.x", "missing"} {
+		if _, ok := lookup(record, path); ok {
+			t.Errorf("lookup(%q) found a value", path)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultEndpoint is the OTLP/HTTP endpoint of a collector running on the local machine
+const DefaultEndpoint = "http://localhost:4318"
+
+// Standard OpenTelemetry environment variables honored by the exporter
+const (
+	EndpointEnv = "OTEL_EXPORTER_OTLP_ENDPOINT"
+	HeadersEnv  = "OTEL_EXPORTER_OTLP_HEADERS"
+)
+
+// scopeName identifies the instrumentation scope of exported metrics and spans
+const scopeName = "github.com/johanneserhardt/cxusage"
+
+// Config configures an OTLP/HTTP exporter
+type Config struct {
+	Endpoint       string            // Base URL; /v1/metrics and /v1/traces are appended
+	Headers        map[string]string // Extra request headers, e.g. for authentication
+	Timeout        time.Duration
+	ServiceVersion string
+}
+
+// Exporter sends metrics and traces to an OpenTelemetry collector using OTLP/HTTP with
+// JSON encoding
+type Exporter struct {
+	endpoint string
+	headers  map[string]string
+	client   *http.Client
+	resource resource
+	scope    scope
+}
+
+// NewExporter creates an exporter, falling back to the standard OTEL_EXPORTER_OTLP_*
+// environment variables and the local collector default
+func NewExporter(cfg Config) *Exporter {
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = os.Getenv(EndpointEnv)
+	}
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+
+	headers := ParseHeaders(os.Getenv(HeadersEnv))
+	for k, v := range cfg.Headers {
+		headers[k] = v
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	return &Exporter{
+		endpoint: strings.TrimRight(endpoint, "/"),
+		headers:  headers,
+		client:   &http.Client{Timeout: timeout},
+		resource: resource{Attributes: []keyValue{stringAttr("service.name", "cxusage")}},
+		scope:    scope{Name: scopeName, Version: cfg.ServiceVersion},
+	}
+}
+
+// Endpoint returns the base URL the exporter sends to
+func (e *Exporter) Endpoint() string {
+	return e.endpoint
+}
+
+// ParseHeaders parses headers in the OTEL_EXPORTER_OTLP_HEADERS format (key=value,key2=value2)
+func ParseHeaders(value string) map[string]string {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(k) == "" {
+			continue
+		}
+		headers[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return headers
+}
+
+// exportMetrics sends metrics to the collector
+func (e *Exporter) exportMetrics(ctx context.Context, metrics []metric) error {
+	if len(metrics) == 0 {
+		return nil
+	}
+	req := metricsRequest{ResourceMetrics: []resourceMetrics{{
+		Resource:     e.resource,
+		ScopeMetrics: []scopeMetrics{{Scope: e.scope, Metrics: metrics}},
+	}}}
+	return e.post(ctx, "/v1/metrics", req)
+}
+
+// exportSpans sends spans to the collector
+func (e *Exporter) exportSpans(ctx context.Context, spans []span) error {
+	if len(spans) == 0 {
+		return nil
+	}
+	req := tracesRequest{ResourceSpans: []resourceSpans{{
+		Resource:   e.resource,
+		ScopeSpans: []scopeSpans{{Scope: e.scope, Spans: spans}},
+	}}}
+	return e.post(ctx, "/v1/traces", req)
+}
+
+func (e *Exporter) post(ctx context.Context, path string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode OTLP request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send to OTLP collector: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("OTLP collector rejected %s: %s %s", path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// OTLP JSON encoding (opentelemetry-proto, JSON mapping). Trace and span ids are hex
+// strings and 64-bit integers are decimal strings.
+
+type metricsRequest struct {
+	ResourceMetrics []resourceMetrics `json:"resourceMetrics"`
+}
+
+type resourceMetrics struct {
+	Resource     resource       `json:"resource"`
+	ScopeMetrics []scopeMetrics `json:"scopeMetrics"`
+}
+
+type scopeMetrics struct {
+	Scope   scope    `json:"scope"`
+	Metrics []metric `json:"metrics"`
+}
+
+type metric struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Unit        string `json:"unit,omitempty"`
+	Sum         *sum   `json:"sum,omitempty"`
+}
+
+type sum struct {
+	DataPoints             []numberDataPoint `json:"dataPoints"`
+	AggregationTemporality int               `json:"aggregationTemporality"`
+	IsMonotonic            bool              `json:"isMonotonic"`
+}
+
+// aggregationTemporalityDelta reports each data point as the change since its start time
+const aggregationTemporalityDelta = 1
+
+type numberDataPoint struct {
+	Attributes        []keyValue `json:"attributes,omitempty"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	TimeUnixNano      string     `json:"timeUnixNano"`
+	AsInt             string     `json:"asInt,omitempty"`
+	AsDouble          *float64   `json:"asDouble,omitempty"`
+}
+
+type tracesRequest struct {
+	ResourceSpans []resourceSpans `json:"resourceSpans"`
+}
+
+type resourceSpans struct {
+	Resource   resource     `json:"resource"`
+	ScopeSpans []scopeSpans `json:"scopeSpans"`
+}
+
+type scopeSpans struct {
+	Scope scope  `json:"scope"`
+	Spans []span `json:"spans"`
+}
+
+type span struct {
+	TraceID           string     `json:"traceId"`
+	SpanID            string     `json:"spanId"`
+	ParentSpanID      string     `json:"parentSpanId,omitempty"`
+	Name              string     `json:"name"`
+	Kind              int        `json:"kind"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+	Attributes        []keyValue `json:"attributes,omitempty"`
+}
+
+// spanKindInternal marks spans that do not cross a process boundary
+const spanKindInternal = 1
+
+type resource struct {
+	Attributes []keyValue `json:"attributes"`
+}
+
+type scope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type keyValue struct {
+	Key   string   `json:"key"`
+	Value anyValue `json:"value"`
+}
+
+type anyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+func stringAttr(key, value string) keyValue {
+	return keyValue{Key: key, Value: anyValue{StringValue: &value}}
+}
+
+func intAttr(key string, value int64) keyValue {
+	s := strconv.FormatInt(value, 10)
+	return keyValue{Key: key, Value: anyValue{IntValue: &s}}
+}
+
+func doubleAttr(key string, value float64) keyValue {
+	return keyValue{Key: key, Value: anyValue{DoubleValue: &value}}
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// hexID derives a stable id of n bytes from a value, so re-exporting the same
+// sessions and turns produces the same trace and span ids
+func hexID(value string, n int) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:n])
+}
@@ -0,0 +1,243 @@
+package otlp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/johanneserhardt/cxusage/internal/types"
+)
+
+// collector records the OTLP requests it receives
+type collector struct {
+	mu      sync.Mutex
+	metrics []metricsRequest
+	traces  []tracesRequest
+	headers http.Header
+	fail    bool
+	// failTraces rejects that many trace requests while accepting metrics
+	failTraces int
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.fail {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	if r.URL.Path == "/v1/traces" && c.failTraces > 0 {
+		c.failTraces--
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	c.headers = r.Header.Clone()
+
+	switch r.URL.Path {
+	case "/v1/metrics":
+		var req metricsRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		c.metrics = append(c.metrics, req)
+	case "/v1/traces":
+		var req tracesRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		c.traces = append(c.traces, req)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (c *collector) spans() []span {
+	var spans []span
+	for _, req := range c.traces {
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				spans = append(spans, ss.Spans...)
+			}
+		}
+	}
+	return spans
+}
+
+func otlpTestEntries() []types.CodexUsageEntry {
+	ts := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	return []types.CodexUsageEntry{
+		{Timestamp: ts, SessionID: "s1", RequestID: "r1", Model: "gpt-4o", ProjectPath: "/work/app",
+			Usage: types.Usage{PromptTokens: 100, CompletionTokens: 20, TotalTokens: 120}, Cost: 0.01, Duration: 1500},
+		{Timestamp: ts.Add(time.Minute), SessionID: "s1", RequestID: "r2", Model: "gpt-4o",
+			Usage: types.Usage{PromptTokens: 50, CompletionTokens: 10, TotalTokens: 60}, Cost: 0.005},
+		{Timestamp: ts.Add(2 * time.Minute), SessionID: "s2", RequestID: "r3", Model: "gpt-4o-mini"},
+	}
+}
+
+func TestStreamExportsMetricsAndSessionTraces(t *testing.T) {
+	c := &collector{}
+	server := httptest.NewServer(c)
+	defer server.Close()
+
+	exporter := NewExporter(Config{Endpoint: server.URL, Headers: map[string]string{"Authorization": "Bearer token"}})
+	stream := NewStream(exporter, 30*time.Minute)
+	entries := otlpTestEntries()
+	now := entries[2].Timestamp.Add(time.Minute)
+
+	stats, err := stream.Push(context.Background(), entries, now)
+	if err != nil {
+		t.Fatalf("push failed: %v", err)
+	}
+	if stats.Entries != 3 || stats.Sessions != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	if got := c.headers.Get("Authorization"); got != "Bearer token" {
+		t.Errorf("expected the configured header, got %q", got)
+	}
+
+	metrics := c.metrics[0].ResourceMetrics[0].ScopeMetrics[0].Metrics
+	if len(metrics) != 3 || metrics[0].Name != "cxusage.tokens" || len(metrics[0].Sum.DataPoints) != 6 {
+		t.Fatalf("unexpected metrics %+v", metrics)
+	}
+
+	// Pushing the same entries again sends nothing new
+	if stats, _ := stream.Push(context.Background(), entries, now); stats.Entries != 0 {
+		t.Errorf("expected entries to be exported once, got %d", stats.Entries)
+	}
+
+	stats, err = stream.Flush(context.Background())
+	if err != nil || stats.Sessions != 2 {
+		t.Fatalf("expected two sessions flushed, got %+v (%v)", stats, err)
+	}
+
+	var turns, sessions []span
+	for _, s := range c.spans() {
+		if s.Name == "codex.session" {
+			sessions = append(sessions, s)
+		} else {
+			turns = append(turns, s)
+		}
+	}
+	if len(turns) != 3 || len(sessions) != 2 {
+		t.Fatalf("expected 3 turn spans and 2 session spans, got %d and %d", len(turns), len(sessions))
+	}
+	if turns[0].TraceID != turns[1].TraceID || turns[0].TraceID == turns[2].TraceID {
+		t.Error("expected turns grouped into one trace per session")
+	}
+	if turns[0].StartTimeUnixNano != unixNano(entries[0].Timestamp.Add(-1500*time.Millisecond)) {
+		t.Errorf("expected the turn span to start at the request, got %s", turns[0].StartTimeUnixNano)
+	}
+	for _, s := range sessions {
+		if s.TraceID == turns[0].TraceID && s.SpanID != turns[0].ParentSpanID {
+			t.Error("expected turn spans to be children of the session span")
+		}
+	}
+}
+
+func TestStreamExportsSessionSpanOnce(t *testing.T) {
+	c := &collector{}
+	server := httptest.NewServer(c)
+	defer server.Close()
+
+	stream := NewStream(NewExporter(Config{Endpoint: server.URL}), 30*time.Minute)
+	entries := otlpTestEntries()
+	now := entries[2].Timestamp.Add(time.Hour)
+
+	if stats, err := stream.Push(context.Background(), entries[:1], now); err != nil || stats.Sessions != 1 {
+		t.Fatalf("expected the idle session to be closed, got %+v (%v)", stats, err)
+	}
+
+	// A later turn of the closed session is a child of the exported session span
+	stats, err := stream.Push(context.Background(), entries[1:2], now)
+	if err != nil || stats.Entries != 1 || stats.Sessions != 0 {
+		t.Fatalf("expected only the new turn, got %+v (%v)", stats, err)
+	}
+	if stats, _ := stream.Flush(context.Background()); stats.Sessions != 0 {
+		t.Errorf("expected no session span to flush, got %d", stats.Sessions)
+	}
+
+	var root span
+	var sessions int
+	for _, s := range c.spans() {
+		if s.Name == "codex.session" {
+			root = s
+			sessions++
+		}
+	}
+	if sessions != 1 {
+		t.Fatalf("expected one session span, got %d", sessions)
+	}
+	for _, s := range c.spans() {
+		if s.Name == "codex.turn" && s.ParentSpanID != root.SpanID {
+			t.Errorf("expected turn %s to be a child of the session span", s.SpanID)
+		}
+	}
+}
+
+func TestStreamRetriesAfterCollectorFailure(t *testing.T) {
+	c := &collector{fail: true}
+	server := httptest.NewServer(c)
+	defer server.Close()
+
+	stream := NewStream(NewExporter(Config{Endpoint: server.URL}), 30*time.Minute)
+	entries := otlpTestEntries()
+
+	if _, err := stream.Push(context.Background(), entries, time.Now()); err == nil {
+		t.Fatal("expected an error from a failing collector")
+	}
+
+	c.mu.Lock()
+	c.fail = false
+	c.mu.Unlock()
+	stats, err := stream.Push(context.Background(), entries, time.Now())
+	if err != nil {
+		t.Fatalf("retry failed: %v", err)
+	}
+	if stats.Entries != 3 || stats.Sessions != 2 {
+		t.Errorf("expected all entries and idle sessions on retry, got %+v", stats)
+	}
+}
+
+func TestStreamSendsMetricsOnceWhenSpansFail(t *testing.T) {
+	c := &collector{failTraces: 1}
+	server := httptest.NewServer(c)
+	defer server.Close()
+
+	stream := NewStream(NewExporter(Config{Endpoint: server.URL}), 30*time.Minute)
+	entries := otlpTestEntries()
+
+	if _, err := stream.Push(context.Background(), entries, time.Now()); err == nil {
+		t.Fatal("expected an error from the rejected spans")
+	}
+	stats, err := stream.Push(context.Background(), entries, time.Now())
+	if err != nil || stats.Entries != 3 {
+		t.Fatalf("retry: got %+v (%v)", stats, err)
+	}
+
+	// Each turn is counted once, although its spans were sent twice
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	turns := 0
+	for _, req := range c.metrics {
+		for _, rm := range req.ResourceMetrics {
+			for _, sm := range rm.ScopeMetrics {
+				for _, m := range sm.Metrics {
+					if m.Name == "cxusage.turns" {
+						turns += len(m.Sum.DataPoints)
+					}
+				}
+			}
+		}
+	}
+	if turns != len(entries) {
+		t.Errorf("got %d turn data points, want %d", turns, len(entries))
+	}
+}
+
+func TestParseHeaders(t *testing.T) {
+	headers := ParseHeaders("api-key=secret, x-team = platform,invalid")
+	if len(headers) != 2 || headers["api-key"] != "secret" || headers["x-team"] != "platform" {
+		t.Errorf("unexpected headers %v", headers)
+	}
+}
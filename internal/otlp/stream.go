@@ -0,0 +1,331 @@
+package otlp
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/johanneserhardt/cxusage/internal/codex"
+	"github.com/johanneserhardt/cxusage/internal/types"
+)
+
+// batchSize limits the number of entries sent in one request
+const batchSize = 500
+
+// session accumulates the turns of one Codex session until its span is exported
+type session struct {
+	traceID string
+	spanID  string
+	start   time.Time
+	end     time.Time
+	first   types.CodexUsageEntry
+	model   string
+	turns   int
+	input   int
+	output  int
+	cost    float64
+}
+
+// Stream exports usage entries as they appear: every new entry becomes metric data points
+// and a turn span right away, while the session span is exported once the session is
+// idle (or on Flush), when its full duration is known. Turns that arrive after their
+// session span was exported are exported as children of that span, which is never
+// exported twice.
+type Stream struct {
+	exporter *Exporter
+	idle     time.Duration
+	seen     map[string]time.Time
+	metrics  map[string]time.Time // entries whose metrics were sent but not their spans
+	open     map[string]*session
+	closed   map[string]time.Time // sessions whose span was exported, by their end
+}
+
+// Stats counts what a Push or Flush sent to the collector
+type Stats struct {
+	Entries  int
+	Sessions int
+}
+
+// NewStream creates a stream; sessions without new turns for the idle duration are closed
+func NewStream(exporter *Exporter, idle time.Duration) *Stream {
+	return &Stream{
+		exporter: exporter,
+		idle:     idle,
+		seen:     make(map[string]time.Time),
+		metrics:  make(map[string]time.Time),
+		open:     make(map[string]*session),
+		closed:   make(map[string]time.Time),
+	}
+}
+
+// Push exports entries not sent before and closes sessions without turns for the idle duration
+func (s *Stream) Push(ctx context.Context, entries []types.CodexUsageEntry, now time.Time) (Stats, error) {
+	var stats Stats
+
+	var fresh []types.CodexUsageEntry
+	for _, entry := range entries {
+		key := codex.EntryKey(entry)
+		if _, ok := s.seen[key]; ok {
+			continue
+		}
+		fresh = append(fresh, entry)
+	}
+	sort.Slice(fresh, func(i, j int) bool {
+		return fresh[i].Timestamp.Before(fresh[j].Timestamp)
+	})
+
+	for start := 0; start < len(fresh); start += batchSize {
+		end := start + batchSize
+		if end > len(fresh) {
+			end = len(fresh)
+		}
+		batch := fresh[start:end]
+
+		// Data points are deltas, so metrics accepted before a failed span export are
+		// not sent again on the retry
+		var unsent []types.CodexUsageEntry
+		var spans []span
+		for _, entry := range batch {
+			if _, ok := s.metrics[codex.EntryKey(entry)]; !ok {
+				unsent = append(unsent, entry)
+			}
+			spans = append(spans, turnSpan(entry))
+		}
+		if err := s.exporter.exportMetrics(ctx, entryMetrics(unsent)); err != nil {
+			return stats, err
+		}
+		for _, entry := range unsent {
+			s.metrics[codex.EntryKey(entry)] = entry.Timestamp
+		}
+		if err := s.exporter.exportSpans(ctx, spans); err != nil {
+			return stats, err
+		}
+
+		// Only track entries once the collector accepted them, so failures are retried
+		for _, entry := range batch {
+			key := codex.EntryKey(entry)
+			s.seen[key] = entry.Timestamp
+			delete(s.metrics, key)
+			s.track(entry)
+		}
+		stats.Entries += len(batch)
+	}
+
+	closed, err := s.close(ctx, func(sess *session) bool {
+		return now.Sub(sess.end) >= s.idle
+	})
+	stats.Sessions = closed
+	return stats, err
+}
+
+// Flush exports the spans of all open sessions
+func (s *Stream) Flush(ctx context.Context) (Stats, error) {
+	closed, err := s.close(ctx, func(*session) bool { return true })
+	return Stats{Sessions: closed}, err
+}
+
+// Forget drops the de-dup state of entries before the cutoff, which are no longer polled
+func (s *Stream) Forget(before time.Time) {
+	for key, ts := range s.seen {
+		if ts.Before(before) {
+			delete(s.seen, key)
+		}
+	}
+	for key, ts := range s.metrics {
+		if ts.Before(before) {
+			delete(s.metrics, key)
+		}
+	}
+	for key, end := range s.closed {
+		if end.Before(before) {
+			delete(s.closed, key)
+		}
+	}
+}
+
+// track adds an entry to its session, unless the session span was already exported
+func (s *Stream) track(entry types.CodexUsageEntry) {
+	key := sessionKey(entry)
+	if _, ok := s.closed[key]; ok {
+		return
+	}
+	sess, ok := s.open[key]
+	if !ok {
+		sess = &session{
+			start: turnStart(entry),
+			end:   entry.Timestamp,
+			first: entry,
+		}
+		sess.traceID, sess.spanID = sessionIDs(key)
+		s.open[key] = sess
+	}
+	if start := turnStart(entry); start.Before(sess.start) {
+		sess.start = start
+	}
+	if entry.Timestamp.After(sess.end) {
+		sess.end = entry.Timestamp
+	}
+	sess.model = entry.Model
+	sess.turns++
+	sess.input += entry.Usage.PromptTokens
+	sess.output += entry.Usage.CompletionTokens
+	sess.cost += entry.Cost
+}
+
+// close exports and removes the sessions matching done
+func (s *Stream) close(ctx context.Context, done func(*session) bool) (int, error) {
+	var keys []string
+	var spans []span
+	for key, sess := range s.open {
+		if done(sess) {
+			keys = append(keys, key)
+			spans = append(spans, sessionSpan(sess))
+		}
+	}
+	if err := s.exporter.exportSpans(ctx, spans); err != nil {
+		return 0, err
+	}
+	for _, key := range keys {
+		s.closed[key] = s.open[key].end
+		delete(s.open, key)
+	}
+	return len(keys), nil
+}
+
+// sessionKey groups entries into traces; redacted entries without a session become
+// their own trace
+func sessionKey(entry types.CodexUsageEntry) string {
+	if entry.SessionID != "" {
+		return entry.Source + "|" + entry.SessionID
+	}
+	return "entry|" + codex.EntryKey(entry)
+}
+
+// sessionIDs returns the trace id and root span id of a session
+func sessionIDs(key string) (traceID, spanID string) {
+	return hexID("trace|"+key, 16), hexID("session|"+key, 8)
+}
+
+// turnStart is the start of the turn, when its latency is known
+func turnStart(entry types.CodexUsageEntry) time.Time {
+	return entry.Timestamp.Add(-time.Duration(entry.Duration) * time.Millisecond)
+}
+
+// entryAttributes describe where an entry came from
+func entryAttributes(entry types.CodexUsageEntry) []keyValue {
+	attrs := []keyValue{stringAttr("gen_ai.request.model", entry.Model)}
+	if entry.ProjectPath != "" {
+		attrs = append(attrs, stringAttr("cxusage.project", entry.ProjectPath))
+	}
+	if entry.RepoURL != "" {
+		attrs = append(attrs, stringAttr("cxusage.repo_url", entry.RepoURL))
+	}
+	if entry.Source != "" {
+		attrs = append(attrs, stringAttr("cxusage.source", entry.Source))
+	}
+	if entry.User != "" {
+		attrs = append(attrs, stringAttr("cxusage.user", entry.User))
+	}
+	if entry.Agent != "" {
+		attrs = append(attrs, stringAttr("cxusage.agent", entry.Agent))
+	}
+	if entry.Provenance != "" {
+		attrs = append(attrs, stringAttr("cxusage.provenance", string(entry.Provenance)))
+	}
+	return attrs
+}
+
+// entryMetrics converts entries into per-turn delta data points for token, cost and turn counters
+func entryMetrics(entries []types.CodexUsageEntry) []metric {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	var tokens, cost, turns []numberDataPoint
+	for _, entry := range entries {
+		attrs := entryAttributes(entry)
+		start, end := unixNano(turnStart(entry)), unixNano(entry.Timestamp)
+
+		for _, t := range []struct {
+			kind  string
+			count int
+		}{
+			{"input", entry.Usage.PromptTokens}, {"output", entry.Usage.CompletionTokens},
+			{"cache_creation", entry.Usage.CacheCreationTokens}, {"cache_read", entry.Usage.CacheReadTokens},
+		} {
+			// Only Claude Code logs cache tokens
+			if t.count == 0 && (t.kind == "cache_creation" || t.kind == "cache_read") {
+				continue
+			}
+			tokens = append(tokens, numberDataPoint{
+				Attributes:        append(append([]keyValue{}, attrs...), stringAttr("gen_ai.token.type", t.kind)),
+				StartTimeUnixNano: start,
+				TimeUnixNano:      end,
+				AsInt:             strconv.Itoa(t.count),
+			})
+		}
+
+		value := entry.Cost
+		cost = append(cost, numberDataPoint{Attributes: attrs, StartTimeUnixNano: start, TimeUnixNano: end, AsDouble: &value})
+		turns = append(turns, numberDataPoint{Attributes: attrs, StartTimeUnixNano: start, TimeUnixNano: end, AsInt: "1"})
+	}
+
+	return []metric{
+		{Name: "cxusage.tokens", Description: "Tokens used per Codex turn", Unit: "{token}",
+			Sum: &sum{DataPoints: tokens, AggregationTemporality: aggregationTemporalityDelta, IsMonotonic: true}},
+		{Name: "cxusage.cost", Description: "Estimated cost per Codex turn", Unit: "USD",
+			Sum: &sum{DataPoints: cost, AggregationTemporality: aggregationTemporalityDelta, IsMonotonic: true}},
+		{Name: "cxusage.turns", Description: "Number of Codex turns", Unit: "{turn}",
+			Sum: &sum{DataPoints: turns, AggregationTemporality: aggregationTemporalityDelta, IsMonotonic: true}},
+	}
+}
+
+// turnSpan represents one turn as a child of its session span
+func turnSpan(entry types.CodexUsageEntry) span {
+	attrs := append(entryAttributes(entry),
+		intAttr("gen_ai.usage.input_tokens", int64(entry.Usage.PromptTokens)),
+		intAttr("gen_ai.usage.output_tokens", int64(entry.Usage.CompletionTokens)),
+		doubleAttr("cxusage.cost_usd", entry.Cost),
+	)
+	if entry.RequestID != "" {
+		attrs = append(attrs, stringAttr("cxusage.request_id", entry.RequestID))
+	}
+
+	traceID, parentID := sessionIDs(sessionKey(entry))
+	return span{
+		TraceID:           traceID,
+		SpanID:            hexID("turn|"+codex.EntryKey(entry), 8),
+		ParentSpanID:      parentID,
+		Name:              "codex.turn",
+		Kind:              spanKindInternal,
+		StartTimeUnixNano: unixNano(turnStart(entry)),
+		EndTimeUnixNano:   unixNano(entry.Timestamp),
+		Attributes:        attrs,
+	}
+}
+
+// sessionSpan is the root span of a session's trace
+func sessionSpan(sess *session) span {
+	attrs := entryAttributes(sess.first)
+	attrs[0] = stringAttr("gen_ai.request.model", sess.model)
+	if sess.first.SessionID != "" {
+		attrs = append(attrs, stringAttr("cxusage.session_id", sess.first.SessionID))
+	}
+	attrs = append(attrs,
+		intAttr("cxusage.turns", int64(sess.turns)),
+		intAttr("gen_ai.usage.input_tokens", int64(sess.input)),
+		intAttr("gen_ai.usage.output_tokens", int64(sess.output)),
+		doubleAttr("cxusage.cost_usd", sess.cost),
+	)
+
+	return span{
+		TraceID:           sess.traceID,
+		SpanID:            sess.spanID,
+		Name:              "codex.session",
+		Kind:              spanKindInternal,
+		StartTimeUnixNano: unixNano(sess.start),
+		EndTimeUnixNano:   unixNano(sess.end),
+		Attributes:        attrs,
+	}
+}
@@ -0,0 +1,267 @@
+package pricing
+
+import (
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/johanneserhardt/cxusage/internal/types"
+)
+
+// DefaultProvider is the provider assumed for entries that do not name one
+const DefaultProvider = "openai"
+
+// Rate is the price of a model in USD per 1M tokens. Cache writes and reads are priced
+// as input when the rate has no cache prices.
+type Rate struct {
+	Input      float64
+	Output     float64
+	CacheWrite float64
+	CacheRead  float64
+}
+
+// listPrices are the public OpenAI prices per 1M tokens, used for every provider without
+// its own rates (Azure OpenAI bills the same list prices)
+var listPrices = map[string]Rate{
+	// GPT-5 and reasoning models
+	"gpt-5":             {Input: 1.25, Output: 10.0},
+	"gpt-5-codex":       {Input: 1.25, Output: 10.0},
+	"gpt-5-mini":        {Input: 0.25, Output: 2.0},
+	"gpt-5-nano":        {Input: 0.05, Output: 0.4},
+	"gpt-4.1":           {Input: 2.0, Output: 8.0},
+	"gpt-4.1-mini":      {Input: 0.4, Output: 1.6},
+	"gpt-4.1-nano":      {Input: 0.1, Output: 0.4},
+	"o3":                {Input: 2.0, Output: 8.0},
+	"o3-mini":           {Input: 1.1, Output: 4.4},
+	"o4-mini":           {Input: 1.1, Output: 4.4},
+	"codex-mini-latest": {Input: 1.5, Output: 6.0},
+
+	// GPT-4 models
+	"gpt-4":                {Input: 30.0, Output: 60.0},
+	"gpt-4-32k":            {Input: 60.0, Output: 120.0},
+	"gpt-4-turbo":          {Input: 10.0, Output: 30.0},
+	"gpt-4-turbo-preview":  {Input: 10.0, Output: 30.0},
+	"gpt-4-1106-preview":   {Input: 10.0, Output: 30.0},
+	"gpt-4-0125-preview":   {Input: 10.0, Output: 30.0},
+	"gpt-4-vision-preview": {Input: 10.0, Output: 30.0},
+	"gpt-4o":               {Input: 5.0, Output: 15.0},
+	"gpt-4o-mini":          {Input: 0.15, Output: 0.6},
+
+	// GPT-3.5 models
+	"gpt-3.5-turbo":          {Input: 0.5, Output: 1.5},
+	"gpt-3.5-turbo-16k":      {Input: 3.0, Output: 4.0},
+	"gpt-3.5-turbo-0125":     {Input: 0.5, Output: 1.5},
+	"gpt-3.5-turbo-1106":     {Input: 1.0, Output: 2.0},
+	"gpt-3.5-turbo-instruct": {Input: 1.5, Output: 2.0},
+
+	// Codex models (deprecated but might still be in logs)
+	"code-davinci-002": {Input: 0.0, Output: 0.0}, // Free during beta
+	"code-cushman-001": {Input: 0.0, Output: 0.0}, // Free during beta
+
+	// Text completion models (legacy)
+	"text-davinci-003": {Input: 20.0, Output: 20.0},
+	"text-davinci-002": {Input: 20.0, Output: 20.0},
+	"text-curie-001":   {Input: 2.0, Output: 2.0},
+	"text-babbage-001": {Input: 0.5, Output: 0.5},
+	"text-ada-001":     {Input: 0.4, Output: 0.4},
+
+	// Embedding models
+	"text-embedding-3-small": {Input: 0.02, Output: 0.0},
+	"text-embedding-3-large": {Input: 0.13, Output: 0.0},
+	"text-embedding-ada-002": {Input: 0.10, Output: 0.0},
+
+	// Fine-tuning models (base rates)
+	"davinci:ft-personal": {Input: 120.0, Output: 120.0},
+	"curie:ft-personal":   {Input: 12.0, Output: 12.0},
+	"babbage:ft-personal": {Input: 2.4, Output: 2.4},
+	"ada:ft-personal":     {Input: 1.6, Output: 1.6},
+
+	// Anthropic Claude models, with 5-minute cache write and cache read prices
+	"claude-opus-4-5":   {Input: 5.0, Output: 25.0, CacheWrite: 6.25, CacheRead: 0.5},
+	"claude-opus-4-1":   {Input: 15.0, Output: 75.0, CacheWrite: 18.75, CacheRead: 1.5},
+	"claude-opus-4":     {Input: 15.0, Output: 75.0, CacheWrite: 18.75, CacheRead: 1.5},
+	"claude-sonnet-4-5": {Input: 3.0, Output: 15.0, CacheWrite: 3.75, CacheRead: 0.3},
+	"claude-sonnet-4":   {Input: 3.0, Output: 15.0, CacheWrite: 3.75, CacheRead: 0.3},
+	"claude-haiku-4-5":  {Input: 1.0, Output: 5.0, CacheWrite: 1.25, CacheRead: 0.1},
+	"claude-3-7-sonnet": {Input: 3.0, Output: 15.0, CacheWrite: 3.75, CacheRead: 0.3},
+	"claude-3-5-sonnet": {Input: 3.0, Output: 15.0, CacheWrite: 3.75, CacheRead: 0.3},
+	"claude-3-5-haiku":  {Input: 0.8, Output: 4.0, CacheWrite: 1.0, CacheRead: 0.08},
+	"claude-3-opus":     {Input: 15.0, Output: 75.0, CacheWrite: 18.75, CacheRead: 1.5},
+	"claude-3-haiku":    {Input: 0.25, Output: 1.25, CacheWrite: 0.3, CacheRead: 0.03},
+}
+
+// LocalProviders are provider ids that run models on the user's own machine and cost
+// nothing unless rates are configured for them
+var LocalProviders = []string{"oss", "ollama", "lmstudio", "llamacpp", "vllm"}
+
+// Table prices usage by provider and model
+type Table struct {
+	providers map[string]map[string]Rate // provider -> model ("*" for all models) -> rate
+	local     map[string]bool
+}
+
+// Default returns a table with the built-in list prices and local providers
+func Default() *Table {
+	return New(types.PricingConfig{})
+}
+
+// New returns a table with the built-in prices and the rates and local providers of the
+// pricing section of the config file
+func New(config types.PricingConfig) *Table {
+	t := &Table{
+		providers: make(map[string]map[string]Rate),
+		local:     make(map[string]bool),
+	}
+	for _, id := range LocalProviders {
+		t.AddLocalProvider(id)
+	}
+	for _, id := range config.LocalProviders {
+		t.AddLocalProvider(id)
+	}
+	for _, r := range config.Rates {
+		provider := strings.ToLower(r.Provider)
+		model := r.Model
+		if model == "" {
+			model = "*"
+		}
+		if t.providers[provider] == nil {
+			t.providers[provider] = make(map[string]Rate)
+		}
+		t.providers[provider][model] = Rate{Input: r.Input, Output: r.Output, CacheWrite: r.CacheWrite, CacheRead: r.CacheRead}
+	}
+	return t
+}
+
+// AddLocalProvider marks a provider as running locally, so its usage costs nothing
+func (t *Table) AddLocalProvider(id string) {
+	t.local[strings.ToLower(id)] = true
+}
+
+// Lookup returns the rate of a model at a provider. Configured rates come first, then
+// local providers (free), then the list prices by exact or longest prefix match.
+func (t *Table) Lookup(provider, model string) (Rate, bool) {
+	provider = strings.ToLower(provider)
+	if provider == "" {
+		provider = DefaultProvider
+	}
+
+	if rates, ok := t.providers[provider]; ok {
+		if rate, ok := matchModel(rates, model); ok {
+			return rate, true
+		}
+		if rate, ok := rates["*"]; ok {
+			return rate, true
+		}
+	}
+	if t.local[provider] {
+		return Rate{}, true
+	}
+	return matchModel(listPrices, model)
+}
+
+// Cost returns the cost of usage of a model at a provider, and whether the model is priced
+func (t *Table) Cost(provider, model string, usage types.Usage) (float64, bool) {
+	rate, ok := t.Lookup(provider, model)
+	if !ok {
+		return 0, false
+	}
+	inputCost := float64(usage.PromptTokens) * rate.Input / 1000000
+	outputCost := float64(usage.CompletionTokens) * rate.Output / 1000000
+	cacheWrite, cacheRead := rate.cachePrices()
+	cacheCost := (float64(usage.CacheCreationTokens)*cacheWrite + float64(usage.CacheReadTokens)*cacheRead) / 1000000
+	return inputCost + outputCost + cacheCost, true
+}
+
+// cachePrices returns the cache write and read prices, the input price where unset
+func (r Rate) cachePrices() (write, read float64) {
+	write, read = r.CacheWrite, r.CacheRead
+	if write == 0 {
+		write = r.Input
+	}
+	if read == 0 {
+		read = r.Input
+	}
+	return write, read
+}
+
+// Rates lists every price of the table in lookup order: configured rates, then free local
+// providers (model "*"), then the list prices (provider "*") that apply to all other
+// providers. Cache prices are filled in as Cost applies them.
+func (t *Table) Rates() []types.ModelPricing {
+	var configured, local []types.ModelPricing
+	for provider, rates := range t.providers {
+		for model, rate := range rates {
+			configured = append(configured, modelPricing(provider, model, rate))
+		}
+	}
+	for provider := range t.local {
+		// Configured rates for all models win over the provider being local
+		if _, ok := t.providers[provider]["*"]; !ok {
+			local = append(local, modelPricing(provider, "*", Rate{}))
+		}
+	}
+	for _, rows := range [][]types.ModelPricing{configured, local} {
+		sort.Slice(rows, func(i, j int) bool {
+			if rows[i].Provider != rows[j].Provider {
+				return rows[i].Provider < rows[j].Provider
+			}
+			return rows[i].Model < rows[j].Model
+		})
+	}
+
+	rates := append(configured, local...)
+	for _, model := range Models() {
+		rates = append(rates, modelPricing("*", model, listPrices[model]))
+	}
+	return rates
+}
+
+// modelPricing describes the rate of a model at a provider
+func modelPricing(provider, model string, rate Rate) types.ModelPricing {
+	write, read := rate.cachePrices()
+	return types.ModelPricing{
+		Provider: provider, Model: model, InputPrice: rate.Input, OutputPrice: rate.Output,
+		CacheWritePrice: write, CacheReadPrice: read,
+	}
+}
+
+// Models returns the models with list prices, sorted by name
+func Models() []string {
+	models := make([]string, 0, len(listPrices))
+	for model := range listPrices {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+	return models
+}
+
+// matchModel finds the rate of a model by exact match, else by the longest matching prefix
+// so variants like gpt-4o-mini-2024-07-18 are priced as gpt-4o-mini rather than gpt-4o
+func matchModel(rates map[string]Rate, model string) (Rate, bool) {
+	if rate, ok := rates[model]; ok {
+		return rate, true
+	}
+	var best Rate
+	matched := ""
+	for prefix, rate := range rates {
+		if prefix != "*" && strings.HasPrefix(model, prefix) && len(prefix) > len(matched) {
+			best, matched = rate, prefix
+		}
+	}
+	return best, matched != ""
+}
+
+// IsLocalURL reports whether a provider base URL points at the local machine
+func IsLocalURL(baseURL string) bool {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
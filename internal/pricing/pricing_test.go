@@ -0,0 +1,110 @@
+package pricing
+
+import (
+	"testing"
+
+	"github.com/johanneserhardt/cxusage/internal/types"
+)
+
+func TestLookup(t *testing.T) {
+	table := New(types.PricingConfig{
+		LocalProviders: []string{"mybox"},
+		Rates: []types.PriceRate{
+			{Provider: "azure", Model: "gpt-5-codex", Input: 2, Output: 20},
+			{Provider: "openrouter", Input: 1, Output: 4},
+			{Provider: "ollama", Model: "qwen3-coder", Input: 0.1, Output: 0.2},
+		},
+	})
+
+	tests := []struct {
+		provider, model string
+		want            Rate
+		ok              bool
+	}{
+		{"", "gpt-5-codex", Rate{Input: 1.25, Output: 10}, true},
+		{"openai", "gpt-4o-mini-2024-07-18", Rate{Input: 0.15, Output: 0.6}, true}, // longest prefix, not gpt-4o
+		{"azure", "gpt-5-codex", Rate{Input: 2, Output: 20}, true},
+		{"Azure", "gpt-5", Rate{Input: 1.25, Output: 10}, true}, // no azure rate for gpt-5: list price
+		{"openrouter", "deepseek/deepseek-chat", Rate{Input: 1, Output: 4}, true},
+		{"ollama", "llama3", Rate{}, true},
+		{"ollama", "qwen3-coder:30b", Rate{Input: 0.1, Output: 0.2}, true},
+		{"mybox", "gpt-5", Rate{}, true},
+		{"openai", "claude-sonnet", Rate{}, false},
+		{"anthropic", "claude-sonnet-4-5-20250929", Rate{Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.3}, true},
+		{"anthropic", "claude-opus-4-20250514", Rate{Input: 15, Output: 75, CacheWrite: 18.75, CacheRead: 1.5}, true},
+	}
+	for _, tt := range tests {
+		got, ok := table.Lookup(tt.provider, tt.model)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Lookup(%q, %q) = %+v, %v; want %+v, %v", tt.provider, tt.model, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestCost(t *testing.T) {
+	usage := types.Usage{PromptTokens: 1000000, CompletionTokens: 100000, TotalTokens: 1100000}
+	if cost, ok := Default().Cost("openai", "gpt-5", usage); !ok || cost != 2.25 {
+		t.Errorf("gpt-5 cost = %v, %v; want 2.25", cost, ok)
+	}
+	cached := types.Usage{PromptTokens: 1000000, CacheCreationTokens: 1000000, CacheReadTokens: 10000000}
+	if cost, ok := Default().Cost("anthropic", "claude-sonnet-4-5-20250929", cached); !ok || cost != 9.75 {
+		t.Errorf("claude cost = %v, %v; want 9.75", cost, ok)
+	}
+	if cost, ok := Default().Cost("openai", "gpt-5", cached); !ok || cost != 15 {
+		t.Errorf("cache without cache prices = %v, %v; want 15", cost, ok)
+	}
+	if cost, ok := Default().Cost("lmstudio", "gpt-oss-20b", usage); !ok || cost != 0 {
+		t.Errorf("local cost = %v, %v; want 0", cost, ok)
+	}
+}
+
+func TestRates(t *testing.T) {
+	table := New(types.PricingConfig{
+		LocalProviders: []string{"mybox"},
+		Rates: []types.PriceRate{
+			{Provider: "azure", Model: "gpt-5-codex", Input: 2, Output: 20},
+			{Provider: "ollama", Model: "qwen3-coder", Input: 0.1, Output: 0.2},
+			{Provider: "mybox", Input: 0.5, Output: 1},
+		},
+	})
+	rates := table.Rates()
+
+	byKey := make(map[string]types.ModelPricing)
+	for _, r := range rates {
+		byKey[r.Provider+"|"+r.Model] = r
+	}
+	want := map[string]types.ModelPricing{
+		"azure|gpt-5-codex":   {Provider: "azure", Model: "gpt-5-codex", InputPrice: 2, OutputPrice: 20, CacheWritePrice: 2, CacheReadPrice: 2},
+		"ollama|qwen3-coder":  {Provider: "ollama", Model: "qwen3-coder", InputPrice: 0.1, OutputPrice: 0.2, CacheWritePrice: 0.1, CacheReadPrice: 0.1},
+		"ollama|*":            {Provider: "ollama", Model: "*"},
+		"mybox|*":             {Provider: "mybox", Model: "*", InputPrice: 0.5, OutputPrice: 1, CacheWritePrice: 0.5, CacheReadPrice: 0.5},
+		"*|claude-sonnet-4-5": {Provider: "*", Model: "claude-sonnet-4-5", InputPrice: 3, OutputPrice: 15, CacheWritePrice: 3.75, CacheReadPrice: 0.3},
+	}
+	for key, w := range want {
+		if got := byKey[key]; got != w {
+			t.Errorf("%s: got %+v, want %+v", key, got, w)
+		}
+	}
+	if len(byKey) != len(rates) {
+		t.Errorf("rates repeat a provider and model: %+v", rates)
+	}
+	// Configured rates come before local providers and list prices
+	if rates[0].Provider != "azure" || rates[len(rates)-1].Provider != "*" {
+		t.Errorf("unexpected order: first %+v, last %+v", rates[0], rates[len(rates)-1])
+	}
+}
+
+func TestIsLocalURL(t *testing.T) {
+	for url, want := range map[string]bool{
+		"http://localhost:11434/v1":               true,
+		"http://127.0.0.1:1234/v1":                true,
+		"http://[::1]:8000/v1":                    true,
+		"https://example.openai.azure.com/openai": false,
+		"https://openrouter.ai/api/v1":            false,
+		"":                                        false,
+	} {
+		if got := IsLocalURL(url); got != want {
+			t.Errorf("IsLocalURL(%q) = %v, want %v", url, got, want)
+		}
+	}
+}
@@ -0,0 +1,440 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/johanneserhardt/cxusage/internal/types"
+
+	// Pure-Go SQLite driver, so builds do not need cgo
+	_ "modernc.org/sqlite"
+)
+
+// SchemaVersion is the version of the database layout written by Export
+const SchemaVersion = 1
+
+// timeLayout stores timestamps as fixed-width UTC ISO 8601 text, which sorts correctly and
+// is understood by SQLite date functions
+const timeLayout = "2006-01-02T15:04:05.000000000Z"
+
+// Dataset is everything written to an exported database
+type Dataset struct {
+	Entries   []types.CodexUsageEntry
+	Blocks    []types.SessionBlock
+	Pricing   []types.ModelPricing
+	StartDate time.Time
+	EndDate   time.Time
+}
+
+const schema = `
+CREATE TABLE metadata (
+	key   TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+);
+
+CREATE TABLE models (
+	id   INTEGER PRIMARY KEY,
+	name TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE projects (
+	id       INTEGER PRIMARY KEY,
+	path     TEXT NOT NULL UNIQUE,
+	repo_url TEXT
+);
+
+CREATE TABLE sessions (
+	id            INTEGER PRIMARY KEY,
+	session_key   TEXT NOT NULL UNIQUE,
+	project_id    INTEGER REFERENCES projects(id),
+	source        TEXT,
+	user          TEXT,
+	start_time    TEXT NOT NULL,
+	end_time      TEXT NOT NULL,
+	request_count INTEGER NOT NULL,
+	total_tokens  INTEGER NOT NULL,
+	total_cost    REAL NOT NULL
+);
+
+CREATE TABLE entries (
+	id                INTEGER PRIMARY KEY,
+	session_id        INTEGER REFERENCES sessions(id),
+	project_id        INTEGER REFERENCES projects(id),
+	model_id          INTEGER NOT NULL REFERENCES models(id),
+	timestamp         TEXT NOT NULL,
+	request_id        TEXT,
+	entry_id          TEXT,
+	source            TEXT,
+	user              TEXT,
+	prompt_tokens     INTEGER NOT NULL,
+	completion_tokens INTEGER NOT NULL,
+	cache_creation_tokens INTEGER NOT NULL DEFAULT 0,
+	cache_read_tokens INTEGER NOT NULL DEFAULT 0,
+	total_tokens      INTEGER NOT NULL,
+	cost              REAL NOT NULL,
+	duration_ms       INTEGER,
+	estimated         INTEGER NOT NULL,
+	source_file       TEXT,
+	source_line       INTEGER,
+	reasoning_effort  TEXT,
+	provider          TEXT,
+	agent             TEXT,
+	provenance        TEXT
+);
+
+CREATE TABLE blocks (
+	id              INTEGER PRIMARY KEY,
+	user            TEXT,
+	start_time      TEXT NOT NULL,
+	end_time        TEXT NOT NULL,
+	actual_end_time TEXT,
+	is_active       INTEGER NOT NULL,
+	is_gap          INTEGER NOT NULL,
+	request_count   INTEGER NOT NULL,
+	input_tokens    INTEGER NOT NULL,
+	output_tokens   INTEGER NOT NULL,
+	total_tokens    INTEGER NOT NULL,
+	total_cost      REAL NOT NULL
+);
+
+CREATE TABLE pricing (
+	provider                       TEXT NOT NULL,
+	model                          TEXT NOT NULL,
+	input_price_per_million        REAL NOT NULL,
+	output_price_per_million       REAL NOT NULL,
+	cache_write_price_per_million  REAL NOT NULL,
+	cache_read_price_per_million   REAL NOT NULL,
+	PRIMARY KEY (provider, model)
+);
+
+CREATE INDEX idx_entries_timestamp ON entries(timestamp);
+CREATE INDEX idx_entries_session ON entries(session_id);
+CREATE INDEX idx_entries_model ON entries(model_id);
+CREATE INDEX idx_entries_project ON entries(project_id);
+CREATE INDEX idx_entries_user ON entries(user);
+CREATE INDEX idx_sessions_start ON sessions(start_time);
+CREATE INDEX idx_blocks_start ON blocks(start_time);
+
+CREATE VIEW usage AS
+SELECT e.timestamp, m.name AS model, e.prompt_tokens, e.completion_tokens,
+       e.cache_creation_tokens, e.cache_read_tokens, e.total_tokens,
+       e.cost, e.duration_ms, e.estimated, e.source, e.user, s.session_key, p.path AS project, p.repo_url,
+       e.source_file, e.source_line, e.reasoning_effort, e.provider, e.agent, e.provenance
+FROM entries e
+JOIN models m ON m.id = e.model_id
+LEFT JOIN sessions s ON s.id = e.session_id
+LEFT JOIN projects p ON p.id = e.project_id;
+`
+
+// Export writes the dataset into a new SQLite database, replacing any existing file
+func Export(path string, data *Dataset) error {
+	tmp := path + ".tmp"
+	_ = os.Remove(tmp)
+
+	if err := writeDatabase(tmp, data); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to write database: %w", err)
+	}
+	return nil
+}
+
+func writeDatabase(path string, data *Dataset) error {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(schema); err != nil {
+		return fmt.Errorf("failed to create schema: %w", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	w := &writer{
+		tx:       tx,
+		models:   make(map[string]int64),
+		projects: make(map[string]int64),
+		sessions: make(map[string]int64),
+	}
+	if err := w.writeMetadata(data); err != nil {
+		return err
+	}
+	if err := w.writeSessions(data.Entries); err != nil {
+		return err
+	}
+	if err := w.writeEntries(data.Entries); err != nil {
+		return err
+	}
+	if err := w.writeBlocks(data.Blocks); err != nil {
+		return err
+	}
+	if err := w.writePricing(data.Pricing); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to write database: %w", err)
+	}
+	return nil
+}
+
+// writer inserts rows and keeps the ids of normalized rows
+type writer struct {
+	tx       *sql.Tx
+	models   map[string]int64
+	projects map[string]int64
+	sessions map[string]int64
+}
+
+func (w *writer) writeMetadata(data *Dataset) error {
+	values := map[string]string{
+		"schema_version": fmt.Sprint(SchemaVersion),
+		"exported_at":    formatTime(time.Now()),
+		"start_date":     formatTime(data.StartDate),
+		"end_date":       formatTime(data.EndDate),
+	}
+	for key, value := range values {
+		if _, err := w.tx.Exec(`INSERT INTO metadata (key, value) VALUES (?, ?)`, key, value); err != nil {
+			return fmt.Errorf("failed to write metadata: %w", err)
+		}
+	}
+	return nil
+}
+
+// writeSessions summarizes entries per session id
+func (w *writer) writeSessions(entries []types.CodexUsageEntry) error {
+	type session struct {
+		first, last types.CodexUsageEntry
+		requests    int
+		tokens      int
+		cost        float64
+	}
+
+	var order []string
+	sessions := make(map[string]*session)
+	for _, entry := range entries {
+		if entry.SessionID == "" {
+			continue
+		}
+		s, ok := sessions[entry.SessionID]
+		if !ok {
+			s = &session{first: entry, last: entry}
+			sessions[entry.SessionID] = s
+			order = append(order, entry.SessionID)
+		}
+		if entry.Timestamp.Before(s.first.Timestamp) {
+			s.first = entry
+		}
+		if entry.Timestamp.After(s.last.Timestamp) {
+			s.last = entry
+		}
+		s.requests++
+		s.tokens += entry.Usage.TotalTokens
+		s.cost += entry.Cost
+	}
+
+	for _, key := range order {
+		s := sessions[key]
+		projectID, err := w.projectID(s.first.ProjectPath, s.first.RepoURL)
+		if err != nil {
+			return err
+		}
+		res, err := w.tx.Exec(`INSERT INTO sessions
+			(session_key, project_id, source, user, start_time, end_time, request_count, total_tokens, total_cost)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			key, projectID, s.first.Source, s.first.User,
+			formatTime(s.first.Timestamp), formatTime(s.last.Timestamp), s.requests, s.tokens, s.cost)
+		if err != nil {
+			return fmt.Errorf("failed to write session: %w", err)
+		}
+		if w.sessions[key], err = res.LastInsertId(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *writer) writeEntries(entries []types.CodexUsageEntry) error {
+	stmt, err := w.tx.Prepare(`INSERT INTO entries
+		(session_id, project_id, model_id, timestamp, request_id, entry_id, source, user,
+		 prompt_tokens, completion_tokens, cache_creation_tokens, cache_read_tokens, total_tokens, cost,
+		 duration_ms, estimated, source_file, source_line, reasoning_effort, provider, agent, provenance)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, entry := range entries {
+		modelID, err := w.modelID(entry.Model)
+		if err != nil {
+			return err
+		}
+		projectID, err := w.projectID(entry.ProjectPath, entry.RepoURL)
+		if err != nil {
+			return err
+		}
+
+		var sessionID interface{}
+		if id, ok := w.sessions[entry.SessionID]; ok {
+			sessionID = id
+		}
+
+		if _, err := stmt.Exec(sessionID, projectID, modelID, formatTime(entry.Timestamp),
+			nullString(entry.RequestID), nullString(entry.EntryID), nullString(entry.Source), nullString(entry.User),
+			entry.Usage.PromptTokens, entry.Usage.CompletionTokens, entry.Usage.CacheCreationTokens,
+			entry.Usage.CacheReadTokens, entry.Usage.TotalTokens, entry.Cost, entry.Duration, entry.Estimated,
+			nullString(entry.File), entry.Line, nullString(entry.ReasoningEffort), nullString(entry.Provider),
+			nullString(entry.Agent), nullString(string(entry.Provenance))); err != nil {
+			return fmt.Errorf("failed to write entry: %w", err)
+		}
+	}
+	return nil
+}
+
+func (w *writer) writeBlocks(sessionBlocks []types.SessionBlock) error {
+	for _, block := range sessionBlocks {
+		var actualEnd interface{}
+		if block.ActualEndTime != nil {
+			actualEnd = formatTime(*block.ActualEndTime)
+		}
+		if _, err := w.tx.Exec(`INSERT INTO blocks
+			(user, start_time, end_time, actual_end_time, is_active, is_gap,
+			 request_count, input_tokens, output_tokens, total_tokens, total_cost)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			nullString(block.User), formatTime(block.StartTime), formatTime(block.EndTime), actualEnd,
+			block.IsActive, block.IsGap, block.RequestCount, block.InputTokens, block.OutputTokens,
+			block.TotalTokens, block.TotalCost); err != nil {
+			return fmt.Errorf("failed to write block: %w", err)
+		}
+	}
+	return nil
+}
+
+func (w *writer) writePricing(pricing []types.ModelPricing) error {
+	for _, p := range pricing {
+		if _, err := w.tx.Exec(`INSERT INTO pricing
+			(provider, model, input_price_per_million, output_price_per_million,
+			 cache_write_price_per_million, cache_read_price_per_million)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			p.Provider, p.Model, p.InputPrice, p.OutputPrice, p.CacheWritePrice, p.CacheReadPrice); err != nil {
+			return fmt.Errorf("failed to write pricing: %w", err)
+		}
+	}
+	return nil
+}
+
+// modelID returns the id of a model row, inserting it on first use
+func (w *writer) modelID(name string) (int64, error) {
+	if id, ok := w.models[name]; ok {
+		return id, nil
+	}
+	res, err := w.tx.Exec(`INSERT INTO models (name) VALUES (?)`, name)
+	if err != nil {
+		return 0, fmt.Errorf("failed to write model: %w", err)
+	}
+	id, err := res.LastInsertId()
+	w.models[name] = id
+	return id, err
+}
+
+// projectID returns the id of a project row (nil without a path), inserting it on first use
+func (w *writer) projectID(path, repoURL string) (interface{}, error) {
+	if path == "" {
+		return nil, nil
+	}
+	if id, ok := w.projects[path]; ok {
+		return id, nil
+	}
+	res, err := w.tx.Exec(`INSERT INTO projects (path, repo_url) VALUES (?, ?)`, path, nullString(repoURL))
+	if err != nil {
+		return nil, fmt.Errorf("failed to write project: %w", err)
+	}
+	id, err := res.LastInsertId()
+	w.projects[path] = id
+	return id, err
+}
+
+// LoadEntries reads usage entries within the date range (inclusive) from an exported database
+func LoadEntries(path string, startDate, endDate time.Time) ([]types.CodexUsageEntry, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("database not found: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database %s: %w", filepath.Base(path), err)
+	}
+	defer db.Close()
+
+	var version int
+	if err := db.QueryRow(`SELECT value FROM metadata WHERE key = 'schema_version'`).Scan(&version); err != nil {
+		return nil, fmt.Errorf("%s is not a cxusage database: %w", filepath.Base(path), err)
+	}
+	if version > SchemaVersion {
+		return nil, fmt.Errorf("database %s has unsupported schema version %d", filepath.Base(path), version)
+	}
+
+	rows, err := db.Query(`
+		SELECT e.timestamp, m.name, COALESCE(s.session_key, ''), COALESCE(e.request_id, ''),
+		       COALESCE(e.entry_id, ''), COALESCE(e.source, ''), COALESCE(e.user, ''),
+		       COALESCE(p.path, ''), COALESCE(p.repo_url, ''),
+		       e.prompt_tokens, e.completion_tokens, e.cache_creation_tokens, e.cache_read_tokens,
+		       e.total_tokens, e.cost, COALESCE(e.duration_ms, 0),
+		       e.estimated, COALESCE(e.source_file, ''), COALESCE(e.source_line, 0), COALESCE(e.reasoning_effort, ''),
+		       COALESCE(e.provider, ''), COALESCE(e.agent, ''), COALESCE(e.provenance, '')
+		FROM entries e
+		JOIN models m ON m.id = e.model_id
+		LEFT JOIN sessions s ON s.id = e.session_id
+		LEFT JOIN projects p ON p.id = e.project_id
+		WHERE e.timestamp >= ? AND e.timestamp <= ?
+		ORDER BY e.timestamp`,
+		formatTime(startDate), formatTime(endDate))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []types.CodexUsageEntry
+	for rows.Next() {
+		var entry types.CodexUsageEntry
+		var timestamp string
+		if err := rows.Scan(&timestamp, &entry.Model, &entry.SessionID, &entry.RequestID,
+			&entry.EntryID, &entry.Source, &entry.User, &entry.ProjectPath, &entry.RepoURL,
+			&entry.Usage.PromptTokens, &entry.Usage.CompletionTokens, &entry.Usage.CacheCreationTokens,
+			&entry.Usage.CacheReadTokens, &entry.Usage.TotalTokens,
+			&entry.Cost, &entry.Duration, &entry.Estimated, &entry.File, &entry.Line, &entry.ReasoningEffort,
+			&entry.Provider, &entry.Agent, &entry.Provenance); err != nil {
+			return nil, fmt.Errorf("failed to read entry: %w", err)
+		}
+		if entry.Timestamp, err = time.Parse(timeLayout, timestamp); err != nil {
+			return nil, fmt.Errorf("invalid timestamp %q: %w", timestamp, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
+
+func nullString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,63 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/johanneserhardt/cxusage/internal/types"
+)
+
+func TestExportLoadRoundTrip(t *testing.T) {
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	entries := []types.CodexUsageEntry{
+		{Timestamp: start.Add(time.Hour + 123456789), SessionID: "s1", RequestID: "r1", Model: "gpt-4o",
+			ProjectPath: "/work/app", RepoURL: "git@example.com:app.git", Source: "codex", User: "alice",
+			Usage: types.Usage{PromptTokens: 100, CompletionTokens: 20, TotalTokens: 120}, Cost: 0.5,
+			Estimated: true, File: "/logs/rollout.jsonl", Line: 7, ReasoningEffort: "high", Provider: "azure"},
+		{Timestamp: start.Add(2 * time.Hour), SessionID: "s1", RequestID: "r2", Model: "gpt-4o-mini",
+			ProjectPath: "/work/app", Usage: types.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}},
+		{Timestamp: start.AddDate(0, 0, 10), EntryID: "abc", Model: "gpt-4o"},
+	}
+
+	path := filepath.Join(t.TempDir(), "usage.db")
+	data := &Dataset{
+		Entries: entries,
+		Blocks:  []types.SessionBlock{{StartTime: start, EndTime: start.Add(5 * time.Hour), RequestCount: 2}},
+		Pricing: []types.ModelPricing{
+			{Provider: "*", Model: "gpt-4o", InputPrice: 2.5, OutputPrice: 10, CacheWritePrice: 2.5, CacheReadPrice: 2.5},
+			{Provider: "azure", Model: "gpt-4o", InputPrice: 3, OutputPrice: 12, CacheWritePrice: 3, CacheReadPrice: 3},
+		},
+		StartDate: start,
+		EndDate:   start.AddDate(0, 0, 30),
+	}
+	if err := Export(path, data); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+
+	loaded, err := LoadEntries(path, start, start.AddDate(0, 0, 7))
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if len(loaded) != 2 {
+		t.Fatalf("expected 2 entries in range, got %d", len(loaded))
+	}
+	if loaded[0] != entries[0] {
+		t.Errorf("entry did not round-trip:\n got %+v\nwant %+v", loaded[0], entries[0])
+	}
+
+	// Exporting again replaces the database
+	if err := Export(path, &Dataset{Entries: entries[:1]}); err != nil {
+		t.Fatalf("second export failed: %v", err)
+	}
+	loaded, _ = LoadEntries(path, start, start.AddDate(0, 0, 30))
+	if len(loaded) != 1 {
+		t.Errorf("expected the database to be replaced, got %d entries", len(loaded))
+	}
+}
+
+func TestLoadEntriesRejectsMissingDatabase(t *testing.T) {
+	if _, err := LoadEntries(filepath.Join(t.TempDir(), "missing.db"), time.Time{}, time.Now()); err == nil {
+		t.Error("expected an error for a missing database")
+	}
+}
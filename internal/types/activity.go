@@ -0,0 +1,21 @@
+package types
+
+// TurnLatency summarizes the durations of the turns answered by one model
+type TurnLatency struct {
+	Model    string `json:"model"`
+	Turns    int    `json:"turns"`
+	MedianMs int64  `json:"median_ms"`
+	P95Ms    int64  `json:"p95_ms"`
+	MeanMs   int64  `json:"mean_ms"`
+	MaxMs    int64  `json:"max_ms"`
+}
+
+// DailyActivity is the active working time of one day, excluding idle gaps
+type DailyActivity struct {
+	Date              string  `json:"date"`
+	ActiveMs          int64   `json:"active_ms"`
+	Turns             int     `json:"turns"`
+	RequestCount      int     `json:"request_count"`
+	TotalCost         float64 `json:"total_cost"`
+	CostPerActiveHour float64 `json:"cost_per_active_hour"`
+}
@@ -0,0 +1,49 @@
+package types
+
+import (
+	"time"
+)
+
+// Dimension is an attribute usage entries can be grouped by
+type Dimension string
+
+const (
+	DimensionDay             Dimension = "day"              // local calendar day (2006-01-02)
+	DimensionWeek            Dimension = "week"             // ISO week (2006-W01)
+	DimensionMonth           Dimension = "month"            // local calendar month (2006-01)
+	DimensionHour            Dimension = "hour"             // local hour (2006-01-02 15:00)
+	DimensionModel           Dimension = "model"
+	DimensionProject         Dimension = "project"          // last element of the working directory
+	DimensionSession         Dimension = "session"
+	DimensionSource          Dimension = "source"           // Codex directory label
+	DimensionUser            Dimension = "user"
+	DimensionReasoningEffort Dimension = "reasoning_effort"
+	DimensionProvider        Dimension = "provider"         // Codex model provider id
+	DimensionAgent           Dimension = "agent"            // coding agent: codex or claude
+	DimensionTool            Dimension = "tool"             // tool calls only
+	DimensionCommand         Dimension = "command"          // tool calls only: program run by shell tools
+)
+
+// UsageTotals is the accumulated usage of a group of entries
+type UsageTotals struct {
+	RequestCount        int                `json:"request_count"`
+	InputTokens         int                `json:"input_tokens"`
+	OutputTokens        int                `json:"output_tokens"`
+	CacheCreationTokens int                `json:"cache_creation_tokens"`
+	CacheReadTokens     int                `json:"cache_read_tokens"`
+	TotalTokens         int                `json:"total_tokens"`
+	TotalCost           float64            `json:"total_cost"`
+	ModelUsage          map[string]Usage   `json:"model_usage"`
+	ModelCosts          map[string]float64 `json:"model_costs"`
+	Models              []string           `json:"models"` // in order of first use
+	FirstSeen           time.Time          `json:"first_seen"`
+	LastSeen            time.Time          `json:"last_seen"`
+	Estimation
+}
+
+// UsageGroup is the usage of all entries sharing the same dimension values
+type UsageGroup struct {
+	Keys   map[Dimension]string `json:"keys"`
+	Values []string             `json:"-"` // dimension values in group-by order
+	UsageTotals
+}
@@ -11,6 +11,7 @@ type SessionBlock struct {
 	ActualEndTime *time.Time `json:"actual_end_time,omitempty"`
 	IsActive     bool      `json:"is_active"`
 	IsGap        bool      `json:"is_gap"`
+	User         string    `json:"user,omitempty"` // Set when grouped by user
 	
 	// Usage data
 	RequestCount int                    `json:"request_count"`
@@ -25,11 +26,53 @@ type SessionBlock struct {
 	OutputTokens             int `json:"output_tokens"`
 	CacheCreationTokens      int `json:"cache_creation_tokens"`
 	CacheReadTokens          int `json:"cache_read_tokens"`
+	
+	// Share of the usage that is estimated
+	Estimation
+	
+	// Projection for the active block (only set on the active block)
+	Projection *BlockProjection `json:"projection,omitempty"`
+	
+	// Entries contributing to the block (used for projections, not serialized)
+	Entries []CodexUsageEntry `json:"-"`
+}
+
+// BlockMode represents how usage entries are grouped into billing blocks
+type BlockMode string
+
+const (
+	// BlockModeFixed aligns blocks to fixed clock slots (00:00, 05:00, 10:00, ...)
+	BlockModeFixed BlockMode = "fixed"
+	// BlockModeRolling starts a block at the first activity and closes it after
+	// the session duration or an inactivity gap (like ccusage)
+	BlockModeRolling BlockMode = "rolling"
+)
+
+// ProjectionStrategy represents how the burn rate of an active block is estimated
+type ProjectionStrategy string
+
+const (
+	// ProjectionAverage divides block tokens by elapsed time
+	ProjectionAverage ProjectionStrategy = "average"
+	// ProjectionRecent uses the burn rate over a recent window (e.g. last 30 minutes)
+	ProjectionRecent ProjectionStrategy = "recent"
+	// ProjectionEWMA uses an exponentially weighted per-minute burn rate
+	ProjectionEWMA ProjectionStrategy = "ewma"
+	// ProjectionHistorical uses past blocks that started at the same hour
+	ProjectionHistorical ProjectionStrategy = "historical"
+)
+
+// ProjectionConfig represents configuration for block projections
+type ProjectionConfig struct {
+	Strategy ProjectionStrategy
+	Window   time.Duration // lookback for recent and ewma strategies
 }
 
 // BlocksConfig represents configuration for blocks command
 type BlocksConfig struct {
 	SessionDurationHours int
+	BlockMode            BlockMode
+	Projection           ProjectionConfig
 	RefreshInterval      time.Duration
 	TokenLimit           *int
 	ShowActive           bool
@@ -41,8 +84,10 @@ type BlocksConfig struct {
 type LiveMonitoringConfig struct {
 	RefreshInterval      time.Duration
 	SessionDurationHours int
+	BlockMode            BlockMode
 	TokenLimit           *int
 	ShowProjections      bool
+	Projection           ProjectionConfig
 }
 
 // BlockProjection represents projected usage for an active block
@@ -51,4 +96,28 @@ type BlockProjection struct {
 	ProjectedCost   float64 `json:"projected_cost"`
 	BurnRate        float64 `json:"burn_rate"` // tokens per minute
 	TimeRemaining   time.Duration `json:"time_remaining"`
-}
\ No newline at end of file
+	
+	// Strategy actually used (may fall back when there is not enough data)
+	Strategy ProjectionStrategy `json:"strategy"`
+	
+	// Likely range of the projection
+	ProjectedTokensLow  int     `json:"projected_tokens_low"`
+	ProjectedTokensHigh int     `json:"projected_tokens_high"`
+	ProjectedCostLow    float64 `json:"projected_cost_low"`
+	ProjectedCostHigh   float64 `json:"projected_cost_high"`
+	
+	// Estimated wall-clock time the limit is hit at the projected burn rate
+	// (nil when no limit is known or it will not be hit before the window ends)
+	LimitReachedAt *time.Time `json:"limit_reached_at,omitempty"`
+	LimitType      LimitType  `json:"limit_type,omitempty"`
+}
+
+// LimitType represents which limit an exhaustion estimate refers to
+type LimitType string
+
+const (
+	// LimitTypeTokens is the user-configured token limit of a block
+	LimitTypeTokens LimitType = "token_limit"
+	// LimitTypeRateLimit is the primary plan rate-limit window reported by Codex CLI
+	LimitTypeRateLimit LimitType = "rate_limit"
+)
\ No newline at end of file
@@ -0,0 +1,25 @@
+package types
+
+import (
+	"time"
+)
+
+const (
+	// BundleFormat identifies cxusage usage bundle files
+	BundleFormat = "cxusage-bundle"
+
+	// BundleVersion is the newest bundle version this build can read
+	BundleVersion = 1
+)
+
+// UsageBundle represents a portable export of one person's usage entries.
+// Entries carry token counts and costs only, never prompt or command text.
+type UsageBundle struct {
+	Format    string            `json:"format"`
+	Version   int               `json:"version"`
+	User      string            `json:"user"`
+	CreatedAt time.Time         `json:"created_at"`
+	StartDate time.Time         `json:"start_date"`
+	EndDate   time.Time         `json:"end_date"`
+	Entries   []CodexUsageEntry `json:"entries"`
+}
@@ -7,27 +7,97 @@ import (
 // CodexUsageEntry represents a usage entry from Codex CLI logs
 type CodexUsageEntry struct {
 	Timestamp    time.Time `json:"timestamp"`
-	SessionID    string    `json:"session_id"`
-	RequestID    string    `json:"request_id"`
+	SessionID    string    `json:"session_id,omitempty"`
+	RequestID    string    `json:"request_id,omitempty"`
 	Model        string    `json:"model"`
 	Usage        Usage     `json:"usage"`
 	Cost         float64   `json:"cost,omitempty"`
 	Command      string    `json:"command,omitempty"`
-	ProjectPath  string    `json:"project_path,omitempty"`
+	ProjectPath  string    `json:"project_path,omitempty"` // Working directory of the session
+	RepoURL      string    `json:"repo_url,omitempty"`     // Git remote of the session
 	Duration     int64     `json:"duration_ms,omitempty"`
+	Source       string    `json:"source,omitempty"` // Label of the Codex directory the entry was read from
+	User         string    `json:"user,omitempty"`   // Identity of the person the usage belongs to
+	EntryID      string    `json:"entry_id,omitempty"` // Salted identity replacing session/request ids when redacted
+	Estimated    bool      `json:"estimated,omitempty"` // Tokens were estimated from message content, not logged
+	File         string    `json:"file,omitempty"`      // Log file the entry was parsed from
+	Line         int       `json:"line,omitempty"`      // Line number within the log file
+	ReasoningEffort string `json:"reasoning_effort,omitempty"` // Reasoning effort of the turn, when logged
+	Provider     string    `json:"provider,omitempty"`  // Codex model provider id, e.g. openai, azure or ollama
+	Agent        string    `json:"agent,omitempty"`     // Coding agent that logged the entry, codex or claude
+	Provenance   Provenance `json:"provenance"`         // Whether the tokens and cost are exact or estimated
 }
 
-// CodexConfig represents Codex CLI configuration
+// Provenance tells how reliable the numbers of an entry are
+type Provenance string
+
+const (
+	// ProvenanceExact entries have logged tokens, priced at their model's price or with a
+	// logged cost
+	ProvenanceExact Provenance = "exact"
+	// ProvenanceEstimatedTokens entries have tokens estimated from message text, so their
+	// cost is estimated too
+	ProvenanceEstimatedTokens Provenance = "estimated_tokens"
+	// ProvenanceEstimatedCost entries have logged tokens of a model without a known price,
+	// priced at a baseline or not at all
+	ProvenanceEstimatedCost Provenance = "estimated_cost"
+)
+
+// CostEstimated reports whether the cost of an entry is not exact
+func (p Provenance) CostEstimated() bool {
+	return p == ProvenanceEstimatedTokens || p == ProvenanceEstimatedCost
+}
+
+// Coding agents whose logs can be read
+const (
+	AgentCodex  = "codex"
+	AgentClaude = "claude"
+)
+
+// CodexSource represents one Codex CLI home directory and its label
+type CodexSource struct {
+	Label string `json:"label" mapstructure:"label"`
+	Path  string `json:"path" mapstructure:"path"`
+}
+
+// CodexLogFile represents a session log file and the source it belongs to
+type CodexLogFile struct {
+	Path   string
+	Source string
+}
+
+// CodexConfig represents the parts of Codex CLI's config.toml that affect usage reports
 type CodexConfig struct {
-	DefaultModel string `json:"default_model" yaml:"default_model"`
-	ApprovalMode string `json:"approval_mode" yaml:"approval_mode"`
-	// Add other config fields as needed
+	Model           string                        `json:"model,omitempty" toml:"model"`
+	ModelProvider   string                        `json:"model_provider,omitempty" toml:"model_provider"`
+	ReasoningEffort string                        `json:"model_reasoning_effort,omitempty" toml:"model_reasoning_effort"`
+	ApprovalPolicy  string                        `json:"approval_policy,omitempty" toml:"approval_policy"`
+	Profile         string                        `json:"profile,omitempty" toml:"profile"`
+	ModelProviders  map[string]CodexModelProvider `json:"model_providers,omitempty" toml:"model_providers"`
+	Profiles        map[string]CodexProfile       `json:"profiles,omitempty" toml:"profiles"`
+}
+
+// CodexModelProvider is a [model_providers.<id>] table of config.toml
+type CodexModelProvider struct {
+	Name    string `json:"name,omitempty" toml:"name"`
+	BaseURL string `json:"base_url,omitempty" toml:"base_url"`
+	EnvKey  string `json:"env_key,omitempty" toml:"env_key"`
+	WireAPI string `json:"wire_api,omitempty" toml:"wire_api"`
+}
+
+// CodexProfile is a [profiles.<name>] table of config.toml
+type CodexProfile struct {
+	Model           string `json:"model,omitempty" toml:"model"`
+	ModelProvider   string `json:"model_provider,omitempty" toml:"model_provider"`
+	ReasoningEffort string `json:"model_reasoning_effort,omitempty" toml:"model_reasoning_effort"`
+	ApprovalPolicy  string `json:"approval_policy,omitempty" toml:"approval_policy"`
 }
 
 // CodexPaths represents the directory structure for Codex CLI
 type CodexPaths struct {
+	Label            string // Source label
 	ConfigDir        string // ~/.codex
-	ConfigFile       string // ~/.codex/config.yaml
+	ConfigFile       string // ~/.codex/config.toml
 	InstructionsFile string // ~/.codex/instructions.md
 	LogsDir          string // ~/.codex/logs (if exists)
 	ProjectsDir      string // ~/.codex/projects (if exists)
@@ -0,0 +1,70 @@
+package types
+
+import (
+	"time"
+)
+
+// DoctorCheck identifies one kind of log parsing problem found by the doctor command
+type DoctorCheck string
+
+const (
+	CheckUnparseableLine DoctorCheck = "unparseable_line"
+	CheckUnknownFormat   DoctorCheck = "unknown_format"
+	CheckUnknownType     DoctorCheck = "unknown_type"
+	CheckLineTooLong     DoctorCheck = "line_too_long"
+	CheckReadError       DoctorCheck = "read_error"
+	CheckUnpricedModel   DoctorCheck = "unpriced_model"
+	CheckMissingMetadata DoctorCheck = "missing_metadata"
+	CheckFutureTimestamp DoctorCheck = "future_timestamp"
+	CheckOutOfOrder      DoctorCheck = "out_of_order"
+	CheckDuplicateKey    DoctorCheck = "duplicate_key"
+)
+
+// Doctor issue severities: errors mean reports are wrong or incomplete, warnings that
+// they may be
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// DoctorIssue is one problem found in a log file
+type DoctorIssue struct {
+	Check    DoctorCheck `json:"check"`
+	Severity string      `json:"severity"`
+	File     string      `json:"file,omitempty"`
+	Line     int         `json:"line,omitempty"`
+	Message  string      `json:"message"`
+}
+
+// DoctorSummary counts the issues of one check
+type DoctorSummary struct {
+	Check    DoctorCheck `json:"check"`
+	Severity string      `json:"severity"`
+	Count    int         `json:"count"`
+}
+
+// DoctorReport is the result of scanning every log file for parsing problems
+type DoctorReport struct {
+	GeneratedAt time.Time       `json:"generated_at"`
+	Version     string          `json:"version,omitempty"`
+	Files       int             `json:"files"`
+	Lines       int             `json:"lines"`
+	Sessions    int             `json:"sessions"`
+	Entries     int             `json:"entries"`
+	Duplicates  int             `json:"duplicates"` // identical entries seen more than once, counted once in reports
+	Formats     map[string]int  `json:"formats"` // files per detected log format
+	RecordTypes map[string]int  `json:"record_types"`
+	Summary     []DoctorSummary `json:"summary"`
+	Issues      []DoctorIssue   `json:"issues"`
+}
+
+// Errors returns the number of issues with error severity
+func (r *DoctorReport) Errors() int {
+	count := 0
+	for _, issue := range r.Issues {
+		if issue.Severity == SeverityError {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,53 @@
+package types
+
+import (
+	"time"
+)
+
+// EstimatorParams turn the token count of logged message text into estimated usage:
+// user messages are input tokens times InputRatio plus RequestOverhead, assistant messages
+// output tokens times OutputRatio
+type EstimatorParams struct {
+	InputRatio      float64 `json:"input_ratio"`
+	RequestOverhead float64 `json:"request_overhead"`
+	OutputRatio     float64 `json:"output_ratio"`
+}
+
+// ModelCalibration is the estimator parameters fitted for one model
+type ModelCalibration struct {
+	EstimatorParams
+	Samples int `json:"samples"` // turns the parameters were fitted from
+}
+
+// Calibration holds the estimator parameters fitted from sessions with exact usage.
+// Models without their own parameters use Default, fitted from every model's turns.
+type Calibration struct {
+	CalibratedAt time.Time                   `json:"calibrated_at"`
+	Default      *ModelCalibration           `json:"default,omitempty"`
+	Models       map[string]ModelCalibration `json:"models"`
+}
+
+// CalibrationStats compares the estimates of one model's turns with their exact usage
+type CalibrationStats struct {
+	Model      string          `json:"model"`
+	Samples    int             `json:"samples"`
+	Calibrated bool            `json:"calibrated"` // the model has its own parameters
+	Params     EstimatorParams `json:"params"`     // parameters the estimates are made with
+	// Mean absolute error of each turn's total tokens and the error of the sum, in percent
+	// of the exact usage, with the built-in and the calibrated parameters
+	DefaultError    float64 `json:"default_error_percent"`
+	DefaultBias     float64 `json:"default_bias_percent"`
+	CalibratedError float64 `json:"calibrated_error_percent"`
+	CalibratedBias  float64 `json:"calibrated_bias_percent"`
+}
+
+// CalibrationReport is the accuracy of token estimates on sessions with exact usage
+type CalibrationReport struct {
+	GeneratedAt  time.Time  `json:"generated_at"`
+	Path         string     `json:"path"`
+	CalibratedAt *time.Time `json:"calibrated_at,omitempty"` // nil without a calibration
+	Saved        bool       `json:"saved"`
+	CrossValidated bool               `json:"cross_validated"` // calibrated errors are from sessions left out of the fit
+	Models         []CalibrationStats `json:"models"`
+	Total          CalibrationStats   `json:"total"`
+}
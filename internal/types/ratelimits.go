@@ -0,0 +1,33 @@
+package types
+
+import (
+	"time"
+)
+
+// RateLimitWindow represents one plan rate-limit window reported by Codex CLI
+type RateLimitWindow struct {
+	UsedPercent   float64    `json:"used_percent"`
+	WindowMinutes int        `json:"window_minutes,omitempty"`
+	ResetsAt      *time.Time `json:"resets_at,omitempty"`
+}
+
+// RateLimitSnapshot represents the rate-limit state attached to a token_count event
+type RateLimitSnapshot struct {
+	Timestamp time.Time        `json:"timestamp"`
+	SessionID string           `json:"session_id,omitempty"`
+	Source    string           `json:"source,omitempty"`
+	Primary   *RateLimitWindow `json:"primary,omitempty"`   // short window (usually 5 hours)
+	Secondary *RateLimitWindow `json:"secondary,omitempty"` // long window (usually weekly)
+}
+
+// RateLimitWindowUsage summarizes all snapshots observed during one primary window
+type RateLimitWindowUsage struct {
+	Source               string     `json:"source,omitempty"`
+	FirstSeen            time.Time  `json:"first_seen"`
+	LastSeen             time.Time  `json:"last_seen"`
+	ResetsAt             *time.Time `json:"resets_at,omitempty"`
+	WindowMinutes        int        `json:"window_minutes,omitempty"`
+	PeakPrimaryPercent   float64    `json:"peak_primary_percent"`
+	PeakSecondaryPercent float64    `json:"peak_secondary_percent"`
+	SnapshotCount        int        `json:"snapshot_count"`
+}
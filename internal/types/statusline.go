@@ -0,0 +1,29 @@
+package types
+
+import (
+	"time"
+)
+
+// StatuslineData represents the cached values rendered by the statusline command
+type StatuslineData struct {
+	GeneratedAt          time.Time `json:"generated_at"`
+	SessionDurationHours int       `json:"session_duration_hours"`
+	BlockMode            BlockMode `json:"block_mode"`
+
+	// Active block (zero values when no block is active)
+	BlockActive    bool      `json:"block_active"`
+	BlockTokens    int       `json:"block_tokens"`
+	BlockCost      float64   `json:"block_cost"`
+	BlockEnd       time.Time `json:"block_end"`
+	BurnRate       float64   `json:"burn_rate"` // tokens per minute
+	ProjectedCost  float64   `json:"projected_cost"`
+	LastModel      string    `json:"last_model,omitempty"`
+
+	// Today's totals (local calendar day)
+	TodayTokens int     `json:"today_tokens"`
+	TodayCost   float64 `json:"today_cost"`
+
+	// Primary plan rate-limit window, when reported by Codex CLI
+	PlanUsedPercent *float64   `json:"plan_used_percent,omitempty"`
+	PlanResetsAt    *time.Time `json:"plan_resets_at,omitempty"`
+}
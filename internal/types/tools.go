@@ -0,0 +1,35 @@
+package types
+
+import (
+	"time"
+)
+
+// ToolCall represents one tool invocation recorded in a Codex session log, joined with its output
+type ToolCall struct {
+	Timestamp    time.Time `json:"timestamp"`
+	SessionID    string    `json:"session_id,omitempty"`
+	CallID       string    `json:"call_id,omitempty"`
+	Tool         string    `json:"tool"`              // function or tool name, e.g. shell or apply_patch
+	Command      string    `json:"command,omitempty"` // program run by shell tools, else the tool name
+	ProjectPath  string    `json:"project_path,omitempty"`
+	Source       string    `json:"source,omitempty"`
+	User         string    `json:"user,omitempty"`
+	ExitCode     *int      `json:"exit_code,omitempty"`
+	Failed       bool      `json:"failed"`
+	Duration     int64     `json:"duration_ms,omitempty"`
+	OutputTokens int       `json:"output_tokens"` // estimated tokens the output adds to the context
+	File         string    `json:"file,omitempty"`
+	Line         int       `json:"line,omitempty"`
+}
+
+// ToolGroup is the accumulated statistics of all tool calls sharing the same dimension values
+type ToolGroup struct {
+	Keys            map[Dimension]string `json:"keys"`
+	Values          []string             `json:"-"` // dimension values in group-by order
+	Calls           int                  `json:"calls"`
+	Failures        int                  `json:"failures"`
+	FailureRate     float64              `json:"failure_rate"` // percentage of calls that failed
+	DurationMs      int64                `json:"duration_ms"`
+	OutputTokens    int                  `json:"output_tokens"`
+	MaxOutputTokens int                  `json:"max_output_tokens"`
+}
@@ -20,30 +20,48 @@ type UsageData struct {
 
 // Usage represents token usage for a request
 type Usage struct {
-	PromptTokens     int `json:"prompt_tokens"`
-	CompletionTokens int `json:"completion_tokens"`
-	TotalTokens      int `json:"total_tokens"`
+	PromptTokens        int `json:"prompt_tokens"`
+	CompletionTokens    int `json:"completion_tokens"`
+	CacheCreationTokens int `json:"cache_creation_tokens,omitempty"` // Prompt tokens written to the cache (Claude)
+	CacheReadTokens     int `json:"cache_read_tokens,omitempty"`     // Prompt tokens read from the cache (Claude)
+	TotalTokens         int `json:"total_tokens"`
 }
 
 // DailyUsage represents aggregated usage data for a single day
 type DailyUsage struct {
 	Date         string             `json:"date"`
+	User         string             `json:"user,omitempty"` // Set when grouped by user
+	Provider     string             `json:"provider,omitempty"` // Set when grouped by provider
+	Agent        string             `json:"agent,omitempty"`    // Set when grouped by agent
 	TotalCost    float64            `json:"total_cost"`
 	TotalTokens  int                `json:"total_tokens"`
 	RequestCount int                `json:"request_count"`
 	ModelUsage   map[string]Usage   `json:"model_usage"`
 	ModelCosts   map[string]float64 `json:"model_costs"`
+	Estimation
 }
 
 // MonthlyUsage represents aggregated usage data for a month
 type MonthlyUsage struct {
 	Month        string             `json:"month"`
+	User         string             `json:"user,omitempty"` // Set when grouped by user
+	Provider     string             `json:"provider,omitempty"` // Set when grouped by provider
+	Agent        string             `json:"agent,omitempty"`    // Set when grouped by agent
 	TotalCost    float64            `json:"total_cost"`
 	TotalTokens  int                `json:"total_tokens"`
 	RequestCount int                `json:"request_count"`
 	DailyBreakdown []DailyUsage     `json:"daily_breakdown"`
 	ModelUsage   map[string]Usage   `json:"model_usage"`
 	ModelCosts   map[string]float64 `json:"model_costs"`
+	Estimation
+}
+
+// Estimation is how much of a period's usage is estimated rather than logged. The percent
+// is of the cost, or of the tokens when the usage cost nothing.
+type Estimation struct {
+	EstimatedTokens  int     `json:"estimated_tokens"`
+	EstimatedCost    float64 `json:"estimated_cost"`
+	EstimatedPercent float64 `json:"estimated_percent"`
 }
 
 // SessionUsage represents usage data grouped by session/project
@@ -61,10 +79,66 @@ type SessionUsage struct {
 
 // Config represents application configuration (updated for local file reading)
 type Config struct {
-	LogLevel     string `mapstructure:"log_level"`
-	LocalLogging bool   `mapstructure:"local_logging"`
-	LogsDir      string `mapstructure:"logs_dir"`
-	CodexPath    string `mapstructure:"codex_path"` // Optional custom codex directory
+	LogLevel     string        `mapstructure:"log_level"`
+	LocalLogging bool          `mapstructure:"local_logging"`
+	LogsDir      string        `mapstructure:"logs_dir"`
+	CodexPath    string        `mapstructure:"codex_path"` // Optional custom codex directory
+	CodexDirs    []CodexSource `mapstructure:"codex_dirs"` // Additional labeled codex directories
+	Sources      []string      `mapstructure:"sources"`    // Only read these source labels (empty means all)
+	User         string        `mapstructure:"user"`       // Identity attached to local usage (defaults to the OS user)
+	Bundles      []string      `mapstructure:"-"`          // Usage bundle files or directories to merge (set from flags)
+	Database     string        `mapstructure:"-"`          // Exported SQLite database to read instead of logs (set from flags)
+	Where        string        `mapstructure:"-"`          // Filter expression applied to entries before aggregation (set from flags)
+	Redact       RedactMode    `mapstructure:"redact"`      // Redaction of identifying fields in outputs
+	RedactSalt   string        `mapstructure:"redact_salt"` // Optional fixed salt for redaction hashes
+	Pricing      PricingConfig `mapstructure:"pricing"`     // Provider-specific prices and local providers
+	Agents       []string      `mapstructure:"agents"`      // Coding agents to read logs of (empty means codex only)
+	ClaudePath   string        `mapstructure:"claude_path"` // Optional custom Claude Code directory
+	Mappings     []LogMapping  `mapstructure:"mappings"`    // JSONL logs of other tools, read as agents named after the mapping
+}
+
+// LogMapping reads usage from the JSONL logs of another tool, such as an LLM proxy. Fields
+// are dot-separated JSON paths into each line (e.g. usage.prompt_tokens or choices.0.model);
+// empty paths are not read.
+type LogMapping struct {
+	Name                string `mapstructure:"name" json:"name" yaml:"name"`                                                       // Agent and source name of the entries
+	Glob                string `mapstructure:"glob" json:"glob" yaml:"glob"`                                                       // Log files; ** matches any directory depth
+	Timestamp           string `mapstructure:"timestamp" json:"timestamp" yaml:"timestamp"`                                        // RFC 3339 string or Unix seconds/milliseconds
+	Model               string `mapstructure:"model" json:"model,omitempty" yaml:"model,omitempty"`
+	Provider            string `mapstructure:"provider" json:"provider,omitempty" yaml:"provider,omitempty"`
+	Session             string `mapstructure:"session" json:"session,omitempty" yaml:"session,omitempty"`
+	Project             string `mapstructure:"project" json:"project,omitempty" yaml:"project,omitempty"`
+	Request             string `mapstructure:"request" json:"request,omitempty" yaml:"request,omitempty"` // Defaults to the file and line number
+	InputTokens         string `mapstructure:"input_tokens" json:"input_tokens,omitempty" yaml:"input_tokens,omitempty"`
+	OutputTokens        string `mapstructure:"output_tokens" json:"output_tokens,omitempty" yaml:"output_tokens,omitempty"`
+	CacheCreationTokens string `mapstructure:"cache_creation_tokens" json:"cache_creation_tokens,omitempty" yaml:"cache_creation_tokens,omitempty"`
+	CacheReadTokens     string `mapstructure:"cache_read_tokens" json:"cache_read_tokens,omitempty" yaml:"cache_read_tokens,omitempty"`
+	TotalTokens         string `mapstructure:"total_tokens" json:"total_tokens,omitempty" yaml:"total_tokens,omitempty"` // Defaults to the sum of the token fields
+	Cost                string `mapstructure:"cost" json:"cost,omitempty" yaml:"cost,omitempty"`                           // Priced by provider and model when missing
+}
+
+// PricingConfig is the pricing section of the config file
+type PricingConfig struct {
+	LocalProviders []string    `mapstructure:"local_providers"` // Providers whose usage costs nothing
+	Rates          []PriceRate `mapstructure:"rates"`           // Prices that override the list prices
+}
+
+// PriceRate is the price of a model at a provider per 1M tokens
+type PriceRate struct {
+	Provider   string  `mapstructure:"provider" json:"provider" yaml:"provider"`
+	Model      string  `mapstructure:"model" json:"model,omitempty" yaml:"model,omitempty"` // Empty means all models of the provider
+	Input      float64 `mapstructure:"input" json:"input" yaml:"input"`
+	Output     float64 `mapstructure:"output" json:"output" yaml:"output"`
+	CacheWrite float64 `mapstructure:"cache_write" json:"cache_write,omitempty" yaml:"cache_write,omitempty"` // Defaults to the input price
+	CacheRead  float64 `mapstructure:"cache_read" json:"cache_read,omitempty" yaml:"cache_read,omitempty"`    // Defaults to the input price
+}
+
+// ConfigValue is the effective value of a configuration key and where it comes from
+type ConfigValue struct {
+	Key    string      `json:"key"`
+	Value  interface{} `json:"value"`
+	Source string      `json:"source"` // default, file, env or flag
+	Env    string      `json:"env"`    // environment variable that sets the key
 }
 
 // OutputFormat represents the output format for CLI commands
@@ -73,6 +147,24 @@ type OutputFormat string
 const (
 	OutputFormatTable OutputFormat = "table"
 	OutputFormatJSON  OutputFormat = "json"
+	OutputFormatNDJSON OutputFormat = "ndjson" // one JSON object per line, for streaming
+)
+
+// ExportFormat represents the file format written by the export command
+type ExportFormat string
+
+const (
+	ExportFormatSQLite ExportFormat = "sqlite" // normalized SQLite database
+	ExportFormatOTLP   ExportFormat = "otlp"   // OpenTelemetry metrics and traces sent to a collector
+)
+
+// RedactMode represents how identifying fields are redacted in outputs
+type RedactMode string
+
+const (
+	RedactModeNone  RedactMode = "none"  // keep values as they are
+	RedactModeHash  RedactMode = "hash"  // replace values with salted hashes
+	RedactModeAlias RedactMode = "alias" // replace values with stable numbered aliases
 )
 
 // CostMode represents how costs should be calculated
@@ -82,4 +174,15 @@ const (
 	CostModeAuto      CostMode = "auto"
 	CostModeCalculate CostMode = "calculate"
 	CostModeDisplay   CostMode = "display"
-)
\ No newline at end of file
+)
+// ModelPricing represents the price of a model at a provider per 1M tokens. Provider "*"
+// holds the list prices of providers without their own rates, model "*" every model of
+// a provider.
+type ModelPricing struct {
+	Provider        string  `json:"provider"`
+	Model           string  `json:"model"`
+	InputPrice      float64 `json:"input_price"`
+	OutputPrice     float64 `json:"output_price"`
+	CacheWritePrice float64 `json:"cache_write_price"`
+	CacheReadPrice  float64 `json:"cache_read_price"`
+}
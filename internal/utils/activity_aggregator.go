@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"sort"
+	"time"
+
+	"github.com/johanneserhardt/cxusage/internal/types"
+)
+
+// activityInterval is a span of time during which a session was active
+type activityInterval struct {
+	start, end time.Time
+}
+
+// AggregateTurnLatency summarizes turn durations per model. Only the final response of a
+// turn carries a duration, so entries without one are skipped.
+func AggregateTurnLatency(entries []types.CodexUsageEntry) []types.TurnLatency {
+	durations := make(map[string][]int64)
+	for _, entry := range entries {
+		if entry.Duration > 0 {
+			durations[entry.Model] = append(durations[entry.Model], entry.Duration)
+		}
+	}
+
+	var result []types.TurnLatency
+	for model, ds := range durations {
+		sort.Slice(ds, func(i, j int) bool { return ds[i] < ds[j] })
+		var total int64
+		for _, d := range ds {
+			total += d
+		}
+		result = append(result, types.TurnLatency{
+			Model:    model,
+			Turns:    len(ds),
+			MedianMs: percentile(ds, 50),
+			P95Ms:    percentile(ds, 95),
+			MeanMs:   total / int64(len(ds)),
+			MaxMs:    ds[len(ds)-1],
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Model < result[j].Model })
+	return result
+}
+
+// percentile returns the nearest-rank percentile of sorted values
+func percentile(sorted []int64, p int) int64 {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// AggregateDailyActivity computes the active working time of each local day. Turns are
+// active from their user message to their final response; activity separated by at most
+// idleGap is joined, longer gaps count as idle.
+func AggregateDailyActivity(entries []types.CodexUsageEntry, idleGap time.Duration) []types.DailyActivity {
+	days := make(map[string]*types.DailyActivity)
+	day := func(date string) *types.DailyActivity {
+		d, ok := days[date]
+		if !ok {
+			d = &types.DailyActivity{Date: date}
+			days[date] = d
+		}
+		return d
+	}
+
+	var intervals []activityInterval
+	for _, entry := range entries {
+		d := day(entry.Timestamp.Local().Format("2006-01-02"))
+		d.RequestCount++
+		d.TotalCost += entry.Cost
+		if entry.Duration > 0 {
+			d.Turns++
+		}
+
+		end := entry.Timestamp
+		start := end.Add(-time.Duration(entry.Duration) * time.Millisecond)
+		intervals = append(intervals, activityInterval{start, end})
+	}
+
+	for _, interval := range mergeIntervals(intervals, idleGap) {
+		// Split activity spanning midnight between both days
+		for start := interval.start; start.Before(interval.end); {
+			local := start.Local()
+			midnight := time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, time.Local)
+			end := interval.end
+			if midnight.Before(end) {
+				end = midnight
+			}
+			day(local.Format("2006-01-02")).ActiveMs += end.Sub(start).Milliseconds()
+			start = end
+		}
+	}
+
+	result := make([]types.DailyActivity, 0, len(days))
+	for _, d := range days {
+		if d.ActiveMs > 0 {
+			d.CostPerActiveHour = d.TotalCost / (float64(d.ActiveMs) / float64(time.Hour/time.Millisecond))
+		}
+		result = append(result, *d)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Date < result[j].Date })
+	return result
+}
+
+// mergeIntervals joins intervals that overlap or are separated by at most gap
+func mergeIntervals(intervals []activityInterval, gap time.Duration) []activityInterval {
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i].start.Before(intervals[j].start) })
+
+	var merged []activityInterval
+	for _, interval := range intervals {
+		if n := len(merged); n > 0 && interval.start.Sub(merged[n-1].end) <= gap {
+			if interval.end.After(merged[n-1].end) {
+				merged[n-1].end = interval.end
+			}
+			continue
+		}
+		merged = append(merged, interval)
+	}
+	return merged
+}
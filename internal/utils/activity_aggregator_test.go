@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/johanneserhardt/cxusage/internal/types"
+)
+
+func mkTurn(model string, end time.Time, duration time.Duration, cost float64) types.CodexUsageEntry {
+	return types.CodexUsageEntry{Timestamp: end, Model: model, Duration: duration.Milliseconds(), Cost: cost}
+}
+
+func TestAggregateTurnLatency(t *testing.T) {
+	base := time.Date(2026, 10, 18, 9, 0, 0, 0, time.Local)
+	var entries []types.CodexUsageEntry
+	for i := 1; i <= 20; i++ {
+		entries = append(entries, mkTurn("gpt-5", base, time.Duration(i)*time.Second, 0))
+	}
+	entries = append(entries, mkTurn("gpt-5", base, 0, 0), mkTurn("o3", base, time.Minute, 0))
+
+	latency := AggregateTurnLatency(entries)
+	if len(latency) != 2 || latency[0].Model != "gpt-5" {
+		t.Fatalf("unexpected latency %+v", latency)
+	}
+	l := latency[0]
+	if l.Turns != 20 || l.MedianMs != 10000 || l.P95Ms != 19000 || l.MeanMs != 10500 || l.MaxMs != 20000 {
+		t.Errorf("unexpected gpt-5 latency %+v", l)
+	}
+	if latency[1].MedianMs != 60000 || latency[1].P95Ms != 60000 {
+		t.Errorf("unexpected o3 latency %+v", latency[1])
+	}
+}
+
+func TestAggregateDailyActivity(t *testing.T) {
+	day := time.Date(2026, 10, 18, 9, 0, 0, 0, time.Local)
+	entries := []types.CodexUsageEntry{
+		// 09:00-09:10 and 09:15-09:20 are joined over a 5 minute gap
+		mkTurn("gpt-5", day.Add(10*time.Minute), 10*time.Minute, 1),
+		mkTurn("gpt-5", day.Add(20*time.Minute), 5*time.Minute, 1),
+		// An hour later is a separate 10 minute stretch
+		mkTurn("gpt-5", day.Add(90*time.Minute), 10*time.Minute, 1),
+		// 23:50-00:10 is split across midnight
+		mkTurn("gpt-5", day.Add(15*time.Hour+10*time.Minute), 20*time.Minute, 2),
+	}
+
+	days := AggregateDailyActivity(entries, 15*time.Minute)
+	if len(days) != 2 {
+		t.Fatalf("expected 2 days, got %+v", days)
+	}
+	first := days[0]
+	if first.ActiveMs != (40 * time.Minute).Milliseconds() || first.Turns != 3 || first.TotalCost != 3 {
+		t.Errorf("unexpected first day %+v", first)
+	}
+	if first.CostPerActiveHour != 4.5 {
+		t.Errorf("expected $4.50 per active hour, got %v", first.CostPerActiveHour)
+	}
+	if days[1].ActiveMs != (10 * time.Minute).Milliseconds() || days[1].TotalCost != 2 {
+		t.Errorf("unexpected second day %+v", days[1])
+	}
+}
@@ -1,192 +1,127 @@
 package utils
 
 import (
-	"sort"
+	"strings"
 	"time"
 
+	"github.com/johanneserhardt/cxusage/internal/aggregate"
 	"github.com/johanneserhardt/cxusage/internal/types"
 )
 
 // AggregateDailyUsage aggregates usage entries into daily summaries
 func AggregateDailyUsage(entries []APIUsageEntry) []types.DailyUsage {
-    dailyMap := make(map[string]*types.DailyUsage)
-
-    for _, entry := range entries {
-        // Group by local calendar day for user-facing correctness
-        date := time.Unix(entry.Created, 0).In(time.Local).Format("2006-01-02")
-		
-		if _, exists := dailyMap[date]; !exists {
-			dailyMap[date] = &types.DailyUsage{
-				Date:        date,
-				ModelUsage:  make(map[string]types.Usage),
-				ModelCosts:  make(map[string]float64),
-			}
-		}
-
-		daily := dailyMap[date]
-		daily.RequestCount++
-		daily.TotalTokens += entry.Usage.TotalTokens
-		daily.TotalCost += entry.Cost
+	return aggregateDaily(codexEntriesFromAPI(entries), nil)
+}
 
-		// Update model-specific usage
-		if modelUsage, exists := daily.ModelUsage[entry.Model]; exists {
-			modelUsage.PromptTokens += entry.Usage.PromptTokens
-			modelUsage.CompletionTokens += entry.Usage.CompletionTokens
-			modelUsage.TotalTokens += entry.Usage.TotalTokens
-			daily.ModelUsage[entry.Model] = modelUsage
-		} else {
-			daily.ModelUsage[entry.Model] = types.Usage{
-				PromptTokens:     entry.Usage.PromptTokens,
-				CompletionTokens: entry.Usage.CompletionTokens,
-				TotalTokens:      entry.Usage.TotalTokens,
-			}
-		}
+// AggregateMonthlyUsage aggregates usage entries into monthly summaries
+func AggregateMonthlyUsage(entries []APIUsageEntry) []types.MonthlyUsage {
+	return aggregateMonthly(codexEntriesFromAPI(entries), nil)
+}
 
-		daily.ModelCosts[entry.Model] += entry.Cost
+// AggregateSessionUsage aggregates usage entries into session summaries (one per hour)
+func AggregateSessionUsage(entries []types.CodexUsageEntry) []types.SessionUsage {
+	var sessionUsage []types.SessionUsage
+	for _, group := range aggregate.GroupBy(entries, []types.Dimension{types.DimensionHour}) {
+		sessionUsage = append(sessionUsage, types.SessionUsage{
+			SessionID:    group.Values[0],
+			StartTime:    group.FirstSeen,
+			EndTime:      group.LastSeen,
+			Duration:     group.LastSeen.Sub(group.FirstSeen),
+			TotalCost:    group.TotalCost,
+			TotalTokens:  group.TotalTokens,
+			RequestCount: group.RequestCount,
+			ModelUsage:   group.ModelUsage,
+			ModelCosts:   group.ModelCosts,
+		})
 	}
+	return sessionUsage
+}
 
-	// Convert map to sorted slice
-	var dailyUsage []types.DailyUsage
-	for _, daily := range dailyMap {
-		dailyUsage = append(dailyUsage, *daily)
+// aggregateDaily groups entries by local calendar day, and by the split dimensions (user,
+// agent, provider) when requested. Days are sorted by date, then the split values.
+func aggregateDaily(entries []types.CodexUsageEntry, split []types.Dimension) []types.DailyUsage {
+	dims := append([]types.Dimension{types.DimensionDay}, split...)
+
+	dailyUsage := []types.DailyUsage{}
+	for _, group := range aggregate.GroupBy(entries, dims) {
+		day := dailyFromTotals(group.Keys[types.DimensionDay], group.UsageTotals)
+		day.User = group.Keys[types.DimensionUser]
+		day.Agent = group.Keys[types.DimensionAgent]
+		day.Provider = group.Keys[types.DimensionProvider]
+		dailyUsage = append(dailyUsage, day)
 	}
-
-	// Sort by date
-	sort.Slice(dailyUsage, func(i, j int) bool {
-		return dailyUsage[i].Date < dailyUsage[j].Date
-	})
-
 	return dailyUsage
 }
 
-// AggregateMonthlyUsage aggregates usage entries into monthly summaries
-func AggregateMonthlyUsage(entries []APIUsageEntry) []types.MonthlyUsage {
-    monthlyMap := make(map[string]*types.MonthlyUsage)
-
-    for _, entry := range entries {
-        // Group by local calendar month for user-facing correctness
-        entryTime := time.Unix(entry.Created, 0).In(time.Local)
-        month := entryTime.Format("2006-01")
-		
-		if _, exists := monthlyMap[month]; !exists {
-			monthlyMap[month] = &types.MonthlyUsage{
-				Month:          month,
-				ModelUsage:     make(map[string]types.Usage),
-				ModelCosts:     make(map[string]float64),
-				DailyBreakdown: []types.DailyUsage{},
-			}
-		}
-
-		monthly := monthlyMap[month]
-		monthly.RequestCount++
-		monthly.TotalTokens += entry.Usage.TotalTokens
-		monthly.TotalCost += entry.Cost
-
-		// Update model-specific usage
-		if modelUsage, exists := monthly.ModelUsage[entry.Model]; exists {
-			modelUsage.PromptTokens += entry.Usage.PromptTokens
-			modelUsage.CompletionTokens += entry.Usage.CompletionTokens
-			modelUsage.TotalTokens += entry.Usage.TotalTokens
-			monthly.ModelUsage[entry.Model] = modelUsage
-		} else {
-			monthly.ModelUsage[entry.Model] = types.Usage{
-				PromptTokens:     entry.Usage.PromptTokens,
-				CompletionTokens: entry.Usage.CompletionTokens,
-				TotalTokens:      entry.Usage.TotalTokens,
-			}
-		}
-
-		monthly.ModelCosts[entry.Model] += entry.Cost
+// aggregateMonthly groups entries by local calendar month (and the split dimensions when
+// requested), with a daily breakdown for each month
+func aggregateMonthly(entries []types.CodexUsageEntry, split []types.Dimension) []types.MonthlyUsage {
+	dims := append([]types.Dimension{types.DimensionMonth}, split...)
+
+	monthlyUsage := []types.MonthlyUsage{}
+	index := make(map[string]int)
+	for _, group := range aggregate.GroupBy(entries, dims) {
+		index[strings.Join(group.Values, "|")] = len(monthlyUsage)
+		monthlyUsage = append(monthlyUsage, types.MonthlyUsage{
+			Month:          group.Keys[types.DimensionMonth],
+			User:           group.Keys[types.DimensionUser],
+			Agent:          group.Keys[types.DimensionAgent],
+			Provider:       group.Keys[types.DimensionProvider],
+			TotalCost:      group.TotalCost,
+			TotalTokens:    group.TotalTokens,
+			RequestCount:   group.RequestCount,
+			DailyBreakdown: []types.DailyUsage{},
+			ModelUsage:     group.ModelUsage,
+			ModelCosts:     group.ModelCosts,
+			Estimation:     group.Estimation,
+		})
 	}
 
-	// Generate daily breakdown for each month
-    for month, monthly := range monthlyMap {
-        // Build local month bounds [start, end] inclusive
-        startTimeUTC, _ := time.Parse("2006-01", month)
-        // Convert bounds to local to align with local grouping
-        startLocal := time.Date(startTimeUTC.Year(), startTimeUTC.Month(), 1, 0, 0, 0, 0, time.Local)
-        // Inclusive end at last second of month in local time
-        endLocal := startLocal.AddDate(0, 1, 0).Add(-time.Second)
-
-        monthEntries := filterEntriesByDateRange(entries, startLocal, endLocal)
-        monthly.DailyBreakdown = AggregateDailyUsage(monthEntries)
-    }
-
-	// Convert map to sorted slice
-	var monthlyUsage []types.MonthlyUsage
-	for _, monthly := range monthlyMap {
-		monthlyUsage = append(monthlyUsage, *monthly)
+	// Days are grouped within each month, so their values start with the month's values
+	dayDims := append(append([]types.Dimension{}, dims...), types.DimensionDay)
+	for _, group := range aggregate.GroupBy(entries, dayDims) {
+		monthly := &monthlyUsage[index[strings.Join(group.Values[:len(dims)], "|")]]
+		day := dailyFromTotals(group.Keys[types.DimensionDay], group.UsageTotals)
+		day.User = group.Keys[types.DimensionUser]
+		day.Agent = group.Keys[types.DimensionAgent]
+		day.Provider = group.Keys[types.DimensionProvider]
+		monthly.DailyBreakdown = append(monthly.DailyBreakdown, day)
 	}
 
-	// Sort by month
-	sort.Slice(monthlyUsage, func(i, j int) bool {
-		return monthlyUsage[i].Month < monthlyUsage[j].Month
-	})
-
 	return monthlyUsage
 }
 
-// AggregateSessionUsage aggregates usage entries into session summaries
-func AggregateSessionUsage(entries []types.CodexUsageEntry) []types.SessionUsage {
-	sessionMap := make(map[string]*types.SessionUsage)
+// dailyFromTotals converts the totals of a day into a daily summary
+func dailyFromTotals(date string, totals types.UsageTotals) types.DailyUsage {
+	return types.DailyUsage{
+		Date:         date,
+		TotalCost:    totals.TotalCost,
+		TotalTokens:  totals.TotalTokens,
+		RequestCount: totals.RequestCount,
+		ModelUsage:   totals.ModelUsage,
+		ModelCosts:   totals.ModelCosts,
+		Estimation:   totals.Estimation,
+	}
+}
 
+// codexEntriesFromAPI converts API usage entries for the aggregation engine
+func codexEntriesFromAPI(entries []APIUsageEntry) []types.CodexUsageEntry {
+	codexEntries := make([]types.CodexUsageEntry, 0, len(entries))
 	for _, entry := range entries {
-		// Generate session ID from timestamp (group by hour)
-		sessionID := entry.Timestamp.Truncate(time.Hour).Format("2006-01-02T15")
-		
-		if _, exists := sessionMap[sessionID]; !exists {
-			sessionMap[sessionID] = &types.SessionUsage{
-				SessionID:   sessionID,
-				StartTime:   entry.Timestamp,
-				EndTime:     entry.Timestamp,
-				ModelUsage:  make(map[string]types.Usage),
-				ModelCosts:  make(map[string]float64),
-			}
-		}
-
-		session := sessionMap[sessionID]
-		session.RequestCount++
-		session.TotalTokens += entry.Usage.TotalTokens
-		session.TotalCost += entry.Cost
-
-		// Update time range
-		if entry.Timestamp.Before(session.StartTime) {
-			session.StartTime = entry.Timestamp
-		}
-		if entry.Timestamp.After(session.EndTime) {
-			session.EndTime = entry.Timestamp
-		}
-
-		// Update model-specific usage
-		if modelUsage, exists := session.ModelUsage[entry.Model]; exists {
-			modelUsage.PromptTokens += entry.Usage.PromptTokens
-			modelUsage.CompletionTokens += entry.Usage.CompletionTokens
-			modelUsage.TotalTokens += entry.Usage.TotalTokens
-			session.ModelUsage[entry.Model] = modelUsage
-		} else {
-			session.ModelUsage[entry.Model] = types.Usage{
+		codexEntries = append(codexEntries, types.CodexUsageEntry{
+			Timestamp: time.Unix(entry.Created, 0),
+			RequestID: entry.ID,
+			Model:     entry.Model,
+			Usage: types.Usage{
 				PromptTokens:     entry.Usage.PromptTokens,
 				CompletionTokens: entry.Usage.CompletionTokens,
 				TotalTokens:      entry.Usage.TotalTokens,
-			}
-		}
-
-		session.ModelCosts[entry.Model] += entry.Cost
-	}
-
-	// Calculate durations and convert to slice
-	var sessionUsage []types.SessionUsage
-	for _, session := range sessionMap {
-		session.Duration = session.EndTime.Sub(session.StartTime)
-		sessionUsage = append(sessionUsage, *session)
+			},
+			Cost: entry.Cost,
+			User: entry.User,
+		})
 	}
-
-	// Sort by start time
-	sort.Slice(sessionUsage, func(i, j int) bool {
-		return sessionUsage[i].StartTime.Before(sessionUsage[j].StartTime)
-	})
-
-	return sessionUsage
+	return codexEntries
 }
 
 // filterEntriesByDateRange filters usage entries by date range
@@ -4,86 +4,116 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/johanneserhardt/cxusage/internal/aggregate"
+	"github.com/johanneserhardt/cxusage/internal/blocks"
 	"github.com/johanneserhardt/cxusage/internal/codex"
+	"github.com/johanneserhardt/cxusage/internal/pricing"
 	"github.com/johanneserhardt/cxusage/internal/types"
 	"github.com/sirupsen/logrus"
 )
 
-// LoadDailyUsageFromCodex loads daily usage data from Codex CLI local files
-func LoadDailyUsageFromCodex(cfg *types.Config, startDate, endDate time.Time, logger *logrus.Logger) ([]types.DailyUsage, error) {
-	logger.Info("Loading usage data from Codex CLI local files")
-
-	// Check if Codex directory exists
-	exists, err := codex.CodexDirExists(cfg)
+// LoadDailyUsageFromCodex loads daily usage data from Codex CLI local files, with one row
+// per day and value of the split dimensions (user, agent, provider)
+func LoadDailyUsageFromCodex(cfg *types.Config, startDate, endDate time.Time, logger *logrus.Logger, split ...types.Dimension) ([]types.DailyUsage, error) {
+	entries, err := loadEntriesFromCodex(cfg, startDate, endDate, logger)
 	if err != nil {
-		return nil, fmt.Errorf("failed to check Codex directory: %w", err)
+		return nil, err
 	}
-	if !exists {
-		return nil, fmt.Errorf("Codex CLI directory not found. Make sure Codex CLI is installed and has been used")
+
+	return aggregateDaily(entries, split), nil
+}
+
+// LoadMonthlyUsageFromCodex loads monthly usage data from Codex CLI local files, with one
+// row per month and value of the split dimensions (user, agent, provider)
+func LoadMonthlyUsageFromCodex(cfg *types.Config, startDate, endDate time.Time, logger *logrus.Logger, split ...types.Dimension) ([]types.MonthlyUsage, error) {
+	logger.Info("Loading monthly usage data from Codex CLI local files")
+
+	entries, err := loadEntriesFromCodex(cfg, startDate, endDate, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load daily usage data: %w", err)
 	}
 
-	// Parse usage entries from local files
-	entries, err := codex.ParseUsageFiles(cfg, startDate, endDate, logger)
+	return aggregateMonthly(entries, split), nil
+}
+
+// LoadUsageGroupsFromCodex loads usage grouped by any combination of dimensions
+func LoadUsageGroupsFromCodex(cfg *types.Config, startDate, endDate time.Time, dims []types.Dimension, logger *logrus.Logger) ([]types.UsageGroup, error) {
+	entries, err := loadEntriesFromCodex(cfg, startDate, endDate, logger)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse Codex usage files: %w", err)
+		return nil, err
 	}
 
-	if len(entries) == 0 {
-		return []types.DailyUsage{}, nil // Return empty slice instead of error
+	return aggregate.GroupBy(entries, dims), nil
+}
+
+// LoadActivityFromCodex loads turn latency per model and active working time per day
+func LoadActivityFromCodex(cfg *types.Config, startDate, endDate time.Time, idleGap time.Duration, logger *logrus.Logger) ([]types.TurnLatency, []types.DailyActivity, error) {
+	entries, err := loadEntriesFromCodex(cfg, startDate, endDate, logger)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// Convert to API format for aggregation and calculate costs
-	apiEntries := convertCodexToAPIEntriesWithCosts(entries, logger)
+	return AggregateTurnLatency(entries), AggregateDailyActivity(entries, idleGap), nil
+}
+
+// LoadBlockHistoryFromCodex loads billing blocks of the last days, used as history for projections
+func LoadBlockHistoryFromCodex(cfg *types.Config, days int, sessionDurationHours int, mode types.BlockMode, logger *logrus.Logger) ([]types.SessionBlock, error) {
+	endDate := time.Now()
+	startDate := endDate.AddDate(0, 0, -days)
 
-	return AggregateDailyUsage(apiEntries), nil
+	entries, err := codex.ParseUsageFiles(cfg, startDate, endDate, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Codex usage files: %w", err)
+	}
+
+	return blocks.AggregateIntoBlocksWithMode(entries, sessionDurationHours, mode), nil
 }
 
-// LoadMonthlyUsageFromCodex loads monthly usage data from Codex CLI local files
-func LoadMonthlyUsageFromCodex(cfg *types.Config, startDate, endDate time.Time, logger *logrus.Logger) ([]types.MonthlyUsage, error) {
-	logger.Info("Loading monthly usage data from Codex CLI local files")
+// loadEntriesFromCodex parses Codex CLI local files (and usage bundles) into costed entries
+func loadEntriesFromCodex(cfg *types.Config, startDate, endDate time.Time, logger *logrus.Logger) ([]types.CodexUsageEntry, error) {
+	logger.Info("Loading usage data from Codex CLI local files")
 
-	// First load all daily usage, then aggregate by month
-	dailyUsage, err := LoadDailyUsageFromCodex(cfg, startDate, endDate, logger)
+	// Check if a log directory exists (not needed when only reporting on bundles)
+	exists, err := codex.LogDirExists(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load daily usage data: %w", err)
+		return nil, fmt.Errorf("failed to check Codex directory: %w", err)
+	}
+	if !exists && len(cfg.Bundles) == 0 && cfg.Database == "" {
+		if !codex.AgentEnabled(cfg, types.AgentCodex) {
+			return nil, fmt.Errorf("Claude Code directory not found. Make sure Claude Code is installed and has been used")
+		}
+		return nil, fmt.Errorf("Codex CLI directory not found. Make sure Codex CLI is installed and has been used")
+	}
+
+	// Parse usage entries from local files
+	entries, err := codex.ParseUsageFiles(cfg, startDate, endDate, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Codex usage files: %w", err)
 	}
 
-	// Convert daily usage to monthly aggregation
-	return aggregateDailyToMonthly(dailyUsage), nil
+	fillMissingCosts(entries, codex.LoadPriceTable(cfg), logger)
+	return entries, nil
 }
 
-// convertCodexToAPIEntriesWithCosts converts Codex entries to API format and calculates costs
-func convertCodexToAPIEntriesWithCosts(codexEntries []types.CodexUsageEntry, logger *logrus.Logger) []APIUsageEntry {
-	var apiEntries []APIUsageEntry
-
-	for _, entry := range codexEntries {
-		cost := entry.Cost
-		
-		// Calculate cost if not provided
-		if cost == 0 {
-			calculatedCost, err := CalculateCost(entry.Model, entry.Usage)
-			if err != nil {
-				logger.WithError(err).WithField("model", entry.Model).Debug("Failed to calculate cost")
-			} else {
-				cost = calculatedCost
-			}
+// fillMissingCosts calculates costs of entries that were logged without one, e.g. from
+// bundles or databases; entries of local providers stay free. Entries of models without a
+// price are marked as having an estimated cost, like entries parsed from session files
+func fillMissingCosts(entries []types.CodexUsageEntry, prices *pricing.Table, logger *logrus.Logger) {
+	for i := range entries {
+		if entries[i].Cost != 0 {
+			continue
 		}
-		
-		apiEntry := APIUsageEntry{
-			ID:      entry.RequestID,
-			Model:   entry.Model,
-			Created: entry.Timestamp.Unix(),
-			Usage: APIUsage{
-				PromptTokens:     entry.Usage.PromptTokens,
-				CompletionTokens: entry.Usage.CompletionTokens,
-				TotalTokens:      entry.Usage.TotalTokens,
-			},
-			Cost: cost,
+		cost, ok := prices.Cost(entries[i].Provider, entries[i].Model, entries[i].Usage)
+		if !ok {
+			logger.WithField("model", entries[i].Model).Debug("No pricing available for model")
+			if !entries[i].Estimated {
+				entries[i].Provenance = types.ProvenanceEstimatedCost
+			}
+			continue
 		}
-		apiEntries = append(apiEntries, apiEntry)
+		entries[i].Cost = cost
+		entries[i].Provenance = codex.EntryProvenance(entries[i])
 	}
-
-	return apiEntries
 }
 
 // APIUsageEntry represents usage entry compatible with existing aggregation code
@@ -93,6 +123,7 @@ type APIUsageEntry struct {
 	Created int64     `json:"created"`
 	Usage   APIUsage  `json:"usage"`
 	Cost    float64   `json:"cost"`
+	User    string    `json:"user,omitempty"`
 }
 
 // APIUsage represents token usage compatible with existing aggregation code
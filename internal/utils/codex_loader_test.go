@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"io"
+	"testing"
+
+	"github.com/johanneserhardt/cxusage/internal/pricing"
+	"github.com/johanneserhardt/cxusage/internal/types"
+	"github.com/sirupsen/logrus"
+)
+
+func TestFillMissingCostsSetsProvenance(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	usage := types.Usage{PromptTokens: 1000, CompletionTokens: 100}
+	entries := []types.CodexUsageEntry{
+		// Bundle entry of a priced model: priced now, so its cost is exact
+		{Model: "gpt-5", Usage: usage, Provenance: types.ProvenanceExact},
+		// Model without a price: the cost stays unknown
+		{Model: "unknown-model", Usage: usage, Provenance: types.ProvenanceExact},
+		// Estimated tokens stay estimated whether priced or not
+		{Model: "gpt-5", Usage: usage, Estimated: true, Provenance: types.ProvenanceEstimatedTokens},
+		{Model: "unknown-model", Usage: usage, Estimated: true, Provenance: types.ProvenanceEstimatedTokens},
+		// Entries with a cost are left alone
+		{Model: "unknown-model", Usage: usage, Cost: 1.5, Provenance: types.ProvenanceExact},
+	}
+	fillMissingCosts(entries, pricing.Default(), logger)
+
+	want := []types.Provenance{
+		types.ProvenanceExact,
+		types.ProvenanceEstimatedCost,
+		types.ProvenanceEstimatedTokens,
+		types.ProvenanceEstimatedTokens,
+		types.ProvenanceExact,
+	}
+	for i, e := range entries {
+		if e.Provenance != want[i] {
+			t.Errorf("entry %d (%s): provenance %q, want %q", i, e.Model, e.Provenance, want[i])
+		}
+	}
+	if entries[0].Cost == 0 || entries[1].Cost != 0 || entries[4].Cost != 1.5 {
+		t.Errorf("unexpected costs %v, %v, %v", entries[0].Cost, entries[1].Cost, entries[4].Cost)
+	}
+}
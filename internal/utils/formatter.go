@@ -68,6 +68,21 @@ func FormatCurrency(amount float64) string {
     return fmt.Sprintf("$%.2f", amount)
 }
 
+// FormatEstimated formats the estimated share of a period: "-" when every number is
+// exact, otherwise the percent, never rounded to 0% or 100% unless it is
+func FormatEstimated(est types.Estimation) string {
+	percent := est.EstimatedPercent
+	switch {
+	case est.EstimatedTokens == 0 && est.EstimatedCost == 0 && percent == 0:
+		return "-"
+	case percent < 1:
+		return "~<1%"
+	case percent > 99 && percent < 100:
+		return "~99%"
+	}
+	return fmt.Sprintf("~%.0f%%", percent)
+}
+
 // CreateCcusageStyleTable creates a table matching ccusage's style
 func CreateCcusageStyleTable(headers []string) *tablewriter.Table {
 	table := tablewriter.NewWriter(os.Stdout)
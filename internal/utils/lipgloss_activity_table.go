@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/johanneserhardt/cxusage/internal/types"
+)
+
+// FormatActivityTables shows turn latency per model and active working time per day
+func FormatActivityTables(latency []types.TurnLatency, days []types.DailyActivity) {
+	titleBorder := lipgloss.NewStyle().
+		BorderStyle(tableBorderStyle).
+		BorderForeground(primaryColor).
+		Padding(0, 1).
+		Foreground(primaryColor).
+		Bold(true)
+
+	fmt.Println()
+	fmt.Println(titleBorder.Render("Codex CLI Turn Latency by Model"))
+	fmt.Println()
+
+	if len(latency) == 0 {
+		fmt.Println("No completed turns found")
+	} else {
+		headers := []string{"Model", "Turns", "Median", "P95", "Mean", "Max"}
+		min := []int{12, 6, 7, 7, 7, 7}
+		if isCompact() {
+			min = []int{8, 5, 6, 6, 6, 6}
+		}
+
+		var rows [][]string
+		for _, l := range latency {
+			rows = append(rows, []string{
+				formatModelNameSimple(l.Model),
+				FormatNumber(l.Turns),
+				formatMillis(l.MedianMs),
+				formatMillis(l.P95Ms),
+				formatMillis(l.MeanMs),
+				formatMillis(l.MaxMs),
+			})
+		}
+
+		widths := computeAutoWidths(headers, rows, min)
+		fmt.Println(CreateTable(headers, rows, widths))
+	}
+
+	fmt.Println()
+	fmt.Println(titleBorder.Render("Codex CLI Active Time by Day"))
+	fmt.Println()
+
+	headers := []string{"Date", "Active", "Turns", "Requests", "Cost (USD)", "Cost / Active Hour"}
+	min := []int{10, 7, 6, 8, 10, 10}
+	if isCompact() {
+		min = []int{10, 6, 5, 5, 8, 8}
+	}
+
+	var rows [][]string
+	total := types.DailyActivity{Date: "Total"}
+	for _, d := range days {
+		rows = append(rows, dailyActivityRow(d))
+		total.ActiveMs += d.ActiveMs
+		total.Turns += d.Turns
+		total.RequestCount += d.RequestCount
+		total.TotalCost += d.TotalCost
+	}
+	if total.ActiveMs > 0 {
+		total.CostPerActiveHour = total.TotalCost / (float64(total.ActiveMs) / 3600000)
+	}
+	rows = append(rows, dailyActivityRow(total))
+
+	widths := computeAutoWidths(headers, rows, min)
+	fmt.Println(CreateTable(headers, rows, widths))
+}
+
+// dailyActivityRow formats one day of the active time table
+func dailyActivityRow(d types.DailyActivity) []string {
+	perHour := "-"
+	if d.ActiveMs > 0 {
+		perHour = FormatCurrency(d.CostPerActiveHour)
+	}
+	return []string{
+		d.Date,
+		formatMillis(d.ActiveMs),
+		FormatNumber(d.Turns),
+		FormatNumber(d.RequestCount),
+		FormatCurrency(d.TotalCost),
+		perHour,
+	}
+}
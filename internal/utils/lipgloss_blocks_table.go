@@ -6,19 +6,21 @@ import (
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/johanneserhardt/cxusage/internal/aggregate"
 	"github.com/johanneserhardt/cxusage/internal/blocks"
 	"github.com/johanneserhardt/cxusage/internal/types"
 )
 
-// FormatBlocksTableProper creates a proper blocks table like ccusage
-func FormatBlocksTableProper(sessionBlocks []types.SessionBlock, tokenLimit *int) {
+// FormatBlocksTableProper creates a proper blocks table like ccusage.
+// The projection is shown for the active block; when nil it is calculated with the average burn rate.
+func FormatBlocksTableProper(sessionBlocks []types.SessionBlock, tokenLimit *int, projection *types.BlockProjection) {
 	if len(sessionBlocks) == 0 {
 		fmt.Println("No blocks found")
 		return
 	}
 	
 	// Print title with border
-	title := "Codex CLI Usage Blocks (~estimated, 5-hour periods)"
+	title := "Codex CLI Usage Blocks (5-hour periods)"
 	titleBorder := lipgloss.NewStyle().
 		BorderStyle(tableBorderStyle).
 		BorderForeground(primaryColor).
@@ -31,11 +33,12 @@ func FormatBlocksTableProper(sessionBlocks []types.SessionBlock, tokenLimit *int
 	fmt.Println()
 	
     // Define headers and build rows
-    headers := []string{"Block Start", "Status", "Duration", "Requests", "Input", "Output", "Total Tokens", "Cost (USD)", "Models"}
+    headers := []string{"Block Start", "Status", "Duration", "Requests", "Input", "Output", "Total Tokens", "Cost (USD)", "Est.", "Models"}
 	
 	var rows [][]string
 	var totalCost float64
 	var totalRequests, totalTokens int
+	var totalEst types.Estimation
 	activeBlockFound := false
 	
 	for _, block := range sessionBlocks {
@@ -64,6 +67,7 @@ func FormatBlocksTableProper(sessionBlocks []types.SessionBlock, tokenLimit *int
 		
 		// Create row
 		row := []string{
+			block.User,
 			block.StartTime.Format("2006-01-02 15:04"),
 			status,
 			duration,
@@ -72,6 +76,7 @@ func FormatBlocksTableProper(sessionBlocks []types.SessionBlock, tokenLimit *int
 			FormatNumber(block.OutputTokens),
 			tokensStr,
 			FormatCurrency(block.TotalCost),
+			FormatEstimated(block.Estimation),
 			modelsStr,
 		}
 		rows = append(rows, row)
@@ -80,11 +85,15 @@ func FormatBlocksTableProper(sessionBlocks []types.SessionBlock, tokenLimit *int
 			totalCost += block.TotalCost
 			totalRequests += block.RequestCount
 			totalTokens += block.TotalTokens
+			totalEst.EstimatedTokens += block.EstimatedTokens
+			totalEst.EstimatedCost += block.EstimatedCost
 		}
 	}
 	
 	// Add totals row
+	aggregate.SetEstimatedPercent(&totalEst, totalCost, totalTokens)
 	totalRow := []string{
+		"",
 		"TOTAL",
 		"",
 		"",
@@ -93,25 +102,31 @@ func FormatBlocksTableProper(sessionBlocks []types.SessionBlock, tokenLimit *int
 		"",
 		FormatNumber(totalTokens),
 		FormatCurrency(totalCost),
+		FormatEstimated(totalEst),
 		"",
 	}
 	rows = append(rows, totalRow)
 	
     // Autosize widths
-    min := []int{14, 8, 8, 7, 7, 7, 10, 10, 10}
+    min := []int{14, 8, 8, 7, 7, 7, 10, 10, 4, 10}
     if isCompact() {
-        min = []int{12, 6, 7, 6, 6, 6, 9, 9, 8}
+        min = []int{12, 6, 7, 6, 6, 6, 9, 9, 4, 8}
     }
+    byUser := sessionBlocks[0].User != ""
+    headers, rows, min = withUserColumn(headers, rows, min, byUser)
     widths := computeAutoWidths(headers, rows, min)
     // Render the table
     table := CreateTable(headers, rows, widths)
     fmt.Println(table)
 	
-	// Show active block projection if found
-	if activeBlockFound {
+	// Show active block projection if found (blocks of several users have no single active block)
+	if activeBlockFound && !byUser {
 		if activeBlock := blocks.GetActiveBlock(sessionBlocks); activeBlock != nil {
 			fmt.Println()
-			showActiveBlockProjectionProper(activeBlock)
+			if projection == nil {
+				projection = blocks.CalculateProjections(activeBlock)
+			}
+			showActiveBlockProjectionProper(activeBlock, projection)
 		}
 	}
 }
@@ -166,8 +181,7 @@ func formatModelsListSimple(models []string) string {
 }
 
 // showActiveBlockProjectionProper shows projections with proper table formatting
-func showActiveBlockProjectionProper(block *types.SessionBlock) {
-	projection := blocks.CalculateProjections(block)
+func showActiveBlockProjectionProper(block *types.SessionBlock, projection *types.BlockProjection) {
 	if projection == nil {
 		return
 	}
@@ -192,7 +206,21 @@ func showActiveBlockProjectionProper(block *types.SessionBlock) {
 		FormatNumber(projection.ProjectedTokens),
 		FormatCurrency(projection.ProjectedCost))
 		
-	fmt.Printf("Burn rate: %.1f tokens/min\n", projection.BurnRate)
+	fmt.Printf("Likely range: %s - %s tokens, %s - %s\n",
+		FormatNumber(projection.ProjectedTokensLow),
+		FormatNumber(projection.ProjectedTokensHigh),
+		FormatCurrency(projection.ProjectedCostLow),
+		FormatCurrency(projection.ProjectedCostHigh))
+		
+	fmt.Printf("Burn rate: %.1f tokens/min (%s)\n", projection.BurnRate, projection.Strategy)
+	
+	if projection.LimitReachedAt != nil {
+		fmt.Printf("%s %s at %s (in %s)\n",
+			Red("⏰"),
+			FormatLimitType(projection.LimitType),
+			projection.LimitReachedAt.Local().Format("15:04"),
+			FormatCountdown(time.Until(*projection.LimitReachedAt)))
+	}
 	
 	// Show warning if projection is high
 	if projection.ProjectedCost > 5.0 {
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/johanneserhardt/cxusage/internal/types"
+)
+
+// FormatConfigTable shows the effective configuration with the source of each value
+func FormatConfigTable(configFile string, values []types.ConfigValue) {
+	title := "cxusage Configuration"
+	titleBorder := lipgloss.NewStyle().
+		BorderStyle(tableBorderStyle).
+		BorderForeground(primaryColor).
+		Padding(0, 1).
+		Foreground(primaryColor).
+		Bold(true)
+
+	fmt.Println()
+	fmt.Println(titleBorder.Render(title))
+	fmt.Println()
+
+	if configFile != "" {
+		fmt.Printf("Config file: %s\n", configFile)
+	} else {
+		fmt.Printf("Config file: %s\n", Yellow("none found (run 'cxusage config init' to create one)"))
+	}
+	fmt.Println()
+
+	headers := []string{"Key", "Value", "Source", "Environment Variable"}
+	min := []int{12, 10, 7, 12}
+
+	var rows [][]string
+	overridden := 0
+	for _, v := range values {
+		rows = append(rows, []string{v.Key, orDash(FormatConfigValue(v.Value), FormatConfigValue(v.Value)), v.Source, v.Env})
+		if v.Source != "default" {
+			overridden++
+		}
+	}
+
+	// Autosize widths
+	widths := computeAutoWidths(headers, rows, min)
+	table := CreateTable(headers, rows, widths)
+	fmt.Println(table)
+	fmt.Printf("%d of %d keys set by a flag, environment variable or the config file\n", overridden, len(values))
+}
+
+// FormatConfigValue formats a configuration value for display
+func FormatConfigValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []string:
+		return strings.Join(v, ",")
+	case []interface{}:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = fmt.Sprint(item)
+		}
+		return strings.Join(parts, ",")
+	}
+	return fmt.Sprint(value)
+}
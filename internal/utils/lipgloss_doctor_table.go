@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/johanneserhardt/cxusage/internal/types"
+)
+
+// FormatDoctorReport shows a summary of the issues found by each check, followed by up to
+// limit occurrences of each
+func FormatDoctorReport(report *types.DoctorReport, limit int) {
+	// Print title with border
+	title := "Codex CLI Log Diagnostics"
+	titleBorder := lipgloss.NewStyle().
+		BorderStyle(tableBorderStyle).
+		BorderForeground(primaryColor).
+		Padding(0, 1).
+		Foreground(primaryColor).
+		Bold(true)
+
+	fmt.Println()
+	fmt.Println(titleBorder.Render(title))
+	fmt.Println()
+
+	fmt.Printf("Scanned %s files with %s lines: %s sessions, %s entries",
+		FormatNumber(report.Files), FormatNumber(report.Lines), FormatNumber(report.Sessions), FormatNumber(report.Entries))
+	if report.Duplicates > 0 {
+		fmt.Printf(" (%s duplicates counted once)", FormatNumber(report.Duplicates))
+	}
+	fmt.Println()
+	if len(report.Formats) > 0 {
+		var formats []string
+		for name, count := range report.Formats {
+			formats = append(formats, fmt.Sprintf("%s (%s)", name, FormatNumber(count)))
+		}
+		sort.Strings(formats)
+		fmt.Printf("Log formats: %s\n", strings.Join(formats, ", "))
+	}
+	fmt.Println()
+
+	if len(report.Issues) == 0 {
+		fmt.Println(Green("✅ No problems found"))
+		return
+	}
+
+	headers := []string{"Check", "Severity", "Count"}
+	min := []int{16, 8, 6}
+	if isCompact() {
+		min = []int{12, 7, 5}
+	}
+	var rows [][]string
+	for _, summary := range report.Summary {
+		rows = append(rows, []string{string(summary.Check), summary.Severity, FormatNumber(summary.Count)})
+	}
+	widths := computeAutoWidths(headers, rows, min)
+	fmt.Println(CreateTable(headers, rows, widths))
+
+	for _, summary := range report.Summary {
+		fmt.Println()
+		fmt.Println(BoldCyan(string(summary.Check)))
+		shown := 0
+		for _, issue := range report.Issues {
+			if issue.Check != summary.Check {
+				continue
+			}
+			if shown == limit {
+				fmt.Printf("  ... and %d more\n", summary.Count-shown)
+				break
+			}
+			location := issue.File
+			if issue.Line > 0 {
+				location = fmt.Sprintf("%s:%d", issue.File, issue.Line)
+			}
+			fmt.Printf("  %s\n    %s\n", location, issue.Message)
+			shown++
+		}
+	}
+	fmt.Println()
+}
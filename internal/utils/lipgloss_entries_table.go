@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/johanneserhardt/cxusage/internal/types"
+)
+
+// FormatEntriesTable shows individual usage entries with the log line each came from
+func FormatEntriesTable(entries []types.CodexUsageEntry) {
+	if len(entries) == 0 {
+		fmt.Println("No usage data found")
+		return
+	}
+
+	// Print title with border
+	title := "Codex CLI Usage Entries (~ = estimated)"
+	titleBorder := lipgloss.NewStyle().
+		BorderStyle(tableBorderStyle).
+		BorderForeground(primaryColor).
+		Padding(0, 1).
+		Foreground(primaryColor).
+		Bold(true)
+
+	fmt.Println()
+	fmt.Println(titleBorder.Render(title))
+	fmt.Println()
+
+	headers := []string{"Time", "Session", "Model", "Project", "Input", "Output", "Total", "Cost (USD)", "Log Line"}
+	min := []int{19, 8, 8, 8, 6, 6, 6, 8, 12}
+	if isCompact() {
+		min = []int{14, 8, 6, 6, 5, 5, 5, 7, 8}
+	}
+
+	var rows [][]string
+	var totalInput, totalOutput, totalTokens int
+	var totalCost float64
+	for _, entry := range entries {
+		rows = append(rows, entryRow(entry))
+		totalInput += entry.Usage.PromptTokens
+		totalOutput += entry.Usage.CompletionTokens
+		totalTokens += entry.Usage.TotalTokens
+		totalCost += entry.Cost
+	}
+	rows = append(rows, []string{
+		"Total", "", "", "",
+		FormatNumber(totalInput),
+		FormatNumber(totalOutput),
+		FormatNumber(totalTokens),
+		FormatCurrency(totalCost),
+		"",
+	})
+
+	// Autosize widths
+	widths := computeAutoWidths(headers, rows, min)
+	table := CreateTable(headers, rows, widths)
+	fmt.Println(table)
+	fmt.Printf("%s entries\n", FormatNumber(len(entries)))
+}
+
+// entryRow formats the columns of one entry; estimated tokens and costs are marked with ~
+func entryRow(entry types.CodexUsageEntry) []string {
+	total, cost := FormatNumber(entry.Usage.TotalTokens), FormatCurrency(entry.Cost)
+	if entry.Provenance == types.ProvenanceEstimatedTokens {
+		total = "~" + total
+	}
+	if entry.Provenance.CostEstimated() {
+		cost = "~" + cost
+	}
+	return []string{
+		entry.Timestamp.Local().Format("2006-01-02 15:04:05"),
+		shortID(entry.SessionID, entry.EntryID),
+		formatModelNameSimple(entry.Model),
+		orDash(filepath.Base(entry.ProjectPath), entry.ProjectPath),
+		FormatNumber(entry.Usage.PromptTokens),
+		FormatNumber(entry.Usage.CompletionTokens),
+		total,
+		cost,
+		formatLogLocation(entry),
+	}
+}
+
+// shortID shortens a session id (or the redacted entry id) for display
+func shortID(sessionID, entryID string) string {
+	id := sessionID
+	if id == "" {
+		id = entryID
+	}
+	if id == "" {
+		return "-"
+	}
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+// formatLogLocation returns file:line of the log line an entry was parsed from
+func formatLogLocation(entry types.CodexUsageEntry) string {
+	if entry.File == "" {
+		return "-"
+	}
+	return fmt.Sprintf("%s:%d", filepath.Base(entry.File), entry.Line)
+}
+
+func orDash(display, value string) string {
+	if value == "" {
+		return "-"
+	}
+	return display
+}
@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/johanneserhardt/cxusage/internal/types"
+)
+
+func TestEntryRow(t *testing.T) {
+	ts := time.Date(2026, 10, 18, 12, 30, 0, 0, time.Local)
+
+	tests := []struct {
+		name  string
+		entry types.CodexUsageEntry
+		want  []string
+	}{
+		{
+			name: "exact",
+			entry: types.CodexUsageEntry{Timestamp: ts, SessionID: "0199a1b2-c3d4", Model: "gpt-4o", ProjectPath: "/work/app",
+				Usage: types.Usage{PromptTokens: 1200, CompletionTokens: 300, TotalTokens: 1500}, Cost: 0.25,
+				File: "/home/me/.codex/sessions/rollout-a.jsonl", Line: 42, Provenance: types.ProvenanceExact},
+			want: []string{"2026-10-18 12:30:00", "0199a1b2", "gpt-4o", "app", "1,200", "300", "1,500", "$0.25", "rollout-a.jsonl:42"},
+		},
+		{
+			name: "estimated tokens, redacted",
+			entry: types.CodexUsageEntry{Timestamp: ts, EntryID: "abc", Model: "gpt-5",
+				Usage: types.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}, Cost: 0.5, Provenance: types.ProvenanceEstimatedTokens},
+			want: []string{"2026-10-18 12:30:00", "abc", "gpt-5", "-", "10", "5", "~15", "~$0.50", "-"},
+		},
+		{
+			name:  "estimated cost",
+			entry: types.CodexUsageEntry{Timestamp: ts, Usage: types.Usage{TotalTokens: 7}, Cost: 1, Provenance: types.ProvenanceEstimatedCost},
+			want:  []string{"2026-10-18 12:30:00", "-", "", "-", "0", "0", "7", "~$1.00", "-"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := entryRow(tt.entry); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
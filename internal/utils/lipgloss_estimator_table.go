@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/johanneserhardt/cxusage/internal/types"
+)
+
+// FormatCalibrationReport shows the estimator parameters of each model with the error of
+// their estimates against exact usage, before and after calibration
+func FormatCalibrationReport(report *types.CalibrationReport, minSamples int) {
+	// Print title with border
+	title := "Codex CLI Token Estimator Calibration"
+	titleBorder := lipgloss.NewStyle().
+		BorderStyle(tableBorderStyle).
+		BorderForeground(primaryColor).
+		Padding(0, 1).
+		Foreground(primaryColor).
+		Bold(true)
+
+	fmt.Println()
+	fmt.Println(titleBorder.Render(title))
+	fmt.Println()
+
+	if report.Total.Samples == 0 {
+		fmt.Printf("%s\n", Yellow("No sessions with both message text and exact usage found"))
+		fmt.Println()
+		fmt.Printf("Calibration needs logs of Codex CLI versions that record token_count events.\n")
+		return
+	}
+
+	switch {
+	case report.Saved && report.CalibratedAt != nil:
+		fmt.Printf("Calibration of %s, stored in %s\n", report.CalibratedAt.Local().Format("2006-01-02 15:04"), report.Path)
+	case report.CalibratedAt != nil:
+		fmt.Printf("Calibration not stored (dry run or fewer than %d turns)\n", minSamples)
+	default:
+		fmt.Printf("Not calibrated, using the built-in parameters\n")
+	}
+	fmt.Printf("Compared with the exact usage of %s turns; errors are the mean absolute error per\n", FormatNumber(report.Total.Samples))
+	fmt.Printf("turn, bias the error of the sum, in percent of the exact tokens\n")
+	if report.CrossValidated {
+		fmt.Printf("Calibrated errors are cross-validated: each session is estimated with parameters\n")
+		fmt.Printf("fitted to the other sessions\n")
+	}
+	fmt.Println()
+
+	headers := []string{"Model", "Turns", "Input ×", "Overhead", "Output ×", "Error", "Bias", "Calibrated Error", "Calibrated Bias"}
+	min := []int{12, 6, 7, 8, 8, 7, 7, 10, 10}
+	if isCompact() {
+		min = []int{10, 5, 6, 6, 6, 6, 6, 8, 8}
+	}
+
+	var rows [][]string
+	for _, stats := range report.Models {
+		model := stats.Model
+		if model == "" {
+			model = "-"
+		}
+		if !stats.Calibrated && report.CalibratedAt != nil {
+			// Fewer turns than needed: estimated with the parameters of all models
+			model += " *"
+		}
+		rows = append(rows, append([]string{model}, calibrationCells(stats)...))
+	}
+	rows = append(rows, append([]string{"Total"}, calibrationCells(report.Total)...))
+
+	widths := computeAutoWidths(headers, rows, min)
+	fmt.Println(CreateTable(headers, rows, widths))
+	if report.CalibratedAt != nil && len(report.Models) > 0 {
+		fmt.Printf("* fewer than %d turns, estimated with the parameters fitted from all models\n", minSamples)
+	}
+}
+
+// calibrationCells formats the parameter and error columns of a model
+func calibrationCells(stats types.CalibrationStats) []string {
+	return []string{
+		FormatNumber(stats.Samples),
+		fmt.Sprintf("%.2f", stats.Params.InputRatio),
+		FormatNumber(int(stats.Params.RequestOverhead + 0.5)),
+		fmt.Sprintf("%.2f", stats.Params.OutputRatio),
+		fmt.Sprintf("%.1f%%", stats.DefaultError),
+		fmt.Sprintf("%+.1f%%", stats.DefaultBias),
+		fmt.Sprintf("%.1f%%", stats.CalibratedError),
+		fmt.Sprintf("%+.1f%%", stats.CalibratedBias),
+	}
+}
@@ -0,0 +1,170 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/johanneserhardt/cxusage/internal/types"
+)
+
+// FormatRateLimitsTableProper shows the latest plan limits and the history of primary windows.
+// With several sources, the latest limits of each source are shown.
+func FormatRateLimitsTableProper(latest *types.RateLimitSnapshot, latestBySource map[string]*types.RateLimitSnapshot, windows []types.RateLimitWindowUsage) {
+	if latest == nil {
+		fmt.Println("No rate-limit data found")
+		return
+	}
+
+	// Print title with border
+	title := "Codex CLI Plan Rate Limits"
+	titleBorder := lipgloss.NewStyle().
+		BorderStyle(tableBorderStyle).
+		BorderForeground(primaryColor).
+		Padding(0, 1).
+		Foreground(primaryColor).
+		Bold(true)
+
+	fmt.Println()
+	fmt.Println(titleBorder.Render(title))
+	fmt.Println()
+
+	now := time.Now()
+	if len(latestBySource) > 1 {
+		var sources []string
+		for source := range latestBySource {
+			sources = append(sources, source)
+		}
+		sort.Strings(sources)
+		for _, source := range sources {
+			snapshot := latestBySource[source]
+			fmt.Printf("%s - last update: %s\n", BoldCyan(source), snapshot.Timestamp.Local().Format("2006-01-02 15:04:05"))
+			printRateLimitWindowLine("Primary", snapshot.Primary, now)
+			printRateLimitWindowLine("Secondary", snapshot.Secondary, now)
+			fmt.Println()
+		}
+	} else {
+		fmt.Printf("Last update: %s\n", latest.Timestamp.Local().Format("2006-01-02 15:04:05"))
+		printRateLimitWindowLine("Primary", latest.Primary, now)
+		printRateLimitWindowLine("Secondary", latest.Secondary, now)
+		fmt.Println()
+	}
+
+	headers := []string{"First Seen", "Last Seen", "Window", "Resets", "Peak Primary", "Peak Secondary", "Events"}
+	min := []int{16, 16, 6, 16, 8, 8, 6}
+	if isCompact() {
+		min = []int{11, 11, 5, 11, 6, 6, 5}
+	}
+	showSource := len(latestBySource) > 1
+	if showSource {
+		headers = append([]string{"Source"}, headers...)
+		min = append([]int{6}, min...)
+	}
+
+	var rows [][]string
+	for _, window := range windows {
+		resets := "-"
+		if window.ResetsAt != nil {
+			resets = window.ResetsAt.Local().Format("2006-01-02 15:04")
+		}
+		row := []string{
+			window.FirstSeen.Local().Format("2006-01-02 15:04"),
+			window.LastSeen.Local().Format("2006-01-02 15:04"),
+			FormatWindowLabel(window.WindowMinutes),
+			resets,
+			fmt.Sprintf("%.1f%%", window.PeakPrimaryPercent),
+			fmt.Sprintf("%.1f%%", window.PeakSecondaryPercent),
+			FormatNumber(window.SnapshotCount),
+		}
+		if showSource {
+			row = append([]string{window.Source}, row...)
+		}
+		rows = append(rows, row)
+	}
+
+	// Autosize widths
+	widths := computeAutoWidths(headers, rows, min)
+	table := CreateTable(headers, rows, widths)
+	fmt.Println(table)
+}
+
+// printRateLimitWindowLine prints the current state of one rate-limit window
+func printRateLimitWindowLine(name string, window *types.RateLimitWindow, now time.Time) {
+	if window == nil {
+		return
+	}
+
+	used := window.UsedPercent
+	resets := "unknown reset time"
+	if window.ResetsAt != nil {
+		if now.Before(*window.ResetsAt) {
+			resets = fmt.Sprintf("resets in %s (%s)",
+				FormatCountdown(window.ResetsAt.Sub(now)),
+				window.ResetsAt.Local().Format("2006-01-02 15:04"))
+		} else {
+			used = 0
+			resets = "window has reset"
+		}
+	}
+
+	fmt.Printf("%s (%s): %s used, %s\n",
+		name,
+		FormatWindowLabel(window.WindowMinutes),
+		FormatPercentWithColor(used),
+		resets)
+}
+
+// FormatPercentWithColor formats a usage percentage colored by severity
+func FormatPercentWithColor(percent float64) string {
+	text := fmt.Sprintf("%.1f%%", percent)
+	if percent > 80 {
+		return Red(text)
+	} else if percent > 50 {
+		return Yellow(text)
+	}
+	return Green(text)
+}
+
+// FormatCountdown formats a remaining duration as "2h 13m" or "13m"
+func FormatCountdown(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	mins := int(d.Minutes()) % 60
+	if days > 0 {
+		return fmt.Sprintf("%dd %dh", days, hours)
+	}
+	if hours > 0 {
+		return fmt.Sprintf("%dh %dm", hours, mins)
+	}
+	return fmt.Sprintf("%dm", mins)
+}
+
+// FormatLimitType describes which limit an exhaustion estimate refers to
+func FormatLimitType(limitType types.LimitType) string {
+	switch limitType {
+	case types.LimitTypeRateLimit:
+		return "Plan rate limit reached"
+	default:
+		return "Token limit reached"
+	}
+}
+
+// FormatWindowLabel formats a rate-limit window length for display
+func FormatWindowLabel(minutes int) string {
+	switch {
+	case minutes <= 0:
+		return "-"
+	case minutes == 7*24*60:
+		return "weekly"
+	case minutes%(24*60) == 0:
+		return fmt.Sprintf("%dd", minutes/(24*60))
+	case minutes%60 == 0:
+		return fmt.Sprintf("%dh", minutes/60)
+	default:
+		return fmt.Sprintf("%dm", minutes)
+	}
+}
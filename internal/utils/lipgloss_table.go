@@ -9,6 +9,7 @@ import (
     "github.com/charmbracelet/lipgloss"
     xterm "github.com/charmbracelet/x/term"
     runewidth "github.com/mattn/go-runewidth"
+    "github.com/johanneserhardt/cxusage/internal/aggregate"
     "github.com/johanneserhardt/cxusage/internal/types"
 )
 
@@ -195,7 +196,7 @@ func FormatDailyUsageTableProper(dailyUsage []types.DailyUsage) {
 	}
 	
 	// Print title with border like ccusage
-	title := "Codex CLI Token Usage Report - Daily (~estimated)"
+	title := "Codex CLI Token Usage Report - Daily"
 	titleBorder := lipgloss.NewStyle().
 		BorderStyle(tableBorderStyle).
 		BorderForeground(primaryColor).
@@ -208,20 +209,23 @@ func FormatDailyUsageTableProper(dailyUsage []types.DailyUsage) {
 	fmt.Println()
 	
     // Define headers and build rows
-    headers := []string{"Date", "Models", "Input", "Output", "Cache Create", "Cache Read", "Total Tokens", "Cost (USD)"}
+    headers := []string{"Date", "Models", "Input", "Output", "Cache Create", "Cache Read", "Total Tokens", "Cost (USD)", "Est."}
 	
 	var rows [][]string
 	var totalCost float64
-	var totalInput, totalOutput, totalTokens int
+	var totalInput, totalOutput, totalCacheCreate, totalCacheRead, totalTokens int
+	var totalEst types.Estimation
 	
 	// Process each day
 	for _, day := range dailyUsage {
-		var inputTokens, outputTokens int
+		var inputTokens, outputTokens, cacheCreate, cacheRead int
 		var modelsList []string
 		
 		for model, usage := range day.ModelUsage {
 			inputTokens += usage.PromptTokens
 			outputTokens += usage.CompletionTokens
+			cacheCreate += usage.CacheCreationTokens
+			cacheRead += usage.CacheReadTokens
 			modelsList = append(modelsList, model)
 		}
 		
@@ -233,41 +237,59 @@ func FormatDailyUsageTableProper(dailyUsage []types.DailyUsage) {
 		
 		// Create row
 		row := []string{
+			day.User,
+			day.Agent,
+			day.Provider,
 			day.Date,
 			modelsStr,
 			FormatNumber(inputTokens),
 			FormatNumber(outputTokens),
-			"0", // Cache create
-			"0", // Cache read
+			FormatNumber(cacheCreate),
+			FormatNumber(cacheRead),
 			FormatNumber(day.TotalTokens),
 			FormatCurrency(day.TotalCost),
+			FormatEstimated(day.Estimation),
 		}
 		rows = append(rows, row)
 		
+		totalEst.EstimatedTokens += day.EstimatedTokens
+		totalEst.EstimatedCost += day.EstimatedCost
 		totalCost += day.TotalCost
 		totalInput += inputTokens
 		totalOutput += outputTokens
+		totalCacheCreate += cacheCreate
+		totalCacheRead += cacheRead
 		totalTokens += day.TotalTokens
 	}
 	
 	// Add totals row
+	aggregate.SetEstimatedPercent(&totalEst, totalCost, totalTokens)
 	totalRow := []string{
+		"",
+		"",
+		"",
 		"Total",
 		"",
 		FormatNumber(totalInput),
 		FormatNumber(totalOutput),
-		"0",
-		"0", 
+		FormatNumber(totalCacheCreate),
+		FormatNumber(totalCacheRead),
 		FormatNumber(totalTokens),
 		FormatCurrency(totalCost),
+		FormatEstimated(totalEst),
 	}
 	rows = append(rows, totalRow)
 	
     // Autosize column widths based on content and terminal width
-    min := []int{10, 12, 6, 6, 6, 6, 10, 8}
+    min := []int{10, 12, 6, 6, 6, 6, 10, 8, 4}
     if isCompact() {
-        min = []int{8, 10, 5, 5, 5, 5, 9, 8}
+        min = []int{8, 10, 5, 5, 5, 5, 9, 8, 4}
+    }
+    byUser, byAgent, byProvider := dailyUsage[0].User != "", dailyUsage[0].Agent != "", false
+    for _, day := range dailyUsage {
+        byProvider = byProvider || day.Provider != ""
     }
+    headers, rows, min = withGroupColumns(headers, rows, min, splitColumns(byUser, byAgent, byProvider)...)
     widths := computeAutoWidths(headers, rows, min)
     // Render the table
     table := CreateTable(headers, rows, widths)
@@ -282,7 +304,7 @@ func FormatMonthlyUsageTableProper(monthlyUsage []types.MonthlyUsage) {
 	}
 	
 	// Print title with border
-	title := "Codex CLI Token Usage Report - Monthly (~estimated)"
+	title := "Codex CLI Token Usage Report - Monthly"
 	titleBorder := lipgloss.NewStyle().
 		BorderStyle(tableBorderStyle).
 		BorderForeground(primaryColor).
@@ -295,11 +317,12 @@ func FormatMonthlyUsageTableProper(monthlyUsage []types.MonthlyUsage) {
 	fmt.Println()
 	
     // Define headers and build rows
-    headers := []string{"Month", "Days Active", "Total Requests", "Input Tokens", "Output Tokens", "Total Tokens", "Total Cost (USD)"}
+    headers := []string{"Month", "Days Active", "Total Requests", "Input Tokens", "Output Tokens", "Total Tokens", "Total Cost (USD)", "Est."}
 	
 	var rows [][]string
 	var totalCost float64
 	var totalRequests, totalInput, totalOutput, totalTokens int
+	var totalEst types.Estimation
 	
 	// Process each month
 	for _, month := range monthlyUsage {
@@ -315,6 +338,9 @@ func FormatMonthlyUsageTableProper(monthlyUsage []types.MonthlyUsage) {
 		}
 		
 		row := []string{
+			month.User,
+			month.Agent,
+			month.Provider,
 			month.Month,
 			strconv.Itoa(activeDays),
 			FormatNumber(month.RequestCount),
@@ -322,9 +348,12 @@ func FormatMonthlyUsageTableProper(monthlyUsage []types.MonthlyUsage) {
 			FormatNumber(outputTokens),
 			FormatNumber(month.TotalTokens),
 			FormatCurrency(month.TotalCost),
+			FormatEstimated(month.Estimation),
 		}
 		rows = append(rows, row)
 		
+		totalEst.EstimatedTokens += month.EstimatedTokens
+		totalEst.EstimatedCost += month.EstimatedCost
 		totalCost += month.TotalCost
 		totalRequests += month.RequestCount
 		totalInput += inputTokens
@@ -333,7 +362,11 @@ func FormatMonthlyUsageTableProper(monthlyUsage []types.MonthlyUsage) {
 	}
 	
 	// Add totals row
+	aggregate.SetEstimatedPercent(&totalEst, totalCost, totalTokens)
 	totalRow := []string{
+		"",
+		"",
+		"",
 		"Total",
 		"",
 		FormatNumber(totalRequests),
@@ -341,20 +374,72 @@ func FormatMonthlyUsageTableProper(monthlyUsage []types.MonthlyUsage) {
 		FormatNumber(totalOutput),
 		FormatNumber(totalTokens),
 		FormatCurrency(totalCost),
+		FormatEstimated(totalEst),
 	}
 	rows = append(rows, totalRow)
 	
     // Autosize widths
-    min := []int{7, 6, 10, 10, 10, 10, 12}
+    min := []int{7, 6, 10, 10, 10, 10, 12, 4}
     if isCompact() {
-        min = []int{6, 5, 8, 8, 8, 8, 10}
+        min = []int{6, 5, 8, 8, 8, 8, 10, 4}
+    }
+    byUser, byAgent, byProvider := monthlyUsage[0].User != "", monthlyUsage[0].Agent != "", false
+    for _, month := range monthlyUsage {
+        byProvider = byProvider || month.Provider != ""
     }
+    headers, rows, min = withGroupColumns(headers, rows, min, splitColumns(byUser, byAgent, byProvider)...)
     widths := computeAutoWidths(headers, rows, min)
     // Render the table
     table := CreateTable(headers, rows, widths)
     fmt.Println(table)
 }
 
+// withUserColumn adds a leading User header when rows are grouped by user.
+// Rows always carry the user as their first cell; it is dropped otherwise.
+func withUserColumn(headers []string, rows [][]string, min []int, byUser bool) ([]string, [][]string, []int) {
+	return withGroupColumns(headers, rows, min, groupColumn{title: "User", min: 6, shown: byUser})
+}
+
+// groupColumn is a leading column of rows grouped by a dimension such as user or provider
+type groupColumn struct {
+	title string
+	min   int
+	shown bool
+}
+
+// withGroupColumns adds leading headers for the shown group columns. Rows always carry one
+// cell per group column first; cells of columns that are not shown are dropped.
+func withGroupColumns(headers []string, rows [][]string, min []int, columns ...groupColumn) ([]string, [][]string, []int) {
+	var groupHeaders []string
+	var groupMin []int
+	for _, c := range columns {
+		if c.shown {
+			groupHeaders = append(groupHeaders, c.title)
+			groupMin = append(groupMin, c.min)
+		}
+	}
+
+	trimmed := make([][]string, len(rows))
+	for i, row := range rows {
+		for j, c := range columns {
+			if c.shown {
+				trimmed[i] = append(trimmed[i], row[j])
+			}
+		}
+		trimmed[i] = append(trimmed[i], row[len(columns):]...)
+	}
+	return append(groupHeaders, headers...), trimmed, append(groupMin, min...)
+}
+
+// splitColumns returns the user, agent and provider columns of daily and monthly rows
+func splitColumns(byUser, byAgent, byProvider bool) []groupColumn {
+	return []groupColumn{
+		{title: "User", min: 6, shown: byUser},
+		{title: "Agent", min: 6, shown: byAgent},
+		{title: "Provider", min: 8, shown: byProvider},
+	}
+}
+
 // padString pads a string to a specific width
 func padString(s string, width int) string {
     // Ensure we measure and trim by display width (handles wide runes)
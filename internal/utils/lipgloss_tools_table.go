@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/johanneserhardt/cxusage/internal/types"
+)
+
+// FormatToolGroupsTable shows tool call statistics grouped by the given dimensions
+func FormatToolGroupsTable(groups []types.ToolGroup, dims []types.Dimension) {
+	if len(groups) == 0 {
+		fmt.Println("No tool calls found")
+		return
+	}
+
+	// Print title with border
+	names := make([]string, len(dims))
+	for i, dim := range dims {
+		names[i] = dimensionTitles[dim]
+	}
+	title := "Codex CLI Tool Calls by " + strings.Join(names, ", ") + " (~estimated output tokens)"
+	titleBorder := lipgloss.NewStyle().
+		BorderStyle(tableBorderStyle).
+		BorderForeground(primaryColor).
+		Padding(0, 1).
+		Foreground(primaryColor).
+		Bold(true)
+
+	fmt.Println()
+	fmt.Println(titleBorder.Render(title))
+	fmt.Println()
+
+	headers := append([]string{}, names...)
+	headers = append(headers, "Calls", "Failed", "Fail %", "Time", "Output Tokens", "Avg", "Max")
+	var min []int
+	for range dims {
+		min = append(min, 6)
+	}
+	if isCompact() {
+		min = append(min, 5, 6, 6, 5, 8, 5, 5)
+	} else {
+		min = append(min, 6, 6, 6, 7, 13, 7, 7)
+	}
+
+	var rows [][]string
+	total := types.ToolGroup{}
+	for _, group := range groups {
+		var row []string
+		for _, value := range group.Values {
+			if value == "" {
+				value = "-"
+			}
+			row = append(row, value)
+		}
+		row = append(row, toolGroupCells(group)...)
+		rows = append(rows, row)
+
+		total.Calls += group.Calls
+		total.Failures += group.Failures
+		total.DurationMs += group.DurationMs
+		total.OutputTokens += group.OutputTokens
+		if group.MaxOutputTokens > total.MaxOutputTokens {
+			total.MaxOutputTokens = group.MaxOutputTokens
+		}
+	}
+	total.FailureRate = float64(total.Failures) / float64(total.Calls) * 100
+
+	totalRow := []string{"Total"}
+	for range dims[1:] {
+		totalRow = append(totalRow, "")
+	}
+	totalRow = append(totalRow, toolGroupCells(total)...)
+	rows = append(rows, totalRow)
+
+	// Autosize widths
+	widths := computeAutoWidths(headers, rows, min)
+	table := CreateTable(headers, rows, widths)
+	fmt.Println(table)
+}
+
+// toolGroupCells formats the statistics columns of a tool group
+func toolGroupCells(group types.ToolGroup) []string {
+	failRate := "-"
+	if group.Failures > 0 {
+		failRate = fmt.Sprintf("%.1f%%", group.FailureRate)
+	}
+	return []string{
+		FormatNumber(group.Calls),
+		FormatNumber(group.Failures),
+		failRate,
+		formatMillis(group.DurationMs),
+		FormatNumber(group.OutputTokens),
+		FormatNumber(group.OutputTokens / group.Calls),
+		FormatNumber(group.MaxOutputTokens),
+	}
+}
+
+// formatMillis formats a duration in milliseconds as "850ms", "12.4s", "3m 20s" or "2h 13m"
+func formatMillis(ms int64) string {
+	switch {
+	case ms == 0:
+		return "-"
+	case ms < 1000:
+		return fmt.Sprintf("%dms", ms)
+	case ms < 60*1000:
+		return fmt.Sprintf("%.1fs", float64(ms)/1000)
+	case ms < 60*60*1000:
+		return fmt.Sprintf("%dm %ds", ms/60000, ms%60000/1000)
+	}
+	return FormatCountdown(time.Duration(ms) * time.Millisecond)
+}
@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/johanneserhardt/cxusage/internal/aggregate"
+	"github.com/johanneserhardt/cxusage/internal/types"
+)
+
+// dimensionTitles are the column headers of group-by dimensions
+var dimensionTitles = map[types.Dimension]string{
+	types.DimensionDay:             "Day",
+	types.DimensionWeek:            "Week",
+	types.DimensionMonth:           "Month",
+	types.DimensionHour:            "Hour",
+	types.DimensionModel:           "Model",
+	types.DimensionProject:         "Project",
+	types.DimensionSession:         "Session",
+	types.DimensionSource:          "Source",
+	types.DimensionUser:            "User",
+	types.DimensionReasoningEffort: "Effort",
+	types.DimensionProvider:        "Provider",
+	types.DimensionAgent:           "Agent",
+	types.DimensionTool:            "Tool",
+	types.DimensionCommand:         "Command",
+}
+
+// FormatUsageGroupsTable shows usage grouped by the given dimensions
+func FormatUsageGroupsTable(groups []types.UsageGroup, dims []types.Dimension) {
+	if len(groups) == 0 {
+		fmt.Println("No usage data found")
+		return
+	}
+
+	// Print title with border
+	names := make([]string, len(dims))
+	for i, dim := range dims {
+		names[i] = dimensionTitles[dim]
+	}
+	title := "Codex CLI Usage by " + strings.Join(names, ", ")
+	titleBorder := lipgloss.NewStyle().
+		BorderStyle(tableBorderStyle).
+		BorderForeground(primaryColor).
+		Padding(0, 1).
+		Foreground(primaryColor).
+		Bold(true)
+
+	fmt.Println()
+	fmt.Println(titleBorder.Render(title))
+	fmt.Println()
+
+	headers := append([]string{}, names...)
+	headers = append(headers, "Requests", "Input", "Output", "Total Tokens", "Cost (USD)", "Est.")
+	var min []int
+	for range dims {
+		min = append(min, 6)
+	}
+	if isCompact() {
+		min = append(min, 5, 6, 6, 8, 8, 4)
+	} else {
+		min = append(min, 8, 8, 8, 12, 10, 4)
+	}
+
+	var rows [][]string
+	total := types.UsageTotals{}
+	for _, group := range groups {
+		var row []string
+		for _, value := range group.Values {
+			if value == "" {
+				value = "-"
+			}
+			row = append(row, value)
+		}
+		row = append(row,
+			FormatNumber(group.RequestCount),
+			FormatNumber(group.InputTokens),
+			FormatNumber(group.OutputTokens),
+			FormatNumber(group.TotalTokens),
+			FormatCurrency(group.TotalCost),
+			FormatEstimated(group.Estimation),
+		)
+		rows = append(rows, row)
+
+		total.RequestCount += group.RequestCount
+		total.InputTokens += group.InputTokens
+		total.OutputTokens += group.OutputTokens
+		total.TotalTokens += group.TotalTokens
+		total.TotalCost += group.TotalCost
+		total.EstimatedTokens += group.EstimatedTokens
+		total.EstimatedCost += group.EstimatedCost
+	}
+	aggregate.SetEstimatedPercent(&total.Estimation, total.TotalCost, total.TotalTokens)
+
+	totalRow := []string{"Total"}
+	for range dims[1:] {
+		totalRow = append(totalRow, "")
+	}
+	totalRow = append(totalRow,
+		FormatNumber(total.RequestCount),
+		FormatNumber(total.InputTokens),
+		FormatNumber(total.OutputTokens),
+		FormatNumber(total.TotalTokens),
+		FormatCurrency(total.TotalCost),
+		FormatEstimated(total.Estimation),
+	)
+	rows = append(rows, totalRow)
+
+	// Autosize widths
+	widths := computeAutoWidths(headers, rows, min)
+	table := CreateTable(headers, rows, widths)
+	fmt.Println(table)
+}
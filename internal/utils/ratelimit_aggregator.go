@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"time"
+
+	"github.com/johanneserhardt/cxusage/internal/blocks"
+	"github.com/johanneserhardt/cxusage/internal/types"
+)
+
+// AggregateRateLimitWindows groups time-ordered snapshots into primary rate-limit windows.
+// Each source is a separate account, so its windows are tracked independently.
+func AggregateRateLimitWindows(snapshots []types.RateLimitSnapshot) []types.RateLimitWindowUsage {
+	var windows []types.RateLimitWindowUsage
+	currentBySource := make(map[string]int)
+
+	for _, snapshot := range snapshots {
+		var resetsAt *time.Time
+		if snapshot.Primary != nil {
+			resetsAt = snapshot.Primary.ResetsAt
+		}
+
+		// Start a new window when the primary reset time moves on
+		idx, ok := currentBySource[snapshot.Source]
+		if !ok || (resetsAt != nil && windows[idx].ResetsAt != nil &&
+			!blocks.SameRateLimitWindow(*resetsAt, *windows[idx].ResetsAt)) {
+			windows = append(windows, types.RateLimitWindowUsage{
+				Source:    snapshot.Source,
+				FirstSeen: snapshot.Timestamp,
+				ResetsAt:  resetsAt,
+			})
+			idx = len(windows) - 1
+			currentBySource[snapshot.Source] = idx
+		}
+		current := &windows[idx]
+
+		current.LastSeen = snapshot.Timestamp
+		current.SnapshotCount++
+		if resetsAt != nil {
+			current.ResetsAt = resetsAt
+		}
+		if snapshot.Primary != nil {
+			if snapshot.Primary.WindowMinutes > 0 {
+				current.WindowMinutes = snapshot.Primary.WindowMinutes
+			}
+			if snapshot.Primary.UsedPercent > current.PeakPrimaryPercent {
+				current.PeakPrimaryPercent = snapshot.Primary.UsedPercent
+			}
+		}
+		if snapshot.Secondary != nil && snapshot.Secondary.UsedPercent > current.PeakSecondaryPercent {
+			current.PeakSecondaryPercent = snapshot.Secondary.UsedPercent
+		}
+	}
+
+	return windows
+}
@@ -0,0 +1,240 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/johanneserhardt/cxusage/internal/blocks"
+	"github.com/johanneserhardt/cxusage/internal/codex"
+	"github.com/johanneserhardt/cxusage/internal/types"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultStatuslineTemplate is the statusline layout used when no template is given
+const DefaultStatuslineTemplate = "🤖 {model} | 💰 {block_cost} block ({remaining} left) | 🔥 {burn_rate}/min | 📅 {today_cost} today | 📁 {project}"
+
+// statuslinePlaceholder matches {name} placeholders in statusline templates
+var statuslinePlaceholder = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+// StatuslinePlaceholders lists the placeholders supported in statusline templates
+var StatuslinePlaceholders = []string{
+	"block_tokens", "block_cost", "projected_cost", "burn_rate", "remaining", "block_end",
+	"today_tokens", "today_cost", "plan_used", "plan_reset", "model", "project",
+}
+
+// BuildStatuslineData computes the statusline values from today's entries and the active block
+func BuildStatuslineData(cfg *types.Config, sessionDurationHours int, mode types.BlockMode, logger *logrus.Logger) (*types.StatuslineData, error) {
+	now := time.Now()
+	todayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	// Load enough history to cover both today and the longest possible active block
+	startDate := now.Add(-time.Duration(sessionDurationHours) * time.Hour)
+	if todayStart.Before(startDate) {
+		startDate = todayStart
+	}
+
+	entries, err := codex.ParseUsageFiles(cfg, startDate, now, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Codex usage files: %w", err)
+	}
+
+	data := &types.StatuslineData{
+		GeneratedAt:          now,
+		SessionDurationHours: sessionDurationHours,
+		BlockMode:            mode,
+	}
+
+	var lastSeen time.Time
+	for _, entry := range entries {
+		if entry.Timestamp.After(lastSeen) && entry.Model != "" {
+			lastSeen = entry.Timestamp
+			data.LastModel = entry.Model
+		}
+		if entry.Timestamp.Before(todayStart) {
+			continue
+		}
+		data.TodayTokens += entry.Usage.TotalTokens
+		data.TodayCost += entry.Cost
+	}
+
+	sessionBlocks := blocks.AggregateIntoBlocksWithMode(entries, sessionDurationHours, mode)
+	if activeBlock := blocks.GetActiveBlock(sessionBlocks); activeBlock != nil {
+		data.BlockActive = true
+		data.BlockTokens = activeBlock.TotalTokens
+		data.BlockCost = activeBlock.TotalCost
+		data.BlockEnd = activeBlock.EndTime
+		if projection := blocks.CalculateProjections(activeBlock); projection != nil {
+			data.BurnRate = projection.BurnRate
+			data.ProjectedCost = projection.ProjectedCost
+		}
+	}
+
+	// Plan limits are optional; a failure here should not break the statusline
+	snapshots, err := codex.ParseRateLimitFiles(cfg, now.Add(-time.Duration(sessionDurationHours)*time.Hour), now, logger)
+	if err != nil {
+		logger.WithError(err).Debug("Failed to load rate-limit data for statusline")
+	} else if latest := codex.LatestRateLimitSnapshot(snapshots); latest != nil && latest.Primary != nil {
+		used := codex.CurrentUsedPercent(latest.Primary, now)
+		data.PlanUsedPercent = &used
+		data.PlanResetsAt = latest.Primary.ResetsAt
+	}
+
+	return data, nil
+}
+
+// RenderStatusline fills the placeholders of a statusline template.
+// Unknown placeholders are left untouched; values that are not available render as "-".
+func RenderStatusline(template string, data *types.StatuslineData, project string, now time.Time) string {
+	values := map[string]string{
+		"block_tokens":   "-",
+		"block_cost":     "-",
+		"projected_cost": "-",
+		"burn_rate":      "-",
+		"remaining":      "-",
+		"block_end":      "-",
+		"today_tokens":   FormatNumber(data.TodayTokens),
+		"today_cost":     formatStatuslineCost(data.TodayCost),
+		"plan_used":      "-",
+		"plan_reset":     "-",
+		"model":          "-",
+		"project":        "-",
+	}
+
+	// The active block may have ended since the data was cached
+	if data.BlockActive && now.Before(data.BlockEnd) {
+		values["block_tokens"] = FormatNumber(data.BlockTokens)
+		values["block_cost"] = formatStatuslineCost(data.BlockCost)
+		values["projected_cost"] = formatStatuslineCost(data.ProjectedCost)
+		values["burn_rate"] = formatStatuslineBurnRate(data.BurnRate)
+		values["remaining"] = FormatCountdown(data.BlockEnd.Sub(now))
+		values["block_end"] = data.BlockEnd.Local().Format("15:04")
+	}
+
+	if data.PlanUsedPercent != nil {
+		used := *data.PlanUsedPercent
+		if data.PlanResetsAt != nil && !now.Before(*data.PlanResetsAt) {
+			used = 0
+		} else if data.PlanResetsAt != nil {
+			values["plan_reset"] = FormatCountdown(data.PlanResetsAt.Sub(now))
+		}
+		values["plan_used"] = FormatPercentWithColor(used)
+	}
+
+	if data.LastModel != "" {
+		values["model"] = Cyan(formatModelNameSimple(data.LastModel))
+	}
+	if project != "" {
+		values["project"] = project
+	}
+
+	return statuslinePlaceholder.ReplaceAllStringFunc(template, func(match string) string {
+		if value, ok := values[match[1:len(match)-1]]; ok {
+			return value
+		}
+		return match
+	})
+}
+
+// formatStatuslineCost formats a cost colored by its size
+func formatStatuslineCost(cost float64) string {
+	text := FormatCurrency(cost)
+	if cost >= 10 {
+		return Red(text)
+	} else if cost >= 5 {
+		return Yellow(text)
+	}
+	return Green(text)
+}
+
+// formatStatuslineBurnRate formats a burn rate (tokens per minute) compactly
+func formatStatuslineBurnRate(rate float64) string {
+	var text string
+	switch {
+	case rate >= 1000000:
+		text = fmt.Sprintf("%.1fM", rate/1000000)
+	case rate >= 1000:
+		text = fmt.Sprintf("%.1fk", rate/1000)
+	default:
+		text = fmt.Sprintf("%.0f", rate)
+	}
+
+	// Same thresholds as the live dashboard
+	if rate > 1000 {
+		return Red(text)
+	} else if rate > 500 {
+		return Yellow(text)
+	}
+	return Green(text)
+}
+
+// StatuslineCachePath returns the cache file for the entries a configuration selects, so
+// statuslines of other sources, agents, bundles, databases or filters are cached separately
+func StatuslineCachePath(cacheDir string, cfg *types.Config) string {
+	selection := *cfg
+	// Logging settings don't change the numbers
+	selection.LogLevel, selection.LocalLogging, selection.LogsDir = "", false, ""
+
+	raw, err := json.Marshal(struct {
+		Config    types.Config
+		CodexHome string
+	}{selection, os.Getenv(codex.CodexHomeEnv)})
+	if err != nil {
+		return filepath.Join(cacheDir, "statusline.json")
+	}
+	sum := sha256.Sum256(raw)
+	return filepath.Join(cacheDir, "statusline-"+hex.EncodeToString(sum[:6])+".json")
+}
+
+// ReadStatuslineCache returns cached statusline data if it is younger than ttl and was built
+// with the same block settings
+func ReadStatuslineCache(path string, ttl time.Duration, sessionDurationHours int, mode types.BlockMode) (*types.StatuslineData, bool) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+
+	var data types.StatuslineData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, false
+	}
+	if time.Since(data.GeneratedAt) > ttl ||
+		data.SessionDurationHours != sessionDurationHours || data.BlockMode != mode {
+		return nil, false
+	}
+
+	return &data, true
+}
+
+// WriteStatuslineCache stores statusline data, replacing the cache file atomically
+func WriteStatuslineCache(path string, data *types.StatuslineData) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode statusline cache: %w", err)
+	}
+
+	// Concurrent statusline invocations each write their own temporary file
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write statusline cache: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write statusline cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write statusline cache: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write statusline cache: %w", err)
+	}
+
+	return nil
+}
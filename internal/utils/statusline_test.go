@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/johanneserhardt/cxusage/internal/types"
+)
+
+func TestRenderStatusline(t *testing.T) {
+	color.NoColor = true
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	used := 42.0
+	resetsAt := now.Add(90 * time.Minute)
+
+	data := &types.StatuslineData{
+		BlockActive:     true,
+		BlockTokens:     12345,
+		BlockCost:       1.5,
+		BlockEnd:        now.Add(2 * time.Hour),
+		BurnRate:        1500,
+		ProjectedCost:   3,
+		LastModel:       "gpt-5-codex",
+		TodayTokens:     50000,
+		TodayCost:       6.25,
+		PlanUsedPercent: &used,
+		PlanResetsAt:    &resetsAt,
+	}
+
+	got := RenderStatusline("{block_tokens} {block_cost} {projected_cost} {burn_rate} {today_tokens} {today_cost} {model} {project} {unknown}", data, "cxusage", now)
+	want := "12,345 $1.50 $3.00 1.5k 50,000 $6.25 codex cxusage {unknown}"
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	// After the block ended and the plan window reset, their values are unavailable
+	later := now.Add(3 * time.Hour)
+	got = RenderStatusline("{block_cost}|{remaining}|{plan_reset}|{plan_used}|{project}", data, "", later)
+	if want := "-|-|-|0.0%|-"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestStatuslineCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "statusline.json")
+	if _, ok := ReadStatuslineCache(path, time.Minute, 5, types.BlockModeFixed); ok {
+		t.Fatalf("expected no cache before the first write")
+	}
+
+	data := &types.StatuslineData{GeneratedAt: time.Now(), SessionDurationHours: 5, BlockMode: types.BlockModeFixed, TodayTokens: 42}
+	if err := WriteStatuslineCache(path, data); err != nil {
+		t.Fatal(err)
+	}
+
+	cached, ok := ReadStatuslineCache(path, time.Minute, 5, types.BlockModeFixed)
+	if !ok || cached.TodayTokens != 42 {
+		t.Fatalf("expected cached data, got %+v (%v)", cached, ok)
+	}
+	if _, ok := ReadStatuslineCache(path, time.Minute, 5, types.BlockModeRolling); ok {
+		t.Fatalf("expected a miss for another block mode")
+	}
+	if _, ok := ReadStatuslineCache(path, time.Minute, 6, types.BlockModeFixed); ok {
+		t.Fatalf("expected a miss for another session duration")
+	}
+
+	data.GeneratedAt = time.Now().Add(-2 * time.Minute)
+	if err := WriteStatuslineCache(path, data); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ReadStatuslineCache(path, time.Minute, 5, types.BlockModeFixed); ok {
+		t.Fatalf("expected expired data to be a miss")
+	}
+}
+
+func TestStatuslineCachePath(t *testing.T) {
+	dir := t.TempDir()
+	base := StatuslineCachePath(dir, &types.Config{})
+
+	if got := StatuslineCachePath(dir, &types.Config{LogLevel: "debug"}); got != base {
+		t.Fatalf("expected the log level not to change the cache path")
+	}
+	for name, cfg := range map[string]*types.Config{
+		"source":   {Sources: []string{"work"}},
+		"agent":    {Agents: []string{"claude"}},
+		"bundle":   {Bundles: []string{"team/alice.json"}},
+		"database": {Database: "usage.db"},
+		"where":    {Where: "model = 'o3'"},
+		"redact":   {Redact: types.RedactModeHash},
+	} {
+		if got := StatuslineCachePath(dir, cfg); got == base {
+			t.Errorf("expected a separate cache path for %s", name)
+		}
+	}
+}